@@ -14,23 +14,29 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"time"
 
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/actuator"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/apis"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/controllers/infraevents"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/controllers/machineset"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/managers/vm"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/tracing"
 	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	"github.com/openshift/machine-api-operator/pkg/controller/machine"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	ctrl "sigs.k8s.io/controller-runtime/pkg/manager/signals"
-	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
 // The default durations for the leader election operations.
@@ -40,84 +46,62 @@ var (
 	retryPeriod   = 20 * time.Second
 )
 
-func main() {
-	var printVersion bool
-	flag.BoolVar(&printVersion, "version", false, "print version and exit")
-
-	// TODO Add relevant flags as written in klog initFlags
-	//klog.InitFlags(nil)
-
-	watchNamespace := flag.String(
-		"namespace",
-		"",
-		"Namespace that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
-	)
-
-	// metricsAddr := flag.String(
-	// 	"metrics-addr",
-	// 	":8081",
-	// 	"The address the metric endpoint binds to.",
-	// )
-
-	healthAddr := flag.String(
-		"health-addr",
-		":9440",
-		"The address for health checking.",
-	)
-
-	leaderElectResourceNamespace := flag.String(
-		"leader-elect-resource-namespace",
-		"",
-		"The namespace of resource object that is used for locking during leader election. If unspecified and running in cluster, defaults to the service account namespace for the controller. Required for leader-election outside of a cluster.",
-	)
-
-	leaderElect := flag.Bool(
-		"leader-elect",
-		false,
-		"Start a leader election client and gain leadership before executing the main loop. Enable this when running replicated components for high availability.",
-	)
-
-	leaderElectLeaseDuration := flag.Duration(
-		"leader-elect-lease-duration",
-		leaseDuration,
-		"The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled.",
-	)
-
-	// TODO Remove this flag when stable
-	flag.Set("logtostderr", "true")
+// tracingShutdownTimeout bounds the export of the buffered spans on exit
+const tracingShutdownTimeout = 5 * time.Second
 
+func main() {
+	opts := newOptions(flag.CommandLine)
 	flag.Parse()
 
-	log := logf.Log.WithName("infracluster-controller-manager")
-	logf.SetLogger(logf.ZapLogger(false))
-	entryLog := log.WithName("entrypoint")
+	// The -v flag sets the verbosity of the controllers logs as well
+	verbosity, err := opts.logVerbosity()
+	if err != nil {
+		klog.Fatalf("Error parsing log verbosity: %v", err)
+	}
+	logger, err := logging.New(opts.logFormat, verbosity)
+	if err != nil {
+		klog.Fatalf("Error setting up logger: %v", err)
+	}
+	logf.SetLogger(logger)
+	entryLog := logf.Log.WithName("infracluster-controller-manager").WithName("entrypoint")
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     opts.tracingExporter,
+		OTLPEndpoint: opts.tracingOTLPEndpoint,
+		OTLPInsecure: opts.tracingOTLPInsecure,
+	})
+	if err != nil {
+		entryLog.Error(err, "Unable to set up tracing")
+		os.Exit(1)
+	}
 
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
-		klog.Fatalf("Error getting configuration: %v", err)
+		entryLog.Error(err, "Unable to get configuration")
+		os.Exit(1)
 	}
 
 	// Setup a Manager
-	opts := manager.Options{
-		LeaderElection:          *leaderElect,
-		LeaderElectionNamespace: *leaderElectResourceNamespace,
+	mgrOpts := manager.Options{
+		LeaderElection:          opts.leaderElect,
+		LeaderElectionNamespace: opts.leaderElectResourceNamespace,
 		LeaderElectionID:        "cluster-api-provider-ovirt-leader",
-		LeaseDuration:           leaderElectLeaseDuration,
+		LeaseDuration:           &opts.leaderElectLeaseDuration,
 		// Disable metrics serving
 		MetricsBindAddress:     "0", // *metricsAddr,
-		HealthProbeBindAddress: *healthAddr,
+		HealthProbeBindAddress: opts.healthAddr,
 		// Slow the default retry and renew election rate to reduce etcd writes at idle: BZ 1858400
 		RetryPeriod:   &retryPeriod,
 		RenewDeadline: &renewDeadline,
 	}
 
-	if *watchNamespace != "" {
-		opts.Namespace = *watchNamespace
-		klog.Infof("Watching machine-api objects only in namespace %q for reconciliation.", opts.Namespace)
+	if opts.watchNamespace != "" {
+		mgrOpts.Namespace = opts.watchNamespace
+		entryLog.Info("Watching machine-api objects only in one namespace for reconciliation", "namespace", mgrOpts.Namespace)
 	}
 
-	mgr, err := manager.New(cfg, opts)
+	mgr, err := manager.New(cfg, mgrOpts)
 	if err != nil {
 		entryLog.Error(err, "Unable to set up overall controller manager")
 		os.Exit(1)
@@ -125,36 +109,69 @@ func main() {
 
 	// Setup Scheme for all resources
 	if err := mapiv1beta1.AddToScheme(mgr.GetScheme()); err != nil {
-		klog.Fatalf("Error setting up scheme: %v", err)
+		entryLog.Error(err, "Error setting up scheme")
+		os.Exit(1)
+	}
+	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
+		entryLog.Error(err, "Error setting up scheme")
+		os.Exit(1)
 	}
 
 	// Initialize tenant-cluster clients
-	kubernetesClient, err := tenantcluster.New(mgr)
+	kubernetesClient, err := tenantcluster.New(mgr, opts.tenantClusterTimeout)
 	if err != nil {
 		entryLog.Error(err, "Failed to create tenantcluster client from configuration")
+		os.Exit(1)
 	}
 
-	// Initialize provider vm manager (infraClusterClientBuilder would be the function infracluster.New)
-	providerVM := vm.New(infracluster.New, kubernetesClient)
+	eventRecorder := mgr.GetEventRecorderFor("kubevirtcontroller")
+
+	infraClusterClientBuilder := infracluster.NewBuilder(opts.infraClusterTimeout)
+
+	// Initialize provider vm manager
+	providerVM := vm.New(infraClusterClientBuilder, kubernetesClient, eventRecorder)
 
 	// Initialize machine actuator.
-	machineActuator := actuator.New(providerVM, mgr.GetEventRecorderFor("kubevirtcontroller"))
+	machineActuator := actuator.New(providerVM, eventRecorder)
 
 	// Register Actuator on machine-controller
 	if err := machine.AddWithActuator(mgr, machineActuator); err != nil {
-		klog.Fatalf("Error adding actuator: %v", err)
+		entryLog.Error(err, "Error adding actuator")
+		os.Exit(1)
+	}
+
+	// Register the machine set controller, which publishes the capacity of machine sets for the cluster autoscaler
+	if err := machineset.Add(mgr, machineset.New(kubernetesClient, eventRecorder)); err != nil {
+		entryLog.Error(err, "Error adding machine set controller")
+		os.Exit(1)
+	}
+
+	// Register the infra-cluster events mirror, which reports the failures of the machine VMs on the machines
+	if err := infraevents.Add(mgr, infraevents.New(infraClusterClientBuilder, kubernetesClient, eventRecorder, opts.watchNamespace)); err != nil {
+		entryLog.Error(err, "Error adding infra-cluster events mirror")
+		os.Exit(1)
 	}
 
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
-		klog.Fatal(err)
+		entryLog.Error(err, "Unable to add health check")
+		os.Exit(1)
 	}
 
 	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
-		klog.Fatal(err)
+		entryLog.Error(err, "Unable to add health check")
+		os.Exit(1)
 	}
 
 	// Start the Cmd
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		klog.Fatalf("Error starting manager: %v", err)
+		entryLog.Error(err, "Error starting manager")
+		os.Exit(1)
+	}
+
+	// Export the spans still buffered before exiting
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		entryLog.Error(err, "Unable to shut down tracing")
 	}
 }
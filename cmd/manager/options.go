@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/tracing"
+	"k8s.io/klog"
+)
+
+// options are the command line options of the manager
+type options struct {
+	flagSet                      *flag.FlagSet
+	printVersion                 bool
+	logFormat                    string
+	watchNamespace               string
+	healthAddr                   string
+	leaderElectResourceNamespace string
+	leaderElect                  bool
+	leaderElectLeaseDuration     time.Duration
+	tenantClusterTimeout         time.Duration
+	infraClusterTimeout          time.Duration
+	tracingExporter              string
+	tracingOTLPEndpoint          string
+	tracingOTLPInsecure          bool
+}
+
+// newOptions registers the flags of the manager options, and the klog flags, like -v, on flagSet
+func newOptions(flagSet *flag.FlagSet) *options {
+	o := &options{flagSet: flagSet}
+
+	flagSet.BoolVar(&o.printVersion, "version", false, "print version and exit")
+
+	klog.InitFlags(flagSet)
+	// TODO Remove this flag when stable
+	flagSet.Set("logtostderr", "true")
+
+	flagSet.StringVar(
+		&o.logFormat,
+		"log-format",
+		logging.FormatConsole,
+		fmt.Sprintf("The format of the log entries of the controllers, %q or %q.", logging.FormatConsole, logging.FormatJSON),
+	)
+
+	flagSet.StringVar(
+		&o.watchNamespace,
+		"namespace",
+		"",
+		"Namespace that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
+	)
+
+	// metricsAddr := flag.String(
+	// 	"metrics-addr",
+	// 	":8081",
+	// 	"The address the metric endpoint binds to.",
+	// )
+
+	flagSet.StringVar(
+		&o.healthAddr,
+		"health-addr",
+		":9440",
+		"The address for health checking.",
+	)
+
+	flagSet.StringVar(
+		&o.leaderElectResourceNamespace,
+		"leader-elect-resource-namespace",
+		"",
+		"The namespace of resource object that is used for locking during leader election. If unspecified and running in cluster, defaults to the service account namespace for the controller. Required for leader-election outside of a cluster.",
+	)
+
+	flagSet.BoolVar(
+		&o.leaderElect,
+		"leader-elect",
+		false,
+		"Start a leader election client and gain leadership before executing the main loop. Enable this when running replicated components for high availability.",
+	)
+
+	flagSet.DurationVar(
+		&o.leaderElectLeaseDuration,
+		"leader-elect-lease-duration",
+		leaseDuration,
+		"The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled.",
+	)
+
+	flagSet.DurationVar(
+		&o.tenantClusterTimeout,
+		"tenant-cluster-timeout",
+		tenantcluster.DefaultTimeout,
+		"The timeout of each tenant-cluster API call. 0 disables the timeout.",
+	)
+
+	flagSet.DurationVar(
+		&o.infraClusterTimeout,
+		"infra-cluster-timeout",
+		infracluster.DefaultTimeout,
+		"The timeout of each infra-cluster API call, which stops a hung infra-cluster API server from blocking the reconciliation of machines. 0 disables the timeout.",
+	)
+
+	flagSet.StringVar(
+		&o.tracingExporter,
+		"tracing-exporter",
+		tracing.ExporterNone,
+		fmt.Sprintf("The exporter of the spans of the machine operations and of the cluster API calls: %q, %q to export them to an OTLP gRPC endpoint, or %q to write them to stdout.", tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout),
+	)
+
+	flagSet.StringVar(
+		&o.tracingOTLPEndpoint,
+		"tracing-otlp-endpoint",
+		"",
+		"The host:port of the OTLP gRPC endpoint the spans are exported to. If unspecified, the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or localhost:4317 is used.",
+	)
+
+	flagSet.BoolVar(
+		&o.tracingOTLPInsecure,
+		"tracing-otlp-insecure",
+		false,
+		"Connect to the OTLP endpoint without TLS.",
+	)
+
+	return o
+}
+
+// logVerbosity returns the verbosity set by the klog -v flag, once the flags are parsed
+func (o *options) logVerbosity() (int, error) {
+	return strconv.Atoi(o.flagSet.Lookup("v").Value.String())
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
+	"gotest.tools/assert"
+)
+
+func TestParseOptions(t *testing.T) {
+	cases := []struct {
+		name          string
+		args          []string
+		wantVerbosity int
+		wantFormat    string
+		wantTimeout   time.Duration
+		wantErr       string
+	}{
+		{
+			name:          "Defaults",
+			wantVerbosity: 0,
+			wantFormat:    logging.FormatConsole,
+			wantTimeout:   30 * time.Second,
+		},
+		{
+			name:          "Verbosity, log format and timeout",
+			args:          []string{"-v=2", "-log-format=json", "-infra-cluster-timeout=1m", "-namespace=openshift-machine-api"},
+			wantVerbosity: 2,
+			wantFormat:    logging.FormatJSON,
+			wantTimeout:   time.Minute,
+		},
+		{
+			name:    "Unknown flag",
+			args:    []string{"-unknown"},
+			wantErr: "flag provided but not defined: -unknown",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			flagSet := flag.NewFlagSet("manager", flag.ContinueOnError)
+			flagSet.SetOutput(ioutil.Discard)
+			opts := newOptions(flagSet)
+
+			err := flagSet.Parse(tc.args)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			verbosity, err := opts.logVerbosity()
+			assert.NilError(t, err)
+			assert.Equal(t, verbosity, tc.wantVerbosity)
+			assert.Equal(t, opts.logFormat, tc.wantFormat)
+			assert.Equal(t, opts.infraClusterTimeout, tc.wantTimeout)
+			assert.Equal(t, flagSet.Lookup("logtostderr").Value.String(), "true")
+		})
+	}
+}
@@ -4,24 +4,26 @@ go 1.13
 
 require (
 	github.com/blang/semver v3.5.1+incompatible
-	github.com/gogo/protobuf v1.3.1 // indirect
+	github.com/go-logr/logr v1.2.4
 	github.com/golang/mock v1.2.0
-	github.com/google/gofuzz v1.1.0 // indirect
 	github.com/openshift/machine-api-operator v0.2.1-0.20200402110321-4f3602b96da3
-	golang.org/x/crypto v0.0.0-20191205180655-e7c4368fe9dd // indirect
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.0.1
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
+	go.uber.org/zap v1.10.0
 	gotest.tools v2.2.0+incompatible
-	k8s.io/api v0.18.3
-	k8s.io/apiextensions-apiserver v0.18.0-rc.1 // indirect
-	k8s.io/apimachinery v0.18.3
+	k8s.io/api v0.27.1
+	k8s.io/apimachinery v0.27.1
 	k8s.io/client-go v12.0.0+incompatible
 	// k8s.io/client-go v12.0.0+incompatible
 	k8s.io/klog v1.0.0
-	k8s.io/kube-openapi v0.0.0-20200410145947-61e04a5be9a6 // indirect
-	kubevirt.io/client-go v0.29.0
-	kubevirt.io/containerized-data-importer v1.10.9
+	kubevirt.io/api v1.1.0
+	kubevirt.io/containerized-data-importer-api v1.57.0-alpha1
 	sigs.k8s.io/controller-runtime v0.6.0
 	sigs.k8s.io/controller-tools v0.2.4
-	sigs.k8s.io/yaml v1.2.0
+	sigs.k8s.io/yaml v1.3.0
 )
 
 replace (
@@ -32,7 +34,7 @@ replace (
 	// github.com/openshift/machine-api-operator => github.com/openshift/machine-api-operator v0.2.1-0.20191025120018-fb3724fc7bdf
 	github.com/prometheus/prometheus => github.com/prometheus/prometheus v0.0.0-20190424153033-d3245f150225
 	k8s.io/api => k8s.io/api v0.16.4
-	k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.16.4
+	k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.19.16
 	k8s.io/apimachinery => k8s.io/apimachinery v0.16.4
 	k8s.io/apiserver => k8s.io/apiserver v0.16.4
 	k8s.io/cli-runtime => k8s.io/cli-runtime v0.16.4
@@ -58,7 +60,6 @@ replace (
 	k8s.io/sample-apiserver => k8s.io/sample-apiserver v0.16.4
 	k8s.io/sample-cli-plugin => k8s.io/sample-cli-plugin v0.16.4
 	k8s.io/sample-controller => k8s.io/sample-controller v0.16.4
-	kubevirt.io/client-go => kubevirt.io/client-go v0.29.2
 )
 
 // sigs.k8s.io/controller-runtime 0.6.0
@@ -73,3 +74,20 @@ replace (
 	github.com/operator-framework/api => github.com/operator-framework/api v0.3.5
 	sigs.k8s.io/controller-runtime => sigs.k8s.io/controller-runtime v0.5.2
 )
+
+// kubevirt.io/api v1.1.0 types are built against the kubernetes-1.16.4 pins above, which takes:
+// k8s.io/apiextensions-apiserver v0.19 for the CRD version deprecation fields of its registration,
+// the k8s.io/utils net and pointer helpers it uses with the klog/v2 release still on logr v0.1.0 of controller-runtime,
+// the containerized-data-importer-api release before the k8s.io/api 1.26 types,
+// the openshift/api release of github.com/openshift/machine-api-operator,
+// and the golang.org/x releases still building with the go 1.13 language version of the vendored build.
+replace (
+	github.com/go-logr/logr => github.com/go-logr/logr v0.1.0
+	github.com/openshift/api => github.com/openshift/api v0.0.0-20200127192224-ffde1bfabb9f
+	golang.org/x/net => golang.org/x/net v0.0.0-20200822124328-c89045814202
+	golang.org/x/sys => golang.org/x/sys v0.0.0-20210423185535-09eb48e85fd7
+	golang.org/x/text => golang.org/x/text v0.3.2
+	k8s.io/klog/v2 => k8s.io/klog/v2 v2.0.0
+	k8s.io/utils => k8s.io/utils v0.0.0-20211116205334-6203023598ed
+	kubevirt.io/containerized-data-importer-api => kubevirt.io/containerized-data-importer-api v1.56.0
+)
@@ -18,15 +18,20 @@ package actuator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/klog"
 
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/managers/vm"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/tracing"
 )
 
 const (
@@ -36,6 +41,7 @@ const (
 	updateEventAction = "Update"
 	deleteEventAction = "Delete"
 	noEventAction     = ""
+	existsOperation   = "Exists"
 )
 
 // Actuator is responsible for performing machine reconciliation.
@@ -52,23 +58,66 @@ func New(providerVM vm.ProviderVM, eventRecorder record.EventRecorder) *Actuator
 	}
 }
 
+// machineContext returns a copy of ctx carrying the logger and the span of operation on machine.
+// The span is the parent of the spans of the cluster clients calls made for the operation.
+func machineContext(ctx context.Context, machine *machinev1.Machine, operation string) (context.Context, trace.Span) {
+	ctx, span := tracing.StartSpan(ctx, "actuator."+operation,
+		tracing.MachineKey.String(vm.GetMachineName(machine)),
+		tracing.NamespaceKey.String(machine.GetNamespace()),
+	)
+	log := logging.FromContext(ctx).WithValues(
+		logging.MachineKey, vm.GetMachineName(machine),
+		logging.NamespaceKey, machine.GetNamespace(),
+		logging.OperationKey, operation,
+	)
+	return logging.IntoContext(ctx, log), span
+}
+
+// endSpan ends the span of an operation, which failed with err unless it's a requeue:
+// the failures of the cluster clients calls requeuing the machine are recorded by their own spans
+func endSpan(span trace.Span, err error) {
+	var requeueErr *machinecontroller.RequeueAfterError
+	if errors.As(err, &requeueErr) {
+		span.SetAttributes(attribute.String("requeueAfter", requeueErr.RequeueAfter.String()))
+		err = nil
+	}
+	tracing.EndSpan(span, err)
+}
+
 // Set corresponding event based on error. It also returns the original error
 // for convenience, so callers can do "return handleMachineError(...)".
-func (a *Actuator) handleMachineError(machine *machinev1.Machine, err error, eventAction string) error {
-	klog.Errorf("%v error: %v", vm.GetMachineName(machine), err)
+func (a *Actuator) handleMachineError(ctx context.Context, machine *machinev1.Machine, err error, eventAction string) error {
+	logging.FromContext(ctx).Error(err, "actuator failed")
 	if eventAction != noEventAction {
 		a.eventRecorder.Eventf(machine, corev1.EventTypeWarning, "Failed"+eventAction, "%v", err)
 	}
+	return machineControllerError(err)
+}
+
+// machineControllerError unwraps the machine controller error wrapped in err, since the machine controller
+// only handles unwrapped errors: it delays the requeue of a RequeueAfterError, and fails a machine of an
+// invalid configuration. The message of a MachineError keeps the context of err.
+func machineControllerError(err error) error {
+	var requeueErr *machinecontroller.RequeueAfterError
+	if errors.As(err, &requeueErr) {
+		return requeueErr
+	}
+	var machineErr *machinecontroller.MachineError
+	if errors.As(err, &machineErr) {
+		return &machinecontroller.MachineError{Reason: machineErr.Reason, Message: err.Error()}
+	}
 	return err
 }
 
 // Create creates a machine and is invoked by the machine controller.
-func (a *Actuator) Create(ctx context.Context, machine *machinev1.Machine) error {
-	klog.Infof("%s: actuator creating machine", vm.GetMachineName(machine))
+func (a *Actuator) Create(ctx context.Context, machine *machinev1.Machine) (resultErr error) {
+	ctx, span := machineContext(ctx, machine, createEventAction)
+	defer func() { endSpan(span, resultErr) }()
+	logging.FromContext(ctx).Info("actuator creating machine")
 
-	if err := a.providerVM.Create(machine); err != nil {
+	if err := a.providerVM.Create(ctx, machine); err != nil {
 		fmtErr := fmt.Errorf(vmsFailFmt, vm.GetMachineName(machine), createEventAction, err)
-		return a.handleMachineError(machine, fmtErr, createEventAction)
+		return a.handleMachineError(ctx, machine, fmtErr, createEventAction)
 	}
 
 	a.eventRecorder.Eventf(machine, corev1.EventTypeNormal, createEventAction, "Created Machine %v", vm.GetMachineName(machine))
@@ -77,24 +126,34 @@ func (a *Actuator) Create(ctx context.Context, machine *machinev1.Machine) error
 
 // Exists determines if the given machine currently exists.
 // A machine which is not terminated is considered as existing.
-func (a *Actuator) Exists(ctx context.Context, machine *machinev1.Machine) (bool, error) {
-	klog.Infof("%s: actuator checking if machine exists", vm.GetMachineName(machine))
+func (a *Actuator) Exists(ctx context.Context, machine *machinev1.Machine) (_ bool, resultErr error) {
+	ctx, span := machineContext(ctx, machine, existsOperation)
+	defer func() { endSpan(span, resultErr) }()
+	logging.FromContext(ctx).Info("actuator checking if machine exists")
 
-	return a.providerVM.Exists(machine)
+	return a.providerVM.Exists(ctx, machine)
 }
 
 // Update attempts to sync machine state with an existing instance.
-func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error {
-	klog.Infof("%s: actuator updating machine", vm.GetMachineName(machine))
+func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) (resultErr error) {
+	ctx, span := machineContext(ctx, machine, updateEventAction)
+	defer func() { endSpan(span, resultErr) }()
+	logging.FromContext(ctx).Info("actuator updating machine")
 
 	if strings.Contains(machine.GetName(), "narg") {
 		return nil
 	}
-	wasUpdated, err := a.providerVM.Update(machine)
+	wasUpdated, err := a.providerVM.Update(ctx, machine)
+	var requeueErr *machinecontroller.RequeueAfterError
+	if errors.As(err, &requeueErr) {
+		// The machine controller only delays a requeue for an unwrapped RequeueAfterError, and
+		// waiting for a pending instance is not a failure
+		logging.FromContext(ctx).Info("actuator requeuing machine", "requeueAfter", requeueErr.RequeueAfter)
+		return requeueErr
+	}
 	if err != nil {
-
 		fmtErr := fmt.Errorf(vmsFailFmt, vm.GetMachineName(machine), updateEventAction, err)
-		return a.handleMachineError(machine, fmtErr, updateEventAction)
+		return a.handleMachineError(ctx, machine, fmtErr, updateEventAction)
 	}
 
 	// Create event only if machine object was modified
@@ -106,12 +165,14 @@ func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error
 }
 
 // Delete deletes a machine and updates its finalizer
-func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) error {
-	klog.Infof("%s: actuator deleting machine", vm.GetMachineName(machine))
+func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) (resultErr error) {
+	ctx, span := machineContext(ctx, machine, deleteEventAction)
+	defer func() { endSpan(span, resultErr) }()
+	logging.FromContext(ctx).Info("actuator deleting machine")
 
-	if err := a.providerVM.Delete(machine); err != nil {
+	if err := a.providerVM.Delete(ctx, machine); err != nil {
 		fmtErr := fmt.Errorf(vmsFailFmt, vm.GetMachineName(machine), deleteEventAction, err)
-		return a.handleMachineError(machine, fmtErr, deleteEventAction)
+		return a.handleMachineError(ctx, machine, fmtErr, deleteEventAction)
 	}
 
 	a.eventRecorder.Eventf(machine, corev1.EventTypeNormal, deleteEventAction, "Deleted machine %v", vm.GetMachineName(machine))
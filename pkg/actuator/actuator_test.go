@@ -1,9 +1,14 @@
 package actuator
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"gotest.tools/assert"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
@@ -20,3 +25,44 @@ func TestHandleMachineErrors(t *testing.T) {
 	// TODO implement
 
 }
+
+func TestMachineControllerError(t *testing.T) {
+	requeueErr := &machinecontroller.RequeueAfterError{RequeueAfter: 20 * time.Second}
+	otherErr := fmt.Errorf(vmsFailFmt, "machine-test", createEventAction, errors.New("failed"))
+
+	cases := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "Unwrap a requeue",
+			err:     fmt.Errorf(vmsFailFmt, "machine-test", createEventAction, requeueErr),
+			wantErr: requeueErr,
+		},
+		{
+			name: "Unwrap an invalid configuration",
+			err: fmt.Errorf(vmsFailFmt, "machine-test", createEventAction,
+				machinecontroller.InvalidMachineConfiguration("machine-test: infra-cluster rejected the request")),
+			wantErr: &machinecontroller.MachineError{
+				Reason:  machinev1.InvalidConfigurationMachineError,
+				Message: "machine-test: kubevirt wrapper failed to Create machine: machine-test: infra-cluster rejected the request",
+			},
+		},
+		{
+			name:    "Return other errors",
+			err:     otherErr,
+			wantErr: otherErr,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := machineControllerError(tc.err)
+			if machineErr, ok := tc.wantErr.(*machinecontroller.MachineError); ok {
+				assert.DeepEqual(t, err, machineErr)
+				return
+			}
+			assert.Equal(t, err, tc.wantErr)
+		})
+	}
+}
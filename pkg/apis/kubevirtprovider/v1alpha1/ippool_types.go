@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IPPool is a range of static addresses, allocated to the machines of its namespace which reference it.
+// The allocations are recorded in the status, so they survive controller restarts.
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=ippools,scope=Namespaced
+type IPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IPPoolSpec   `json:"spec,omitempty"`
+	Status IPPoolStatus `json:"status,omitempty"`
+}
+
+// IPPoolSpec is the address range of an IPPool
+// +k8s:openapi-gen=true
+type IPPoolSpec struct {
+	// CIDR is the subnet of the pool, its addresses are allocated in order
+	CIDR       string   `json:"cidr"`
+	Gateway    string   `json:"gateway,omitempty"`
+	DNSServers []string `json:"dnsServers,omitempty"`
+	// Exclusions are addresses, or ranges of "<first>-<last>" addresses, which aren't allocated
+	Exclusions []string `json:"exclusions,omitempty"`
+}
+
+// IPPoolStatus is the allocation state of an IPPool
+// +k8s:openapi-gen=true
+type IPPoolStatus struct {
+	// Allocations maps the allocated addresses to the names of their machines
+	Allocations map[string]string `json:"allocations,omitempty"`
+}
+
+// IPPoolList contains a list of IPPool
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type IPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPPool `json:"items"`
+}
+
+// IPPoolReference allocates a static address of a machine interface from an IPPool of the machine namespace
+// +k8s:openapi-gen=true
+type IPPoolReference struct {
+	// Name is the name of the IPPool
+	Name string `json:"name"`
+	// Interface is the guest interface of the address, the NetworkConfig interface or enp1s0 by default
+	Interface string `json:"interface,omitempty"`
+}
+
+// IPAddressStatus is a static address allocated to a machine interface
+// +k8s:openapi-gen=true
+type IPAddressStatus struct {
+	Pool      string `json:"pool"`
+	Interface string `json:"interface"`
+	// Address is in CIDR notation, with the prefix length of the pool
+	Address string `json:"address"`
+	// Gateway and DNSServers are the ones of the pool when the address was last synced
+	Gateway    string   `json:"gateway,omitempty"`
+	DNSServers []string `json:"dnsServers,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPPool{}, &IPPoolList{})
+}
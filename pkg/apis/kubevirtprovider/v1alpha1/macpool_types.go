@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MACPool is a range of MAC addresses, allocated to the main interfaces of the machines of its namespace which reference it.
+// The allocations are recorded in the status, so they survive controller restarts.
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=macpools,scope=Namespaced
+type MACPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MACPoolSpec   `json:"spec,omitempty"`
+	Status MACPoolStatus `json:"status,omitempty"`
+}
+
+// MACPoolSpec is the address range of a MACPool, set by either Prefix or Range
+// +k8s:openapi-gen=true
+type MACPoolSpec struct {
+	// Prefix is the prefix of the unicast addresses of the pool, of one to five octets, like "02:00:00"
+	Prefix string `json:"prefix,omitempty"`
+	// Range is the "<first>-<last>" range of the unicast addresses of the pool, like "02:00:00:00:10:00-02:00:00:00:1f:ff"
+	Range string `json:"range,omitempty"`
+}
+
+// MACPoolStatus is the allocation state of a MACPool
+// +k8s:openapi-gen=true
+type MACPoolStatus struct {
+	// Allocations maps the allocated addresses to the names of their machines
+	Allocations map[string]string `json:"allocations,omitempty"`
+}
+
+// MACPoolList contains a list of MACPool
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type MACPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MACPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MACPool{}, &MACPoolList{})
+}
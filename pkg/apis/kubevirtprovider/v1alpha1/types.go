@@ -17,8 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 // KubevirtMachineProviderSpec is the Schema for the KubevirtMachineProviderSpec API
@@ -36,8 +39,237 @@ type KubevirtMachineProviderSpec struct {
 	IgnitionSecretName         string `json:"ignitionSecretName,omitempty"`
 	NetworkName                string `json:"networkName,omitempty"`
 	PersistentVolumeAccessMode string `json:"persistentVolumeAccessMode,omitempty"`
+	// EvictionStrategy is set on the VMI template, "LiveMigrate" keeps the VMI running on infra node drain
+	EvictionStrategy string `json:"evictionStrategy,omitempty"`
+	// RestartPolicy decides how resources and sizing changes are applied to a running VMI, one of Never (default), Manual or Immediate
+	RestartPolicy string `json:"restartPolicy,omitempty"`
+	// RunStrategy is set on the VM, one of Always (default), Halted, Manual or RerunOnFailure
+	RunStrategy string `json:"runStrategy,omitempty"`
+	// Instancetype sizes the VM by an infra-cluster instancetype, instead of RequestedCPU and RequestedMemory
+	Instancetype *InstancetypeMatcher `json:"instancetype,omitempty"`
+	// Preference references the infra-cluster preference of the VM
+	Preference *PreferenceMatcher `json:"preference,omitempty"`
+	// NetworkConfig is rendered to a NetworkManager keyfile in the machine ignition,
+	// or to cloud-init network data with the CloudConfig user data format
+	NetworkConfig *NetworkConfig `json:"networkConfig,omitempty"`
+	// IgnitionFiles are added to the machine ignition
+	IgnitionFiles []IgnitionFile `json:"ignitionFiles,omitempty"`
+	// IgnitionUnits are systemd units added to the machine ignition
+	IgnitionUnits []IgnitionUnit `json:"ignitionUnits,omitempty"`
+	// UserDataFormat is the format of the user data of IgnitionSecretName, one of Ignition (default) or CloudConfig
+	UserDataFormat string `json:"userDataFormat,omitempty"`
+	// CloudInitDataSource is the data source of the VM user data, one of ConfigDrive (default) or NoCloud
+	CloudInitDataSource string `json:"cloudInitDataSource,omitempty"`
+	// SSHKeys are the authorized keys propagated to the machine guest through the VMI access credentials
+	SSHKeys *SSHKeysSource `json:"sshKeys,omitempty"`
+	// IPPools allocate static addresses of the machine interfaces, which are added to their NetworkConfig
+	IPPools []IPPoolReference `json:"ipPools,omitempty"`
+	// MACPool is the name of the MACPool of the machine namespace allocating the stable MAC address of the main interface.
+	// KubeVirt assigns a random MAC address without a MACPool.
+	MACPool string `json:"macPool,omitempty"`
+	// NodeAddressRules classify the addresses of the VMI interfaces and the VM name as node addresses.
+	// By default the addresses of the pod network are dropped, the others are internal IPs, and the VM name is an internal DNS name.
+	NodeAddressRules []NodeAddressRule `json:"nodeAddressRules,omitempty"`
+	// IPFamilies are the address families of the machine, the first is the primary family of the node addresses.
+	// Without IPFamilies, the addresses of both families are reported, IPv4 first.
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+	// PodNetworkIPv6CIDR is the IPv6 network of the VMI on the masqueraded pod network, set when IPFamilies has IPv6,
+	// fd10:0:2::/120 by default
+	PodNetworkIPv6CIDR string `json:"podNetworkIPv6CIDR,omitempty"`
+	// Firmware configures the bootloader, the SMBIOS identity and the QEMU machine type of the VMI
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+	// ReadinessProbe is the readiness probe of the VMI, a running machine instance isn't ready while it fails
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+	// LivenessProbe is the liveness probe of the VMI, the VMI is stopped once it fails
+	LivenessProbe *Probe `json:"livenessProbe,omitempty"`
+	// RestartBudget moves the machine to the Failed phase once its VMI restarts more often than the budget, like a guest
+	// crashing on boot, and the machine is not reconciled anymore.
+	// The restarts of the controller, on RestartPolicy or power state changes, aren't counted.
+	RestartBudget *RestartBudget `json:"restartBudget,omitempty"`
 }
 
+// RestartBudget is the number of VMI restarts a machine tolerates within a time window
+// +k8s:openapi-gen=true
+type RestartBudget struct {
+	// MaxRestarts defaults to 5
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+	// Window defaults to 10 minutes
+	Window *metav1.Duration `json:"window,omitempty"`
+}
+
+// Probe is a health check of the machine VMI, with one handler at most.
+// Without handler, the probe connects to the kubelet port of the node.
+// +k8s:openapi-gen=true
+type Probe struct {
+	HTTPGet   *corev1.HTTPGetAction   `json:"httpGet,omitempty"`
+	TCPSocket *corev1.TCPSocketAction `json:"tcpSocket,omitempty"`
+	// Exec runs a command in the guest through the QEMU guest agent
+	Exec *corev1.ExecAction `json:"exec,omitempty"`
+	// GuestAgentPing succeeds while the QEMU guest agent answers
+	GuestAgentPing bool `json:"guestAgentPing,omitempty"`
+	// InitialDelaySeconds defaults to 900 seconds for the liveness probe, the time a node takes to install and
+	// start its kubelet
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	TimeoutSeconds      int32 `json:"timeoutSeconds,omitempty"`
+	PeriodSeconds       int32 `json:"periodSeconds,omitempty"`
+	// SuccessThreshold can only be 1 for the liveness probe
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// FirmwareConfig is the firmware of the machine VMI
+// +k8s:openapi-gen=true
+type FirmwareConfig struct {
+	// Bootloader is one of BIOS (default) or UEFI
+	Bootloader string `json:"bootloader,omitempty"`
+	// SecureBoot verifies the boot chain of the UEFI bootloader, it requires a q35 machine type
+	SecureBoot bool `json:"secureBoot,omitempty"`
+	// PersistentEFI keeps the UEFI variables across VMI restarts, it requires the UEFI bootloader
+	PersistentEFI bool `json:"persistentEFI,omitempty"`
+	// PersistentTPM adds a virtual TPM device, whose state is kept across VMI restarts
+	PersistentTPM bool `json:"persistentTPM,omitempty"`
+	// SMBIOSIdentity sets the SMBIOS system UUID and serial number of the VMI to the machine UID,
+	// so the guest can identify its machine
+	SMBIOSIdentity bool `json:"smbiosIdentity,omitempty"`
+	// MachineType is the QEMU machine type, like q35, the infra-cluster default otherwise
+	MachineType string `json:"machineType,omitempty"`
+}
+
+const (
+	BootloaderBIOS = "BIOS"
+	BootloaderUEFI = "UEFI"
+)
+
+// NodeAddressRule classifies the addresses of a VMI interface, or the VM name
+// +k8s:openapi-gen=true
+type NodeAddressRule struct {
+	// Interface is the VMI interface, named after its network: "main" for the NetworkName network, "pod-network" for the pod network.
+	// The "hostname" interface is the VM name, which is the guest hostname.
+	Interface string `json:"interface"`
+	// Type is one of InternalIP, ExternalIP or Drop, and one of InternalDNS, ExternalDNS or Drop for the hostname
+	Type string `json:"type"`
+}
+
+const (
+	NodeAddressRuleInternalIP  = "InternalIP"
+	NodeAddressRuleExternalIP  = "ExternalIP"
+	NodeAddressRuleInternalDNS = "InternalDNS"
+	NodeAddressRuleExternalDNS = "ExternalDNS"
+	NodeAddressRuleDrop        = "Drop"
+	// NodeAddressRuleHostname is the interface of the rule of the VM name
+	NodeAddressRuleHostname = "hostname"
+)
+
+// SSHKeysSource references a secret of authorized keys in the machine namespace,
+// it is copied to the VM namespace of the infra-cluster and kept in sync
+// +k8s:openapi-gen=true
+type SSHKeysSource struct {
+	// SecretName is the secret holding the authorized keys, each key of the secret holds one or more keys
+	SecretName string `json:"secretName"`
+	// PropagationMethod is one of QemuGuestAgent or ConfigDrive. By default it is ConfigDrive for CloudConfig
+	// user data in a config drive, and QemuGuestAgent otherwise.
+	PropagationMethod string `json:"propagationMethod,omitempty"`
+	// Users are the guest users authorized by the QemuGuestAgent propagation method, "core" by default for Ignition user data
+	Users []string `json:"users,omitempty"`
+}
+
+const (
+	SSHKeysPropagationMethodQemuGuestAgent = "QemuGuestAgent"
+	// SSHKeysPropagationMethodConfigDrive adds the keys to the config drive metadata, which is read by cloud-init
+	SSHKeysPropagationMethodConfigDrive = "ConfigDrive"
+)
+
+// NetworkConfig is the static network configuration of a machine interface
+// +k8s:openapi-gen=true
+type NetworkConfig struct {
+	// Interface is the guest interface name, enp1s0 by default
+	Interface string `json:"interface,omitempty"`
+	// Addresses are the static addresses of the interface, in CIDR notation
+	Addresses []string `json:"addresses,omitempty"`
+	Gateway   string   `json:"gateway,omitempty"`
+	// Gateways are the gateways of the other address family of a dual-stack interface, at most one gateway per family
+	Gateways   []string `json:"gateways,omitempty"`
+	DNSServers []string `json:"dnsServers,omitempty"`
+}
+
+// IgnitionFile is a file of the machine ignition
+// +k8s:openapi-gen=true
+type IgnitionFile struct {
+	Path string `json:"path"`
+	// Mode is the decimal file mode, 420 (0644) by default
+	Mode        *int          `json:"mode,omitempty"`
+	ContentFrom ContentSource `json:"contentFrom"`
+}
+
+// IgnitionUnit is a systemd unit of the machine ignition
+// +k8s:openapi-gen=true
+type IgnitionUnit struct {
+	Name string `json:"name"`
+	// Enabled defaults to true
+	Enabled     *bool         `json:"enabled,omitempty"`
+	ContentFrom ContentSource `json:"contentFrom"`
+}
+
+// ContentSource references a key of a secret or a config map in the machine namespace
+// +k8s:openapi-gen=true
+type ContentSource struct {
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// InstancetypeMatcher references a KubeVirt instancetype
+// +k8s:openapi-gen=true
+type InstancetypeMatcher struct {
+	Name string `json:"name"`
+	// Kind is VirtualMachineClusterInstancetype (default) or VirtualMachineInstancetype
+	Kind string `json:"kind,omitempty"`
+}
+
+// PreferenceMatcher references a KubeVirt preference
+// +k8s:openapi-gen=true
+type PreferenceMatcher struct {
+	Name string `json:"name"`
+	// Kind is VirtualMachineClusterPreference (default) or VirtualMachinePreference
+	Kind string `json:"kind,omitempty"`
+}
+
+const (
+	ClusterInstancetypeKind = "VirtualMachineClusterInstancetype"
+	InstancetypeKind        = "VirtualMachineInstancetype"
+	ClusterPreferenceKind   = "VirtualMachineClusterPreference"
+	PreferenceKind          = "VirtualMachinePreference"
+)
+
+const (
+	// DefaultRequestedMemory is the memory of a machine without RequestedMemory
+	DefaultRequestedMemory = "2048M"
+	// DefaultRequestedCPU is the number of vCPUs of a machine without RequestedCPU
+	DefaultRequestedCPU = 1
+)
+
+const (
+	// UserDataFormatIgnition is the user data format of RHCOS and Fedora CoreOS guests
+	UserDataFormatIgnition = "Ignition"
+	// UserDataFormatCloudConfig is the user data format of cloud-init guests,
+	// their NetworkConfig is rendered to cloud-init network data
+	UserDataFormatCloudConfig = "CloudConfig"
+)
+
+const (
+	CloudInitDataSourceConfigDrive = "ConfigDrive"
+	// CloudInitDataSourceNoCloud isn't read by Ignition
+	CloudInitDataSourceNoCloud = "NoCloud"
+)
+
+const (
+	// RestartPolicyNever leaves resources changes pending until the VMI is restarted by other means
+	RestartPolicyNever = "Never"
+	// RestartPolicyManual leaves resources changes pending until the machine is annotated with a restart request
+	RestartPolicyManual = "Manual"
+	// RestartPolicyImmediate restarts the VM as soon as resources changes are detected
+	RestartPolicyImmediate = "Immediate"
+)
+
 // KubevirtMachineProviderStatus is the type that will be embedded in a Machine.Status.ProviderStatus field.
 // It contains Kubevirt-specific status information.
 // +k8s:openapi-gen=true
@@ -45,6 +277,71 @@ type KubevirtMachineProviderSpec struct {
 type KubevirtMachineProviderStatus struct {
 	metav1.TypeMeta `json:",inline"`
 	kubevirtapiv1.VirtualMachineStatus
+	// InstanceState is the state of the machine instance, derived from VMIPhase and DataVolumePhase
+	InstanceState   string                                    `json:"instanceState,omitempty"`
+	VMIPhase        kubevirtapiv1.VirtualMachineInstancePhase `json:"vmiPhase,omitempty"`
+	DataVolumePhase cdiv1.DataVolumePhase                     `json:"dataVolumePhase,omitempty"`
+	Migration       *MigrationStatus                          `json:"migration,omitempty"`
+	// IPAddresses are the static addresses allocated to the machine from its IPPools
+	IPAddresses []IPAddressStatus `json:"ipAddresses,omitempty"`
+	// MACAddress is the MAC address of the main interface allocated from MACPool
+	MACAddress string `json:"macAddress,omitempty"`
+	MACPool    string `json:"macPool,omitempty"`
+	// GuestOSInfo is the guest OS reported by the QEMU guest agent of the VMI
+	GuestOSInfo *kubevirtapiv1.VirtualMachineInstanceGuestOSInfo `json:"guestOSInfo,omitempty"`
+	// GuestInterfaces are the VMI interfaces reported by the QEMU guest agent
+	GuestInterfaces []GuestInterfaceStatus `json:"guestInterfaces,omitempty"`
+	// VMIRestarts tracks the restarts of the VMI after failures
+	VMIRestarts *VMIRestartsStatus `json:"vmiRestarts,omitempty"`
+	// Backoff tracks the consecutive failed operations on the machine, retried with an exponential backoff
+	Backoff *BackoffStatus `json:"backoff,omitempty"`
+}
+
+// BackoffStatus tracks the consecutive failed operations on the machine, reset by a successful operation
+// +k8s:openapi-gen=true
+type BackoffStatus struct {
+	// Failures is the number of consecutive failed operations
+	Failures int32 `json:"failures,omitempty"`
+	// Delay is the delay before the next attempt, at least the delay asked for by the infra-cluster
+	Delay metav1.Duration `json:"delay,omitempty"`
+	// NextAttemptTime is the time of the next attempt, the operations on the machine wait until then
+	NextAttemptTime *metav1.Time `json:"nextAttemptTime,omitempty"`
+}
+
+// VMIRestartsStatus tracks the restarts of the machine VMI after failures, counted by the start failures of the VM status
+// +k8s:openapi-gen=true
+type VMIRestartsStatus struct {
+	// LastFailedVMIUID is the UID of the last failed VMI counted
+	LastFailedVMIUID types.UID `json:"lastFailedVMIUID,omitempty"`
+	// ConsecutiveFailCount is the consecutive failures of the VM status when the last failed VMI was counted
+	ConsecutiveFailCount int32 `json:"consecutiveFailCount,omitempty"`
+	// Count is the number of restarts since the machine was created
+	Count int32 `json:"count,omitempty"`
+	// Recent are the times of the restarts within the RestartBudget window
+	Recent []metav1.Time `json:"recent,omitempty"`
+}
+
+// GuestInterfaceStatus is a VMI interface reported by the QEMU guest agent
+// +k8s:openapi-gen=true
+type GuestInterfaceStatus struct {
+	// Network is the VMI network of the interface, empty for guest interfaces without a network, like bridges of containers
+	Network string `json:"network,omitempty"`
+	// InterfaceName is the interface name in the guest
+	InterfaceName string `json:"interfaceName"`
+	MAC           string `json:"mac,omitempty"`
+}
+
+// MigrationStatus describes the last live migration of the machine VMI
+// +k8s:openapi-gen=true
+type MigrationStatus struct {
+	// Name is the VirtualMachineInstanceMigration requested by the controller, empty for migrations started by the infra-cluster
+	Name           string                                             `json:"name,omitempty"`
+	UID            types.UID                                          `json:"uid,omitempty"`
+	Phase          kubevirtapiv1.VirtualMachineInstanceMigrationPhase `json:"phase,omitempty"`
+	SourceNode     string                                             `json:"sourceNode,omitempty"`
+	TargetNode     string                                             `json:"targetNode,omitempty"`
+	StartTimestamp *metav1.Time                                       `json:"startTimestamp,omitempty"`
+	EndTimestamp   *metav1.Time                                       `json:"endTimestamp,omitempty"`
 }
 
 func init() {
@@ -17,15 +17,31 @@ limitations under the License.
 package infracluster
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/tracing"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/utils"
 	machineapiapierrors "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	corev1 "k8s.io/api/core/v1"
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
-	"kubevirt.io/client-go/kubecli"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 //go:generate mockgen -source=./client.go -destination=./mock/client_generated.go -package=mock
@@ -37,30 +53,78 @@ const (
 	defaultCredentialsSecretSecretNamespace = "openshift-machine-api"
 )
 
+// DefaultTimeout is the default timeout of infra-cluster API calls
+const DefaultTimeout = 30 * time.Second
+
+// callLogVerbosity is the verbosity of the log entries of the infra-cluster API calls
+const callLogVerbosity = 4
+
 // ClientBuilderFuncType is function type for building infra-cluster clients
-type ClientBuilderFuncType func(tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSecretName, namespace string) (Client, error)
+type ClientBuilderFuncType func(ctx context.Context, tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSecretName, namespace string) (Client, error)
 
 // Client is a wrapper object for actual infra-cluster clients: kubernetes and the kubevirt
 type Client interface {
-	CreateVirtualMachine(namespace string, newVM *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error)
-	DeleteVirtualMachine(namespace string, name string, options *k8smetav1.DeleteOptions) error
-	GetVirtualMachine(namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachine, error)
-	GetVirtualMachineInstance(namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachineInstance, error)
-	ListVirtualMachine(namespace string, options *k8smetav1.ListOptions) (*kubevirtapiv1.VirtualMachineList, error)
-	UpdateVirtualMachine(namespace string, vm *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error)
-	PatchVirtualMachine(namespace string, name string, pt types.PatchType, data []byte, subresources ...string) (result *kubevirtapiv1.VirtualMachine, err error)
-	RestartVirtualMachine(namespace string, name string) error
-	StartVirtualMachine(namespace string, name string) error
-	StopVirtualMachine(namespace string, name string) error
+	CreateVirtualMachine(ctx context.Context, namespace string, newVM *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error)
+	DeleteVirtualMachine(ctx context.Context, namespace string, name string, options *k8smetav1.DeleteOptions) error
+	GetVirtualMachine(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachine, error)
+	GetVirtualMachineInstance(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachineInstance, error)
+	ListVirtualMachine(ctx context.Context, namespace string, options *k8smetav1.ListOptions) (*kubevirtapiv1.VirtualMachineList, error)
+	UpdateVirtualMachine(ctx context.Context, namespace string, vm *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error)
+	ApplyVirtualMachine(ctx context.Context, namespace string, vm *kubevirtapiv1.VirtualMachine, fieldManager string) (*kubevirtapiv1.VirtualMachine, error)
+	PatchVirtualMachine(ctx context.Context, namespace string, name string, pt types.PatchType, data []byte, subresources ...string) (result *kubevirtapiv1.VirtualMachine, err error)
+	RestartVirtualMachine(ctx context.Context, namespace string, name string) error
+	StartVirtualMachine(ctx context.Context, namespace string, name string) error
+	StopVirtualMachine(ctx context.Context, namespace string, name string) error
+	PauseVirtualMachineInstance(ctx context.Context, namespace string, name string) error
+	UnpauseVirtualMachineInstance(ctx context.Context, namespace string, name string) error
+	GetDataVolume(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*cdiv1.DataVolume, error)
+	GetSecret(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*corev1.Secret, error)
+	CreateSecret(ctx context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error)
+	UpdateSecret(ctx context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error)
+	DeleteSecret(ctx context.Context, namespace string, name string, options *k8smetav1.DeleteOptions) error
+	CreateVirtualMachineInstanceMigration(ctx context.Context, namespace string, migration *kubevirtapiv1.VirtualMachineInstanceMigration) (*kubevirtapiv1.VirtualMachineInstanceMigration, error)
+	GetVirtualMachineInstanceMigration(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachineInstanceMigration, error)
+	ListEvents(ctx context.Context, namespace string, options k8smetav1.ListOptions) (*corev1.EventList, error)
+	WatchEvents(ctx context.Context, namespace string, options k8smetav1.ListOptions) (watch.Interface, error)
+	ListObjectMetadata(ctx context.Context, namespace string, resource schema.GroupVersionResource, options k8smetav1.ListOptions) (*k8smetav1.PartialObjectMetadataList, error)
 }
 
+// kubevirtScheme has the kubevirt and CDI objects of the REST clients of the infra-cluster
+var kubevirtScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(kubevirtapiv1.AddToScheme(kubevirtScheme))
+	utilruntime.Must(cdiv1.AddToScheme(kubevirtScheme))
+}
+
+// kubevirtParameterCodec encodes the query parameters of the requests of the REST clients of the infra-cluster
+var kubevirtParameterCodec = runtime.NewParameterCodec(kubevirtScheme)
+
 type client struct {
-	kubevirtClient   kubecli.KubevirtClient
-	kuberentesClient *kubernetes.Clientset
+	kubevirtClient     rest.Interface
+	subresourcesClient rest.Interface
+	cdiClient          rest.Interface
+	kuberentesClient   *kubernetes.Clientset
+	// metadataClient lists the metadata of the objects of any resource
+	metadataClient metadata.Interface
+	// watchClient has no timeout, its watches are long running
+	watchClient *kubernetes.Clientset
+	timeout     time.Duration
 }
 
-// New creates our client wrapper object for the actual kubeVirt and kubernetes clients we use.
-func New(tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSecretName, namespace string) (Client, error) {
+// New creates our client wrapper object for the actual kubeVirt and kubernetes clients we use, with the default timeout.
+func New(ctx context.Context, tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSecretName, namespace string) (Client, error) {
+	return newClient(ctx, tenantClusterKubernetesClient, CredentialsSecretSecretName, namespace, DefaultTimeout)
+}
+
+// NewBuilder returns a builder of infra-cluster clients whose API calls time out after timeout, without timeout if it's 0
+func NewBuilder(timeout time.Duration) ClientBuilderFuncType {
+	return func(ctx context.Context, tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSecretName, namespace string) (Client, error) {
+		return newClient(ctx, tenantClusterKubernetesClient, CredentialsSecretSecretName, namespace, timeout)
+	}
+}
+
+func newClient(ctx context.Context, tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSecretName, namespace string, timeout time.Duration) (Client, error) {
 	CredentialsSecretSecretNamespace := namespace
 	if CredentialsSecretSecretName == "" {
 		CredentialsSecretSecretName = defaultCredentialsSecretSecretName
@@ -71,7 +135,7 @@ func New(tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSe
 		return nil, machineapiapierrors.InvalidMachineConfiguration("Infra-cluster credentials secret - Invalid empty namespace")
 	}
 
-	returnedSecret, err := tenantClusterKubernetesClient.GetSecret(CredentialsSecretSecretName, CredentialsSecretSecretNamespace)
+	returnedSecret, err := tenantClusterKubernetesClient.GetSecret(ctx, CredentialsSecretSecretName, CredentialsSecretSecretNamespace)
 	if err != nil {
 		if apimachineryerrors.IsNotFound(err) {
 			return nil, machineapiapierrors.InvalidMachineConfiguration("Infra-cluster credentials secret %s/%s: %v not found", CredentialsSecretSecretNamespace, CredentialsSecretSecretName, err)
@@ -88,60 +152,353 @@ func New(tenantClusterKubernetesClient tenantcluster.Client, CredentialsSecretSe
 	if err != nil {
 		return nil, err
 	}
-	kubevirtClient, getClientErr := kubecli.GetKubevirtClientFromClientConfig(clientConfig)
-	if getClientErr != nil {
-		return nil, getClientErr
-	}
 	restClientConfig, err := clientConfig.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
+	watchClient, err := kubernetes.NewForConfig(restClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	// The timeout bounds the requests still running once their call timed out
+	restClientConfig.Timeout = timeout
 	kubernetesClient, err := kubernetes.NewForConfig(restClientConfig)
 	if err != nil {
 		return nil, err
 	}
+	metadataClient, err := metadata.NewForConfig(restClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	kubevirtClient, err := newRESTClient(restClientConfig, kubevirtapiv1.GroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	subresourcesClient, err := newRESTClient(restClientConfig, kubevirtapiv1.SubresourceStorageGroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	cdiClient, err := newRESTClient(restClientConfig, cdiv1.SchemeGroupVersion)
+	if err != nil {
+		return nil, err
+	}
 	return &client{
-		kubevirtClient:   kubevirtClient,
-		kuberentesClient: kubernetesClient,
+		kubevirtClient:     kubevirtClient,
+		subresourcesClient: subresourcesClient,
+		cdiClient:          cdiClient,
+		kuberentesClient:   kubernetesClient,
+		metadataClient:     metadataClient,
+		watchClient:        watchClient,
+		timeout:            timeout,
 	}, nil
 }
 
-func (c *client) CreateVirtualMachine(namespace string, newVM *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error) {
-	return c.kubevirtClient.VirtualMachine(namespace).Create(newVM)
+// newRESTClient returns a REST client of the API groupVersion, which decodes the objects of kubevirtScheme
+func newRESTClient(config *rest.Config, groupVersion schema.GroupVersion) (*rest.RESTClient, error) {
+	config = rest.CopyConfig(config)
+	config.GroupVersion = &groupVersion
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = serializer.NewCodecFactory(kubevirtScheme).WithoutConversion()
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(config)
+}
+
+// call returns the result of the call name of the kubernetes clients, which don't take a context,
+// or the error of ctx once it's canceled or the call timed out
+func (c *client) call(ctx context.Context, name string, call func() (interface{}, error)) (interface{}, error) {
+	ctx, end := startCall(ctx, name)
+	result, err := utils.CallWithTimeout(ctx, c.timeout, call)
+	end(err)
+	return result, err
+}
+
+// do sends the request of the call name, and decodes the object of the response into result unless it's nil
+func (c *client) do(ctx context.Context, name string, request *rest.Request, result runtime.Object) error {
+	ctx, end := startCall(ctx, name)
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	response := request.Context(ctx).Do()
+	var err error
+	if result != nil {
+		err = response.Into(result)
+	} else {
+		err = response.Error()
+	}
+	end(err)
+	return err
+}
+
+// startCall starts the span of the call name, a child of the span carried by ctx, like the span of the machine operation
+// it's made for, and returns a copy of ctx carrying it, and the function ending it and logging the call with the logger of ctx
+func startCall(ctx context.Context, name string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.StartSpan(ctx, "infracluster."+name)
+	return ctx, func(err error) {
+		tracing.EndSpan(span, err)
+		log := logging.FromContext(ctx).V(callLogVerbosity)
+		if err != nil {
+			log.Info("infra-cluster API call failed", "call", name, "duration", time.Since(start), "error", err.Error())
+			return
+		}
+		log.Info("infra-cluster API call", "call", name, "duration", time.Since(start))
+	}
+}
+
+func (c *client) CreateVirtualMachine(ctx context.Context, namespace string, newVM *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error) {
+	createdVM := &kubevirtapiv1.VirtualMachine{}
+	err := c.do(ctx, "CreateVirtualMachine", c.kubevirtClient.Post().
+		Namespace(namespace).
+		Resource("virtualmachines").
+		Body(newVM), createdVM)
+	if err != nil {
+		return nil, err
+	}
+	createdVM.SetGroupVersionKind(kubevirtapiv1.VirtualMachineGroupVersionKind)
+	return createdVM, nil
+}
+
+func (c *client) DeleteVirtualMachine(ctx context.Context, namespace string, name string, options *k8smetav1.DeleteOptions) error {
+	return c.do(ctx, "DeleteVirtualMachine", c.kubevirtClient.Delete().
+		Namespace(namespace).
+		Resource("virtualmachines").
+		Name(name).
+		Body(options), nil)
+}
+
+func (c *client) GetVirtualMachine(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachine, error) {
+	vm := &kubevirtapiv1.VirtualMachine{}
+	err := c.do(ctx, "GetVirtualMachine", c.kubevirtClient.Get().
+		Namespace(namespace).
+		Resource("virtualmachines").
+		Name(name).
+		VersionedParams(options, kubevirtParameterCodec), vm)
+	if err != nil {
+		return nil, err
+	}
+	vm.SetGroupVersionKind(kubevirtapiv1.VirtualMachineGroupVersionKind)
+	return vm, nil
+}
+
+func (c *client) GetVirtualMachineInstance(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachineInstance, error) {
+	vmi := &kubevirtapiv1.VirtualMachineInstance{}
+	err := c.do(ctx, "GetVirtualMachineInstance", c.kubevirtClient.Get().
+		Namespace(namespace).
+		Resource("virtualmachineinstances").
+		Name(name).
+		VersionedParams(options, kubevirtParameterCodec), vmi)
+	if err != nil {
+		return nil, err
+	}
+	vmi.SetGroupVersionKind(kubevirtapiv1.VirtualMachineInstanceGroupVersionKind)
+	return vmi, nil
+}
+
+func (c *client) ListVirtualMachine(ctx context.Context, namespace string, options *k8smetav1.ListOptions) (*kubevirtapiv1.VirtualMachineList, error) {
+	vmList := &kubevirtapiv1.VirtualMachineList{}
+	err := c.do(ctx, "ListVirtualMachine", c.kubevirtClient.Get().
+		Namespace(namespace).
+		Resource("virtualmachines").
+		VersionedParams(options, kubevirtParameterCodec), vmList)
+	if err != nil {
+		return nil, err
+	}
+	for i := range vmList.Items {
+		vmList.Items[i].SetGroupVersionKind(kubevirtapiv1.VirtualMachineGroupVersionKind)
+	}
+	return vmList, nil
+}
+
+func (c *client) UpdateVirtualMachine(ctx context.Context, namespace string, vm *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error) {
+	updatedVM := &kubevirtapiv1.VirtualMachine{}
+	err := c.do(ctx, "UpdateVirtualMachine", c.kubevirtClient.Put().
+		Namespace(namespace).
+		Resource("virtualmachines").
+		Name(vm.Name).
+		Body(vm), updatedVM)
+	if err != nil {
+		return nil, err
+	}
+	updatedVM.SetGroupVersionKind(kubevirtapiv1.VirtualMachineGroupVersionKind)
+	return updatedVM, nil
+}
+
+// ApplyVirtualMachine server-side applies the fields set in vm, taking their ownership for fieldManager.
+// The status of vm is not part of the applied configuration.
+func (c *client) ApplyVirtualMachine(ctx context.Context, namespace string, vm *kubevirtapiv1.VirtualMachine, fieldManager string) (*kubevirtapiv1.VirtualMachine, error) {
+	applyConfiguration, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vm)
+	if err != nil {
+		return nil, err
+	}
+	delete(applyConfiguration, "status")
+	data, err := json.Marshal(applyConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	force := true
+	appliedVM := &kubevirtapiv1.VirtualMachine{}
+	err = c.do(ctx, "ApplyVirtualMachine", c.kubevirtClient.Patch(types.ApplyPatchType).
+		Namespace(namespace).
+		Resource("virtualmachines").
+		Name(vm.Name).
+		VersionedParams(&k8smetav1.PatchOptions{FieldManager: fieldManager, Force: &force}, kubevirtParameterCodec).
+		Body(data), appliedVM)
+	if err != nil {
+		return nil, err
+	}
+	appliedVM.SetGroupVersionKind(kubevirtapiv1.VirtualMachineGroupVersionKind)
+	return appliedVM, nil
+}
+
+func (c *client) PatchVirtualMachine(ctx context.Context, namespace string, name string, pt types.PatchType, data []byte, subresources ...string) (*kubevirtapiv1.VirtualMachine, error) {
+	patchedVM := &kubevirtapiv1.VirtualMachine{}
+	err := c.do(ctx, "PatchVirtualMachine", c.kubevirtClient.Patch(pt).
+		Namespace(namespace).
+		Resource("virtualmachines").
+		SubResource(subresources...).
+		Name(name).
+		Body(data), patchedVM)
+	if err != nil {
+		return nil, err
+	}
+	patchedVM.SetGroupVersionKind(kubevirtapiv1.VirtualMachineGroupVersionKind)
+	return patchedVM, nil
+}
+
+// putSubresource puts options to the subresource of the kubevirt object name, of resource
+func (c *client) putSubresource(ctx context.Context, callName string, namespace string, resource string, name string, subresource string, options interface{}) error {
+	body, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, callName, c.subresourcesClient.Put().
+		Namespace(namespace).
+		Resource(resource).
+		Name(name).
+		SubResource(subresource).
+		Body(body), nil)
+}
+
+func (c *client) RestartVirtualMachine(ctx context.Context, namespace string, name string) error {
+	return c.putSubresource(ctx, "RestartVirtualMachine", namespace, "virtualmachines", name, "restart", &kubevirtapiv1.RestartOptions{})
 }
 
-func (c *client) DeleteVirtualMachine(namespace string, name string, options *k8smetav1.DeleteOptions) error {
-	return c.kubevirtClient.VirtualMachine(namespace).Delete(name, options)
+func (c *client) StartVirtualMachine(ctx context.Context, namespace string, name string) error {
+	return c.putSubresource(ctx, "StartVirtualMachine", namespace, "virtualmachines", name, "start", &kubevirtapiv1.StartOptions{})
 }
 
-func (c *client) GetVirtualMachine(namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachine, error) {
-	return c.kubevirtClient.VirtualMachine(namespace).Get(name, options)
+func (c *client) StopVirtualMachine(ctx context.Context, namespace string, name string) error {
+	return c.putSubresource(ctx, "StopVirtualMachine", namespace, "virtualmachines", name, "stop", &kubevirtapiv1.StopOptions{})
 }
 
-func (c *client) GetVirtualMachineInstance(namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachineInstance, error) {
-	return c.kubevirtClient.VirtualMachineInstance(namespace).Get(name, options)
+func (c *client) PauseVirtualMachineInstance(ctx context.Context, namespace string, name string) error {
+	return c.putSubresource(ctx, "PauseVirtualMachineInstance", namespace, "virtualmachineinstances", name, "pause", &kubevirtapiv1.PauseOptions{})
 }
 
-func (c *client) ListVirtualMachine(namespace string, options *k8smetav1.ListOptions) (*kubevirtapiv1.VirtualMachineList, error) {
-	return c.kubevirtClient.VirtualMachine(namespace).List(options)
+func (c *client) UnpauseVirtualMachineInstance(ctx context.Context, namespace string, name string) error {
+	return c.putSubresource(ctx, "UnpauseVirtualMachineInstance", namespace, "virtualmachineinstances", name, "unpause", &kubevirtapiv1.UnpauseOptions{})
 }
 
-func (c *client) UpdateVirtualMachine(namespace string, vm *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachine, error) {
-	return c.kubevirtClient.VirtualMachine(namespace).Update(vm)
+func (c *client) GetDataVolume(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*cdiv1.DataVolume, error) {
+	dataVolume := &cdiv1.DataVolume{}
+	err := c.do(ctx, "GetDataVolume", c.cdiClient.Get().
+		Namespace(namespace).
+		Resource("datavolumes").
+		Name(name).
+		VersionedParams(options, kubevirtParameterCodec), dataVolume)
+	if err != nil {
+		return nil, err
+	}
+	return dataVolume, nil
 }
 
-func (c *client) PatchVirtualMachine(namespace string, name string, pt types.PatchType, data []byte, subresources ...string) (result *kubevirtapiv1.VirtualMachine, err error) {
-	return c.kubevirtClient.VirtualMachine(namespace).Patch(name, pt, data, subresources...)
+func (c *client) GetSecret(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*corev1.Secret, error) {
+	result, err := c.call(ctx, "GetSecret", func() (interface{}, error) {
+		return c.kuberentesClient.CoreV1().Secrets(namespace).Get(name, *options)
+	})
+	value, _ := result.(*corev1.Secret)
+	return value, err
+}
+
+func (c *client) CreateSecret(ctx context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+	result, err := c.call(ctx, "CreateSecret", func() (interface{}, error) {
+		return c.kuberentesClient.CoreV1().Secrets(namespace).Create(secret)
+	})
+	value, _ := result.(*corev1.Secret)
+	return value, err
+}
+
+func (c *client) UpdateSecret(ctx context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+	result, err := c.call(ctx, "UpdateSecret", func() (interface{}, error) {
+		return c.kuberentesClient.CoreV1().Secrets(namespace).Update(secret)
+	})
+	value, _ := result.(*corev1.Secret)
+	return value, err
+}
+
+func (c *client) DeleteSecret(ctx context.Context, namespace string, name string, options *k8smetav1.DeleteOptions) error {
+	_, err := c.call(ctx, "DeleteSecret", func() (interface{}, error) {
+		return nil, c.kuberentesClient.CoreV1().Secrets(namespace).Delete(name, options)
+	})
+	return err
+}
+
+func (c *client) CreateVirtualMachineInstanceMigration(ctx context.Context, namespace string, migration *kubevirtapiv1.VirtualMachineInstanceMigration) (*kubevirtapiv1.VirtualMachineInstanceMigration, error) {
+	createdMigration := &kubevirtapiv1.VirtualMachineInstanceMigration{}
+	err := c.do(ctx, "CreateVirtualMachineInstanceMigration", c.kubevirtClient.Post().
+		Namespace(namespace).
+		Resource("virtualmachineinstancemigrations").
+		Body(migration), createdMigration)
+	if err != nil {
+		return nil, err
+	}
+	createdMigration.SetGroupVersionKind(kubevirtapiv1.VirtualMachineInstanceMigrationGroupVersionKind)
+	return createdMigration, nil
+}
+
+func (c *client) GetVirtualMachineInstanceMigration(ctx context.Context, namespace string, name string, options *k8smetav1.GetOptions) (*kubevirtapiv1.VirtualMachineInstanceMigration, error) {
+	migration := &kubevirtapiv1.VirtualMachineInstanceMigration{}
+	err := c.do(ctx, "GetVirtualMachineInstanceMigration", c.kubevirtClient.Get().
+		Namespace(namespace).
+		Resource("virtualmachineinstancemigrations").
+		Name(name).
+		VersionedParams(options, kubevirtParameterCodec), migration)
+	if err != nil {
+		return nil, err
+	}
+	migration.SetGroupVersionKind(kubevirtapiv1.VirtualMachineInstanceMigrationGroupVersionKind)
+	return migration, nil
 }
 
-func (c *client) RestartVirtualMachine(namespace string, name string) error {
-	return c.kubevirtClient.VirtualMachine(namespace).Restart(name)
+func (c *client) ListEvents(ctx context.Context, namespace string, options k8smetav1.ListOptions) (*corev1.EventList, error) {
+	result, err := c.call(ctx, "ListEvents", func() (interface{}, error) {
+		return c.kuberentesClient.CoreV1().Events(namespace).List(options)
+	})
+	value, _ := result.(*corev1.EventList)
+	return value, err
 }
 
-func (c *client) StartVirtualMachine(namespace string, name string) error {
-	return c.kubevirtClient.VirtualMachine(namespace).Start(name)
+// WatchEvents opens the watch of the events with the request context of ctx, instead of with call, whose timeout
+// would leave a watch opened after it running: the watch is stopped once ctx is done.
+func (c *client) WatchEvents(ctx context.Context, namespace string, options k8smetav1.ListOptions) (watch.Interface, error) {
+	ctx, end := startCall(ctx, "WatchEvents")
+	options.Watch = true
+	watcher, err := c.watchClient.CoreV1().RESTClient().Get().
+		Namespace(namespace).
+		Resource("events").
+		VersionedParams(&options, scheme.ParameterCodec).
+		Context(ctx).
+		Watch()
+	end(err)
+	return watcher, err
 }
 
-func (c *client) StopVirtualMachine(namespace string, name string) error {
-	return c.kubevirtClient.VirtualMachine(namespace).Stop(name)
+func (c *client) ListObjectMetadata(ctx context.Context, namespace string, resource schema.GroupVersionResource, options k8smetav1.ListOptions) (*k8smetav1.PartialObjectMetadataList, error) {
+	result, err := c.call(ctx, "ListObjectMetadata", func() (interface{}, error) {
+		return c.metadataClient.Resource(resource).Namespace(namespace).List(options)
+	})
+	value, _ := result.(*k8smetav1.PartialObjectMetadataList)
+	return value, err
 }
@@ -0,0 +1,137 @@
+package infracluster
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// newTestClient returns a client of the API server handled by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := &rest.Config{Host: server.URL}
+	kubevirtClient, err := newRESTClient(config, kubevirtapiv1.GroupVersion)
+	assert.NilError(t, err)
+	subresourcesClient, err := newRESTClient(config, kubevirtapiv1.SubresourceStorageGroupVersion)
+	assert.NilError(t, err)
+	watchClient, err := kubernetes.NewForConfig(config)
+	assert.NilError(t, err)
+	return &client{kubevirtClient: kubevirtClient, subresourcesClient: subresourcesClient, watchClient: watchClient, timeout: time.Minute}
+}
+
+func TestCreateVirtualMachine(t *testing.T) {
+	runStrategy := kubevirtapiv1.RunStrategyAlways
+	vm := &kubevirtapiv1.VirtualMachine{
+		ObjectMeta: k8smetav1.ObjectMeta{Name: "machine-test"},
+		Spec: kubevirtapiv1.VirtualMachineSpec{
+			RunStrategy: &runStrategy,
+			Template: &kubevirtapiv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kubevirtapiv1.VirtualMachineInstanceSpec{
+					Domain: kubevirtapiv1.DomainSpec{
+						Machine: &kubevirtapiv1.Machine{Type: "q35"},
+					},
+				},
+			},
+		},
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		assert.Equal(t, r.URL.Path, "/apis/kubevirt.io/v1/namespaces/infra/virtualmachines")
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		sentVM := &kubevirtapiv1.VirtualMachine{}
+		assert.NilError(t, json.Unmarshal(body, sentVM))
+		assert.DeepEqual(t, sentVM.Spec, vm.Spec)
+
+		sentVM.UID = "vm-uid"
+		w.Header().Set("Content-Type", "application/json")
+		assert.NilError(t, json.NewEncoder(w).Encode(sentVM))
+	})
+
+	createdVM, err := c.CreateVirtualMachine(context.Background(), "infra", vm)
+	assert.NilError(t, err)
+	assert.Equal(t, string(createdVM.UID), "vm-uid")
+	assert.Equal(t, createdVM.GroupVersionKind(), kubevirtapiv1.VirtualMachineGroupVersionKind)
+	assert.DeepEqual(t, createdVM.Spec, vm.Spec)
+}
+
+func TestStartVirtualMachine(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPut)
+		assert.Equal(t, r.URL.Path, "/apis/subresources.kubevirt.io/v1/namespaces/infra/virtualmachines/machine-test/start")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	assert.NilError(t, c.StartVirtualMachine(context.Background(), "infra", "machine-test"))
+}
+
+func TestPauseVirtualMachineInstance(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPut)
+		assert.Equal(t, r.URL.Path, "/apis/subresources.kubevirt.io/v1/namespaces/infra/virtualmachineinstances/machine-test/pause")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	assert.NilError(t, c.PauseVirtualMachineInstance(context.Background(), "infra", "machine-test"))
+}
+
+func TestGetVirtualMachineNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+	})
+
+	_, err := c.GetVirtualMachine(context.Background(), "infra", "machine-test", &k8smetav1.GetOptions{})
+	assert.Assert(t, apimachineryerrors.IsNotFound(err), "expected a not found error, got %v", err)
+}
+
+func TestWatchEventsStoppedOnContextDone(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/api/v1/namespaces/infra/events")
+		assert.Equal(t, r.URL.Query().Get("watch"), "true")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	// The watch outlives the timeout of the calls
+	c.timeout = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher, err := c.WatchEvents(ctx, "infra", k8smetav1.ListOptions{})
+	assert.NilError(t, err)
+	defer watcher.Stop()
+
+	select {
+	case <-watcher.ResultChan():
+		t.Fatal("watch ended before its context was done")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	// The watch may report the canceled request before it's stopped
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("watch still running once its context was done")
+		}
+	}
+}
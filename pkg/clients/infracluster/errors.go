@@ -0,0 +1,125 @@
+package infracluster
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorClass is the class of an infra-cluster API error, which decides how the machine controller handles it
+type ErrorClass string
+
+const (
+	// ErrorClassNotFound is an error of a missing object
+	ErrorClassNotFound ErrorClass = "NotFound"
+	// ErrorClassForbidden is an error of a request denied to the infra-cluster credentials, or over a quota
+	ErrorClassForbidden ErrorClass = "Forbidden"
+	// ErrorClassInvalid is an error of a request the infra-cluster rejects, retrying it would fail again
+	ErrorClassInvalid ErrorClass = "Invalid"
+	// ErrorClassConflict is an error of a request made with a stale object, or of an object which already exists
+	ErrorClassConflict ErrorClass = "Conflict"
+	// ErrorClassThrottled is an error of a request rate limited by the infra-cluster
+	ErrorClassThrottled ErrorClass = "Throttled"
+	// ErrorClassUnavailable is an error of an infra-cluster which doesn't answer, or fails to handle the request
+	ErrorClassUnavailable ErrorClass = "Unavailable"
+	// ErrorClassUnknown is any other error
+	ErrorClassUnknown ErrorClass = "Unknown"
+)
+
+const (
+	// requeueAfterUnavailable is the delay before reconciling a machine again once the infra-cluster is unavailable,
+	// throttled the request without suggesting a delay, or is missing an object
+	requeueAfterUnavailable = 20 * time.Second
+	// requeueAfterConflict is the delay before reconciling a machine again with up to date objects
+	requeueAfterConflict = 5 * time.Second
+)
+
+// ClassifyError returns the class of an error of the infra-cluster client, also when it's wrapped
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	var apiStatus apimachineryerrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		return classifyStatusError(&apimachineryerrors.StatusError{ErrStatus: apiStatus.Status()})
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassUnavailable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorClassUnavailable
+	}
+	return ErrorClassUnknown
+}
+
+func classifyStatusError(err *apimachineryerrors.StatusError) ErrorClass {
+	switch {
+	case apimachineryerrors.IsNotFound(err), apimachineryerrors.IsGone(err):
+		return ErrorClassNotFound
+	case apimachineryerrors.IsForbidden(err), apimachineryerrors.IsUnauthorized(err):
+		return ErrorClassForbidden
+	case apimachineryerrors.IsInvalid(err), apimachineryerrors.IsBadRequest(err), apimachineryerrors.IsNotAcceptable(err),
+		apimachineryerrors.IsUnsupportedMediaType(err), apimachineryerrors.IsMethodNotSupported(err),
+		apimachineryerrors.IsRequestEntityTooLargeError(err):
+		return ErrorClassInvalid
+	case apimachineryerrors.IsConflict(err), apimachineryerrors.IsAlreadyExists(err):
+		return ErrorClassConflict
+	case apimachineryerrors.IsTooManyRequests(err):
+		return ErrorClassThrottled
+	case apimachineryerrors.IsServiceUnavailable(err), apimachineryerrors.IsServerTimeout(err), apimachineryerrors.IsTimeout(err),
+		apimachineryerrors.IsInternalError(err), apimachineryerrors.IsUnexpectedServerError(err),
+		err.Status().Code >= http.StatusInternalServerError:
+		return ErrorClassUnavailable
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// IsNotFound returns true if err is an infra-cluster error of a missing object
+func IsNotFound(err error) bool {
+	return ClassifyError(err) == ErrorClassNotFound
+}
+
+// RetryAfter returns the delay the infra-cluster asked for before retrying the request of err,
+// from the Retry-After header of its response
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiStatus apimachineryerrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return 0, false
+	}
+	seconds, ok := apimachineryerrors.SuggestsClientDelay(&apimachineryerrors.StatusError{ErrStatus: apiStatus.Status()})
+	if !ok || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// ToMachineError returns the machine controller error of an infra-cluster error of an operation on a machine:
+// the machine of a missing object, a conflict, a throttled request or an unavailable infra-cluster is requeued,
+// after the delay the infra-cluster asked for, if any, a rejected request is an invalid machine configuration,
+// and a denied request is a terminal failure of the operation, built by terminal. Other errors are returned as is.
+func ToMachineError(err error, machineName string, terminal func(msg string, args ...interface{}) *machinecontroller.MachineError) error {
+	switch class := ClassifyError(err); class {
+	case ErrorClassNotFound, ErrorClassConflict, ErrorClassThrottled, ErrorClassUnavailable:
+		requeueAfter := requeueAfterUnavailable
+		if class == ErrorClassConflict {
+			requeueAfter = requeueAfterConflict
+		}
+		if retryAfter, ok := RetryAfter(err); ok {
+			requeueAfter = retryAfter
+		}
+		return &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfter}
+	case ErrorClassInvalid:
+		return machinecontroller.InvalidMachineConfiguration("%v: infra-cluster rejected the request: %v", machineName, err)
+	case ErrorClassForbidden:
+		return terminal("%v: infra-cluster denied the request: %v", machineName, err)
+	default:
+		return err
+	}
+}
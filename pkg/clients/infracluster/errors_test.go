@@ -0,0 +1,229 @@
+package infracluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"gotest.tools/assert"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var virtualMachinesResource = schema.GroupResource{Group: "kubevirt.io", Resource: "virtualmachines"}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{
+			name: "No error",
+		},
+		{
+			name: "Not found",
+			err:  apimachineryerrors.NewNotFound(virtualMachinesResource, "machine-test"),
+			want: ErrorClassNotFound,
+		},
+		{
+			name: "Wrapped not found",
+			err:  fmt.Errorf("failed to get VM: %w", apimachineryerrors.NewNotFound(virtualMachinesResource, "machine-test")),
+			want: ErrorClassNotFound,
+		},
+		{
+			name: "Forbidden",
+			err:  apimachineryerrors.NewForbidden(virtualMachinesResource, "machine-test", errors.New("exceeded quota")),
+			want: ErrorClassForbidden,
+		},
+		{
+			name: "Unauthorized",
+			err:  apimachineryerrors.NewUnauthorized("token expired"),
+			want: ErrorClassForbidden,
+		},
+		{
+			name: "Invalid",
+			err:  apimachineryerrors.NewInvalid(schema.GroupKind{Group: "kubevirt.io", Kind: "VirtualMachine"}, "machine-test", nil),
+			want: ErrorClassInvalid,
+		},
+		{
+			name: "Bad request",
+			err:  apimachineryerrors.NewBadRequest("unknown field"),
+			want: ErrorClassInvalid,
+		},
+		{
+			name: "Conflict",
+			err:  apimachineryerrors.NewConflict(virtualMachinesResource, "machine-test", errors.New("object was modified")),
+			want: ErrorClassConflict,
+		},
+		{
+			name: "Already exists",
+			err:  apimachineryerrors.NewAlreadyExists(virtualMachinesResource, "machine-test"),
+			want: ErrorClassConflict,
+		},
+		{
+			name: "Too many requests",
+			err:  apimachineryerrors.NewTooManyRequests("slow down", 3),
+			want: ErrorClassThrottled,
+		},
+		{
+			name: "Service unavailable",
+			err:  apimachineryerrors.NewServiceUnavailable("etcd is down"),
+			want: ErrorClassUnavailable,
+		},
+		{
+			name: "Internal error",
+			err:  apimachineryerrors.NewInternalError(errors.New("webhook failed")),
+			want: ErrorClassUnavailable,
+		},
+		{
+			name: "Bad gateway",
+			err:  apimachineryerrors.NewGenericServerResponse(502, "get", virtualMachinesResource, "machine-test", "", 0, false),
+			want: ErrorClassUnavailable,
+		},
+		{
+			name: "Timed out call",
+			err:  context.DeadlineExceeded,
+			want: ErrorClassUnavailable,
+		},
+		{
+			name: "Refused connection",
+			err:  &url.Error{Op: "Get", URL: "https://infra:6443", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}},
+			want: ErrorClassUnavailable,
+		},
+		{
+			name: "Other error",
+			err:  errors.New("invalid value of annotation"),
+			want: ErrorClassUnknown,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, ClassifyError(tc.err), tc.want)
+		})
+	}
+}
+
+func TestToMachineError(t *testing.T) {
+	retryAfter := apimachineryerrors.NewTooManyRequests("slow down", 7)
+	throttled := &apimachineryerrors.StatusError{ErrStatus: k8smetav1.Status{Status: k8smetav1.StatusFailure, Code: 429, Reason: k8smetav1.StatusReasonTooManyRequests}}
+	otherErr := errors.New("invalid value of annotation")
+
+	cases := []struct {
+		name             string
+		err              error
+		wantRequeueAfter time.Duration
+		wantReason       machinev1.MachineStatusError
+		wantMessage      string
+		wantErr          error
+	}{
+		{
+			name:             "Requeue a missing object",
+			err:              apimachineryerrors.NewNotFound(virtualMachinesResource, "machine-test"),
+			wantRequeueAfter: requeueAfterUnavailable,
+		},
+		{
+			name:             "Requeue an unavailable infra-cluster",
+			err:              fmt.Errorf("failed to update VM: %w", context.DeadlineExceeded),
+			wantRequeueAfter: requeueAfterUnavailable,
+		},
+		{
+			name:             "Requeue a conflict soon",
+			err:              apimachineryerrors.NewConflict(virtualMachinesResource, "machine-test", errors.New("object was modified")),
+			wantRequeueAfter: requeueAfterConflict,
+		},
+		{
+			name:             "Requeue a throttled request after the suggested delay",
+			err:              retryAfter,
+			wantRequeueAfter: 7 * time.Second,
+		},
+		{
+			name:             "Requeue a throttled request without suggested delay",
+			err:              throttled,
+			wantRequeueAfter: requeueAfterUnavailable,
+		},
+		{
+			name:        "Fail the configuration of a rejected request",
+			err:         apimachineryerrors.NewBadRequest("unknown field"),
+			wantReason:  machinev1.InvalidConfigurationMachineError,
+			wantMessage: "machine-test: infra-cluster rejected the request: unknown field",
+		},
+		{
+			name:        "Fail the operation of a denied request",
+			err:         apimachineryerrors.NewForbidden(virtualMachinesResource, "machine-test", errors.New("exceeded quota")),
+			wantReason:  machinev1.CreateMachineError,
+			wantMessage: `machine-test: infra-cluster denied the request: virtualmachines.kubevirt.io "machine-test" is forbidden: exceeded quota`,
+		},
+		{
+			name:    "Return other errors",
+			err:     otherErr,
+			wantErr: otherErr,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ToMachineError(tc.err, "machine-test", machinecontroller.CreateMachine)
+			switch {
+			case tc.wantRequeueAfter != 0:
+				requeueErr, ok := err.(*machinecontroller.RequeueAfterError)
+				assert.Assert(t, ok, "expected a RequeueAfterError, got %v", err)
+				assert.Equal(t, requeueErr.RequeueAfter, tc.wantRequeueAfter)
+			case tc.wantReason != "":
+				machineErr, ok := err.(*machinecontroller.MachineError)
+				assert.Assert(t, ok, "expected a MachineError, got %v", err)
+				assert.Equal(t, machineErr.Reason, tc.wantReason)
+				assert.Equal(t, machineErr.Message, tc.wantMessage)
+			default:
+				assert.Equal(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name:   "Delay of a throttled request",
+			err:    fmt.Errorf("failed to get VM: %w", apimachineryerrors.NewTooManyRequests("slow down", 7)),
+			want:   7 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "Delay of a server timeout",
+			err:    apimachineryerrors.NewServerTimeout(virtualMachinesResource, "get", 30),
+			want:   30 * time.Second,
+			wantOk: true,
+		},
+		{
+			name: "No delay of a throttled request",
+			err:  &apimachineryerrors.StatusError{ErrStatus: k8smetav1.Status{Status: k8smetav1.StatusFailure, Code: 429, Reason: k8smetav1.StatusReasonTooManyRequests}},
+		},
+		{
+			name: "No delay of another API error",
+			err:  apimachineryerrors.NewNotFound(virtualMachinesResource, "machine-test"),
+		},
+		{
+			name: "No delay of another error",
+			err:  context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := RetryAfter(tc.err)
+			assert.Equal(t, ok, tc.wantOk)
+			assert.Equal(t, got, tc.want)
+		})
+	}
+}
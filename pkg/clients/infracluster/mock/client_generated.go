@@ -5,10 +5,15 @@
 package mock
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/api/core/v1"
+	v10 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	types "k8s.io/apimachinery/pkg/types"
-	v10 "kubevirt.io/client-go/api/v1"
+	watch "k8s.io/apimachinery/pkg/watch"
+	v11 "kubevirt.io/api/core/v1"
+	v1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	reflect "reflect"
 )
 
@@ -36,152 +41,344 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // CreateVirtualMachine mocks base method
-func (m *MockClient) CreateVirtualMachine(namespace string, newVM *v10.VirtualMachine) (*v10.VirtualMachine, error) {
+func (m *MockClient) CreateVirtualMachine(ctx context.Context, namespace string, newVM *v11.VirtualMachine) (*v11.VirtualMachine, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateVirtualMachine", namespace, newVM)
-	ret0, _ := ret[0].(*v10.VirtualMachine)
+	ret := m.ctrl.Call(m, "CreateVirtualMachine", ctx, namespace, newVM)
+	ret0, _ := ret[0].(*v11.VirtualMachine)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateVirtualMachine indicates an expected call of CreateVirtualMachine
-func (mr *MockClientMockRecorder) CreateVirtualMachine(namespace, newVM interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) CreateVirtualMachine(ctx, namespace, newVM interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVirtualMachine", reflect.TypeOf((*MockClient)(nil).CreateVirtualMachine), namespace, newVM)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVirtualMachine", reflect.TypeOf((*MockClient)(nil).CreateVirtualMachine), ctx, namespace, newVM)
 }
 
 // DeleteVirtualMachine mocks base method
-func (m *MockClient) DeleteVirtualMachine(namespace, name string, options *v1.DeleteOptions) error {
+func (m *MockClient) DeleteVirtualMachine(ctx context.Context, namespace, name string, options *v10.DeleteOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteVirtualMachine", namespace, name, options)
+	ret := m.ctrl.Call(m, "DeleteVirtualMachine", ctx, namespace, name, options)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteVirtualMachine indicates an expected call of DeleteVirtualMachine
-func (mr *MockClientMockRecorder) DeleteVirtualMachine(namespace, name, options interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) DeleteVirtualMachine(ctx, namespace, name, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVirtualMachine", reflect.TypeOf((*MockClient)(nil).DeleteVirtualMachine), namespace, name, options)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVirtualMachine", reflect.TypeOf((*MockClient)(nil).DeleteVirtualMachine), ctx, namespace, name, options)
 }
 
 // GetVirtualMachine mocks base method
-func (m *MockClient) GetVirtualMachine(namespace, name string, options *v1.GetOptions) (*v10.VirtualMachine, error) {
+func (m *MockClient) GetVirtualMachine(ctx context.Context, namespace, name string, options *v10.GetOptions) (*v11.VirtualMachine, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetVirtualMachine", namespace, name, options)
-	ret0, _ := ret[0].(*v10.VirtualMachine)
+	ret := m.ctrl.Call(m, "GetVirtualMachine", ctx, namespace, name, options)
+	ret0, _ := ret[0].(*v11.VirtualMachine)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetVirtualMachine indicates an expected call of GetVirtualMachine
-func (mr *MockClientMockRecorder) GetVirtualMachine(namespace, name, options interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) GetVirtualMachine(ctx, namespace, name, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachine", reflect.TypeOf((*MockClient)(nil).GetVirtualMachine), namespace, name, options)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachine", reflect.TypeOf((*MockClient)(nil).GetVirtualMachine), ctx, namespace, name, options)
 }
 
 // GetVirtualMachineInstance mocks base method
-func (m *MockClient) GetVirtualMachineInstance(namespace, name string, options *v1.GetOptions) (*v10.VirtualMachineInstance, error) {
+func (m *MockClient) GetVirtualMachineInstance(ctx context.Context, namespace, name string, options *v10.GetOptions) (*v11.VirtualMachineInstance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetVirtualMachineInstance", namespace, name, options)
-	ret0, _ := ret[0].(*v10.VirtualMachineInstance)
+	ret := m.ctrl.Call(m, "GetVirtualMachineInstance", ctx, namespace, name, options)
+	ret0, _ := ret[0].(*v11.VirtualMachineInstance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetVirtualMachineInstance indicates an expected call of GetVirtualMachineInstance
-func (mr *MockClientMockRecorder) GetVirtualMachineInstance(namespace, name, options interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) GetVirtualMachineInstance(ctx, namespace, name, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstance), namespace, name, options)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstance), ctx, namespace, name, options)
 }
 
 // ListVirtualMachine mocks base method
-func (m *MockClient) ListVirtualMachine(namespace string, options *v1.ListOptions) (*v10.VirtualMachineList, error) {
+func (m *MockClient) ListVirtualMachine(ctx context.Context, namespace string, options *v10.ListOptions) (*v11.VirtualMachineList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListVirtualMachine", namespace, options)
-	ret0, _ := ret[0].(*v10.VirtualMachineList)
+	ret := m.ctrl.Call(m, "ListVirtualMachine", ctx, namespace, options)
+	ret0, _ := ret[0].(*v11.VirtualMachineList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListVirtualMachine indicates an expected call of ListVirtualMachine
-func (mr *MockClientMockRecorder) ListVirtualMachine(namespace, options interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) ListVirtualMachine(ctx, namespace, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVirtualMachine", reflect.TypeOf((*MockClient)(nil).ListVirtualMachine), namespace, options)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVirtualMachine", reflect.TypeOf((*MockClient)(nil).ListVirtualMachine), ctx, namespace, options)
 }
 
 // UpdateVirtualMachine mocks base method
-func (m *MockClient) UpdateVirtualMachine(namespace string, vm *v10.VirtualMachine) (*v10.VirtualMachine, error) {
+func (m *MockClient) UpdateVirtualMachine(ctx context.Context, namespace string, vm *v11.VirtualMachine) (*v11.VirtualMachine, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateVirtualMachine", namespace, vm)
-	ret0, _ := ret[0].(*v10.VirtualMachine)
+	ret := m.ctrl.Call(m, "UpdateVirtualMachine", ctx, namespace, vm)
+	ret0, _ := ret[0].(*v11.VirtualMachine)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateVirtualMachine indicates an expected call of UpdateVirtualMachine
-func (mr *MockClientMockRecorder) UpdateVirtualMachine(namespace, vm interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) UpdateVirtualMachine(ctx, namespace, vm interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVirtualMachine", reflect.TypeOf((*MockClient)(nil).UpdateVirtualMachine), namespace, vm)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVirtualMachine", reflect.TypeOf((*MockClient)(nil).UpdateVirtualMachine), ctx, namespace, vm)
+}
+
+// ApplyVirtualMachine mocks base method
+func (m *MockClient) ApplyVirtualMachine(ctx context.Context, namespace string, vm *v11.VirtualMachine, fieldManager string) (*v11.VirtualMachine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyVirtualMachine", ctx, namespace, vm, fieldManager)
+	ret0, _ := ret[0].(*v11.VirtualMachine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyVirtualMachine indicates an expected call of ApplyVirtualMachine
+func (mr *MockClientMockRecorder) ApplyVirtualMachine(ctx, namespace, vm, fieldManager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyVirtualMachine", reflect.TypeOf((*MockClient)(nil).ApplyVirtualMachine), ctx, namespace, vm, fieldManager)
 }
 
 // PatchVirtualMachine mocks base method
-func (m *MockClient) PatchVirtualMachine(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v10.VirtualMachine, error) {
+func (m *MockClient) PatchVirtualMachine(ctx context.Context, namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v11.VirtualMachine, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{namespace, name, pt, data}
+	varargs := []interface{}{ctx, namespace, name, pt, data}
 	for _, a := range subresources {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "PatchVirtualMachine", varargs...)
-	ret0, _ := ret[0].(*v10.VirtualMachine)
+	ret0, _ := ret[0].(*v11.VirtualMachine)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchVirtualMachine indicates an expected call of PatchVirtualMachine
-func (mr *MockClientMockRecorder) PatchVirtualMachine(namespace, name, pt, data interface{}, subresources ...interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) PatchVirtualMachine(ctx, namespace, name, pt, data interface{}, subresources ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{namespace, name, pt, data}, subresources...)
+	varargs := append([]interface{}{ctx, namespace, name, pt, data}, subresources...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchVirtualMachine", reflect.TypeOf((*MockClient)(nil).PatchVirtualMachine), varargs...)
 }
 
 // RestartVirtualMachine mocks base method
-func (m *MockClient) RestartVirtualMachine(namespace, name string) error {
+func (m *MockClient) RestartVirtualMachine(ctx context.Context, namespace, name string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RestartVirtualMachine", namespace, name)
+	ret := m.ctrl.Call(m, "RestartVirtualMachine", ctx, namespace, name)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RestartVirtualMachine indicates an expected call of RestartVirtualMachine
-func (mr *MockClientMockRecorder) RestartVirtualMachine(namespace, name interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) RestartVirtualMachine(ctx, namespace, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartVirtualMachine", reflect.TypeOf((*MockClient)(nil).RestartVirtualMachine), namespace, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartVirtualMachine", reflect.TypeOf((*MockClient)(nil).RestartVirtualMachine), ctx, namespace, name)
 }
 
 // StartVirtualMachine mocks base method
-func (m *MockClient) StartVirtualMachine(namespace, name string) error {
+func (m *MockClient) StartVirtualMachine(ctx context.Context, namespace, name string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StartVirtualMachine", namespace, name)
+	ret := m.ctrl.Call(m, "StartVirtualMachine", ctx, namespace, name)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StartVirtualMachine indicates an expected call of StartVirtualMachine
-func (mr *MockClientMockRecorder) StartVirtualMachine(namespace, name interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) StartVirtualMachine(ctx, namespace, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartVirtualMachine", reflect.TypeOf((*MockClient)(nil).StartVirtualMachine), namespace, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartVirtualMachine", reflect.TypeOf((*MockClient)(nil).StartVirtualMachine), ctx, namespace, name)
 }
 
 // StopVirtualMachine mocks base method
-func (m *MockClient) StopVirtualMachine(namespace, name string) error {
+func (m *MockClient) StopVirtualMachine(ctx context.Context, namespace, name string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StopVirtualMachine", namespace, name)
+	ret := m.ctrl.Call(m, "StopVirtualMachine", ctx, namespace, name)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StopVirtualMachine indicates an expected call of StopVirtualMachine
-func (mr *MockClientMockRecorder) StopVirtualMachine(namespace, name interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) StopVirtualMachine(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopVirtualMachine", reflect.TypeOf((*MockClient)(nil).StopVirtualMachine), ctx, namespace, name)
+}
+
+// PauseVirtualMachineInstance mocks base method
+func (m *MockClient) PauseVirtualMachineInstance(ctx context.Context, namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseVirtualMachineInstance", ctx, namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseVirtualMachineInstance indicates an expected call of PauseVirtualMachineInstance
+func (mr *MockClientMockRecorder) PauseVirtualMachineInstance(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).PauseVirtualMachineInstance), ctx, namespace, name)
+}
+
+// UnpauseVirtualMachineInstance mocks base method
+func (m *MockClient) UnpauseVirtualMachineInstance(ctx context.Context, namespace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpauseVirtualMachineInstance", ctx, namespace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnpauseVirtualMachineInstance indicates an expected call of UnpauseVirtualMachineInstance
+func (mr *MockClientMockRecorder) UnpauseVirtualMachineInstance(ctx, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpauseVirtualMachineInstance", reflect.TypeOf((*MockClient)(nil).UnpauseVirtualMachineInstance), ctx, namespace, name)
+}
+
+// GetDataVolume mocks base method
+func (m *MockClient) GetDataVolume(ctx context.Context, namespace, name string, options *v10.GetOptions) (*v1beta1.DataVolume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataVolume", ctx, namespace, name, options)
+	ret0, _ := ret[0].(*v1beta1.DataVolume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataVolume indicates an expected call of GetDataVolume
+func (mr *MockClientMockRecorder) GetDataVolume(ctx, namespace, name, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataVolume", reflect.TypeOf((*MockClient)(nil).GetDataVolume), ctx, namespace, name, options)
+}
+
+// GetSecret mocks base method
+func (m *MockClient) GetSecret(ctx context.Context, namespace, name string, options *v10.GetOptions) (*v1.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecret", ctx, namespace, name, options)
+	ret0, _ := ret[0].(*v1.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecret indicates an expected call of GetSecret
+func (mr *MockClientMockRecorder) GetSecret(ctx, namespace, name, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockClient)(nil).GetSecret), ctx, namespace, name, options)
+}
+
+// CreateSecret mocks base method
+func (m *MockClient) CreateSecret(ctx context.Context, namespace string, secret *v1.Secret) (*v1.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSecret", ctx, namespace, secret)
+	ret0, _ := ret[0].(*v1.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSecret indicates an expected call of CreateSecret
+func (mr *MockClientMockRecorder) CreateSecret(ctx, namespace, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSecret", reflect.TypeOf((*MockClient)(nil).CreateSecret), ctx, namespace, secret)
+}
+
+// UpdateSecret mocks base method
+func (m *MockClient) UpdateSecret(ctx context.Context, namespace string, secret *v1.Secret) (*v1.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSecret", ctx, namespace, secret)
+	ret0, _ := ret[0].(*v1.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSecret indicates an expected call of UpdateSecret
+func (mr *MockClientMockRecorder) UpdateSecret(ctx, namespace, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecret", reflect.TypeOf((*MockClient)(nil).UpdateSecret), ctx, namespace, secret)
+}
+
+// DeleteSecret mocks base method
+func (m *MockClient) DeleteSecret(ctx context.Context, namespace, name string, options *v10.DeleteOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecret", ctx, namespace, name, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecret indicates an expected call of DeleteSecret
+func (mr *MockClientMockRecorder) DeleteSecret(ctx, namespace, name, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockClient)(nil).DeleteSecret), ctx, namespace, name, options)
+}
+
+// CreateVirtualMachineInstanceMigration mocks base method
+func (m *MockClient) CreateVirtualMachineInstanceMigration(ctx context.Context, namespace string, migration *v11.VirtualMachineInstanceMigration) (*v11.VirtualMachineInstanceMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVirtualMachineInstanceMigration", ctx, namespace, migration)
+	ret0, _ := ret[0].(*v11.VirtualMachineInstanceMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVirtualMachineInstanceMigration indicates an expected call of CreateVirtualMachineInstanceMigration
+func (mr *MockClientMockRecorder) CreateVirtualMachineInstanceMigration(ctx, namespace, migration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVirtualMachineInstanceMigration", reflect.TypeOf((*MockClient)(nil).CreateVirtualMachineInstanceMigration), ctx, namespace, migration)
+}
+
+// GetVirtualMachineInstanceMigration mocks base method
+func (m *MockClient) GetVirtualMachineInstanceMigration(ctx context.Context, namespace, name string, options *v10.GetOptions) (*v11.VirtualMachineInstanceMigration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualMachineInstanceMigration", ctx, namespace, name, options)
+	ret0, _ := ret[0].(*v11.VirtualMachineInstanceMigration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualMachineInstanceMigration indicates an expected call of GetVirtualMachineInstanceMigration
+func (mr *MockClientMockRecorder) GetVirtualMachineInstanceMigration(ctx, namespace, name, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualMachineInstanceMigration", reflect.TypeOf((*MockClient)(nil).GetVirtualMachineInstanceMigration), ctx, namespace, name, options)
+}
+
+// ListEvents mocks base method
+func (m *MockClient) ListEvents(ctx context.Context, namespace string, options v10.ListOptions) (*v1.EventList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", ctx, namespace, options)
+	ret0, _ := ret[0].(*v1.EventList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents
+func (mr *MockClientMockRecorder) ListEvents(ctx, namespace, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockClient)(nil).ListEvents), ctx, namespace, options)
+}
+
+// WatchEvents mocks base method
+func (m *MockClient) WatchEvents(ctx context.Context, namespace string, options v10.ListOptions) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchEvents", ctx, namespace, options)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchEvents indicates an expected call of WatchEvents
+func (mr *MockClientMockRecorder) WatchEvents(ctx, namespace, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEvents", reflect.TypeOf((*MockClient)(nil).WatchEvents), ctx, namespace, options)
+}
+
+// ListObjectMetadata mocks base method
+func (m *MockClient) ListObjectMetadata(ctx context.Context, namespace string, resource schema.GroupVersionResource, options v10.ListOptions) (*v10.PartialObjectMetadataList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObjectMetadata", ctx, namespace, resource, options)
+	ret0, _ := ret[0].(*v10.PartialObjectMetadataList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObjectMetadata indicates an expected call of ListObjectMetadata
+func (mr *MockClientMockRecorder) ListObjectMetadata(ctx, namespace, resource, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopVirtualMachine", reflect.TypeOf((*MockClient)(nil).StopVirtualMachine), namespace, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObjectMetadata", reflect.TypeOf((*MockClient)(nil).ListObjectMetadata), ctx, namespace, resource, options)
 }
@@ -19,13 +19,19 @@ package tenantcluster
 import (
 	"context"
 	"encoding/json"
+	"time"
 
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/tracing"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/utils"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -41,21 +47,37 @@ const (
 
 // Client is a wrapper object for actual tenant-cluster clients: kubernetesClient and runtimeClient
 type Client interface {
-	PatchMachine(machine *machinev1.Machine, originMachineCopy *machinev1.Machine) error
-	StatusPatchMachine(machine *machinev1.Machine, originMachineCopy *machinev1.Machine) error
-	GetSecret(secretName string, namespace string) (*corev1.Secret, error)
-	GetNamespace() (string, error)
-	GetInfraID() (string, error)
+	GetMachine(ctx context.Context, name string, namespace string) (*machinev1.Machine, error)
+	PatchMachine(ctx context.Context, machine *machinev1.Machine, originMachineCopy *machinev1.Machine) error
+	StatusPatchMachine(ctx context.Context, machine *machinev1.Machine, originMachineCopy *machinev1.Machine) error
+	GetMachineSet(ctx context.Context, name string, namespace string) (*machinev1.MachineSet, error)
+	PatchMachineSet(ctx context.Context, machineSet *machinev1.MachineSet, originMachineSetCopy *machinev1.MachineSet) error
+	GetSecret(ctx context.Context, secretName string, namespace string) (*corev1.Secret, error)
+	GetConfigMap(ctx context.Context, configMapName string, namespace string) (*corev1.ConfigMap, error)
+	GetIPPool(ctx context.Context, name string, namespace string) (*kubevirtproviderv1alpha1.IPPool, error)
+	UpdateIPPoolStatus(ctx context.Context, ipPool *kubevirtproviderv1alpha1.IPPool) error
+	GetMACPool(ctx context.Context, name string, namespace string) (*kubevirtproviderv1alpha1.MACPool, error)
+	UpdateMACPoolStatus(ctx context.Context, macPool *kubevirtproviderv1alpha1.MACPool) error
+	GetNamespace(ctx context.Context) (string, error)
+	GetInfraID(ctx context.Context) (string, error)
 }
 
+// DefaultTimeout is the default timeout of tenant-cluster API calls
+const DefaultTimeout = 30 * time.Second
+
 type kubeClient struct {
 	kubernetesClient *kubernetes.Clientset
 	runtimeClient    client.Client
+	timeout          time.Duration
 }
 
 // New creates our client wrapper object for the actual KubeVirt and VirtCtl clients we use.
-func New(mgr manager.Manager) (Client, error) {
-	kubernetesClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+// Its API calls time out after timeout, without timeout if it's 0.
+func New(mgr manager.Manager, timeout time.Duration) (Client, error) {
+	config := rest.CopyConfig(mgr.GetConfig())
+	// The timeout bounds the requests still running once their call timed out
+	config.Timeout = timeout
+	kubernetesClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -63,24 +85,126 @@ func New(mgr manager.Manager) (Client, error) {
 	return &kubeClient{
 		kubernetesClient: kubernetesClient,
 		runtimeClient:    mgr.GetClient(),
+		timeout:          timeout,
 	}, nil
 }
 
-func (c *kubeClient) PatchMachine(machine *machinev1.Machine, originMachineCopy *machinev1.Machine) error {
-	return c.runtimeClient.Patch(context.Background(), machine, client.MergeFrom(originMachineCopy))
+func (c *kubeClient) GetMachine(ctx context.Context, name string, namespace string) (_ *machinev1.Machine, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetMachine")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	machine := &machinev1.Machine{}
+	if err := c.runtimeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, machine); err != nil {
+		return nil, err
+	}
+	return machine, nil
+}
+
+func (c *kubeClient) PatchMachine(ctx context.Context, machine *machinev1.Machine, originMachineCopy *machinev1.Machine) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.PatchMachine")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.runtimeClient.Patch(ctx, machine, client.MergeFrom(originMachineCopy))
+}
+
+func (c *kubeClient) StatusPatchMachine(ctx context.Context, machine *machinev1.Machine, originMachineCopy *machinev1.Machine) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.StatusPatchMachine")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.runtimeClient.Status().Patch(ctx, machine, client.MergeFrom(originMachineCopy))
+}
+
+func (c *kubeClient) GetMachineSet(ctx context.Context, name string, namespace string) (_ *machinev1.MachineSet, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetMachineSet")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	machineSet := &machinev1.MachineSet{}
+	if err := c.runtimeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, machineSet); err != nil {
+		return nil, err
+	}
+	return machineSet, nil
+}
+
+func (c *kubeClient) PatchMachineSet(ctx context.Context, machineSet *machinev1.MachineSet, originMachineSetCopy *machinev1.MachineSet) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.PatchMachineSet")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.runtimeClient.Patch(ctx, machineSet, client.MergeFrom(originMachineSetCopy))
 }
 
-func (c *kubeClient) StatusPatchMachine(machine *machinev1.Machine, originMachineCopy *machinev1.Machine) error {
-	return c.runtimeClient.Status().Patch(context.Background(), machine, client.MergeFrom(originMachineCopy))
+func (c *kubeClient) GetSecret(ctx context.Context, secretName string, namespace string) (_ *corev1.Secret, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetSecret")
+	defer func() { tracing.EndSpan(span, err) }()
+	result, err := utils.CallWithTimeout(ctx, c.timeout, func() (interface{}, error) {
+		return c.kubernetesClient.CoreV1().Secrets(namespace).Get(secretName, k8smetav1.GetOptions{})
+	})
+	secret, _ := result.(*corev1.Secret)
+	return secret, err
+}
+
+func (c *kubeClient) GetConfigMap(ctx context.Context, configMapName string, namespace string) (_ *corev1.ConfigMap, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetConfigMap")
+	defer func() { tracing.EndSpan(span, err) }()
+	result, err := utils.CallWithTimeout(ctx, c.timeout, func() (interface{}, error) {
+		return c.kubernetesClient.CoreV1().ConfigMaps(namespace).Get(configMapName, k8smetav1.GetOptions{})
+	})
+	configMap, _ := result.(*corev1.ConfigMap)
+	return configMap, err
+}
+
+func (c *kubeClient) GetIPPool(ctx context.Context, name string, namespace string) (_ *kubevirtproviderv1alpha1.IPPool, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetIPPool")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	ipPool := &kubevirtproviderv1alpha1.IPPool{}
+	if err := c.runtimeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ipPool); err != nil {
+		return nil, err
+	}
+	return ipPool, nil
+}
+
+// UpdateIPPoolStatus updates the allocations of the pool, failing with a conflict when the pool was changed since it was read
+func (c *kubeClient) UpdateIPPoolStatus(ctx context.Context, ipPool *kubevirtproviderv1alpha1.IPPool) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.UpdateIPPoolStatus")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.runtimeClient.Status().Update(ctx, ipPool)
+}
+
+func (c *kubeClient) GetMACPool(ctx context.Context, name string, namespace string) (_ *kubevirtproviderv1alpha1.MACPool, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetMACPool")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	macPool := &kubevirtproviderv1alpha1.MACPool{}
+	if err := c.runtimeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, macPool); err != nil {
+		return nil, err
+	}
+	return macPool, nil
 }
 
-func (c *kubeClient) GetSecret(secretName string, namespace string) (*corev1.Secret, error) {
-	return c.kubernetesClient.CoreV1().Secrets(namespace).Get(secretName, k8smetav1.GetOptions{})
+// UpdateMACPoolStatus updates the allocations of the pool, failing with a conflict when the pool was changed since it was read
+func (c *kubeClient) UpdateMACPoolStatus(ctx context.Context, macPool *kubevirtproviderv1alpha1.MACPool) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.UpdateMACPoolStatus")
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.runtimeClient.Status().Update(ctx, macPool)
 }
 
-func (c *kubeClient) GetInfraID() (string, error) {
-	cMap, err := c.getConfigMap()
+func (c *kubeClient) GetInfraID(ctx context.Context) (_ string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetInfraID")
+	defer func() { tracing.EndSpan(span, err) }()
+	cMap, err := c.getConfigMap(ctx)
 	if err != nil {
+		logging.FromContext(ctx).Error(err, "failed to read the infra ID of the tenant-cluster configMap", "configMap", ConfigMapNamespace+"/"+ConfigMapName)
 		return "", nil
 	}
 	infraID, ok := (*cMap)[ConfigMapInfraIDKeyName]
@@ -90,9 +214,12 @@ func (c *kubeClient) GetInfraID() (string, error) {
 	return infraID, nil
 }
 
-func (c *kubeClient) GetNamespace() (string, error) {
-	cMap, err := c.getConfigMap()
+func (c *kubeClient) GetNamespace(ctx context.Context) (_ string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "tenantcluster.GetNamespace")
+	defer func() { tracing.EndSpan(span, err) }()
+	cMap, err := c.getConfigMap(ctx)
 	if err != nil {
+		logging.FromContext(ctx).Error(err, "failed to read the VM namespace of the tenant-cluster configMap", "configMap", ConfigMapNamespace+"/"+ConfigMapName)
 		return "", nil
 	}
 	vmNamespace, ok := (*cMap)[ConfigMapNamespaceKeyName]
@@ -102,8 +229,8 @@ func (c *kubeClient) GetNamespace() (string, error) {
 	return vmNamespace, nil
 }
 
-func (c *kubeClient) getConfigMap() (*map[string]string, error) {
-	configMap, err := c.kubernetesClient.CoreV1().ConfigMaps(ConfigMapNamespace).Get(ConfigMapName, k8smetav1.GetOptions{})
+func (c *kubeClient) getConfigMap(ctx context.Context) (*map[string]string, error) {
+	configMap, err := c.GetConfigMap(ctx, ConfigMapName, ConfigMapNamespace)
 	if err != nil {
 		return nil, err
 	}
@@ -5,9 +5,11 @@
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	v1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
 	v1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	v1 "k8s.io/api/core/v1"
 )
@@ -35,75 +37,192 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// GetMachine mocks base method
+func (m *MockClient) GetMachine(ctx context.Context, name, namespace string) (*v1beta1.Machine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMachine", ctx, name, namespace)
+	ret0, _ := ret[0].(*v1beta1.Machine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMachine indicates an expected call of GetMachine
+func (mr *MockClientMockRecorder) GetMachine(ctx, name, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMachine", reflect.TypeOf((*MockClient)(nil).GetMachine), ctx, name, namespace)
+}
+
 // PatchMachine mocks base method
-func (m *MockClient) PatchMachine(machine, originMachineCopy *v1beta1.Machine) error {
+func (m *MockClient) PatchMachine(ctx context.Context, machine, originMachineCopy *v1beta1.Machine) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchMachine", machine, originMachineCopy)
+	ret := m.ctrl.Call(m, "PatchMachine", ctx, machine, originMachineCopy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PatchMachine indicates an expected call of PatchMachine
-func (mr *MockClientMockRecorder) PatchMachine(machine, originMachineCopy interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) PatchMachine(ctx, machine, originMachineCopy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchMachine", reflect.TypeOf((*MockClient)(nil).PatchMachine), machine, originMachineCopy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchMachine", reflect.TypeOf((*MockClient)(nil).PatchMachine), ctx, machine, originMachineCopy)
 }
 
 // StatusPatchMachine mocks base method
-func (m *MockClient) StatusPatchMachine(machine, originMachineCopy *v1beta1.Machine) error {
+func (m *MockClient) StatusPatchMachine(ctx context.Context, machine, originMachineCopy *v1beta1.Machine) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StatusPatchMachine", machine, originMachineCopy)
+	ret := m.ctrl.Call(m, "StatusPatchMachine", ctx, machine, originMachineCopy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StatusPatchMachine indicates an expected call of StatusPatchMachine
-func (mr *MockClientMockRecorder) StatusPatchMachine(machine, originMachineCopy interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) StatusPatchMachine(ctx, machine, originMachineCopy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatusPatchMachine", reflect.TypeOf((*MockClient)(nil).StatusPatchMachine), ctx, machine, originMachineCopy)
+}
+
+// GetMachineSet mocks base method
+func (m *MockClient) GetMachineSet(ctx context.Context, name, namespace string) (*v1beta1.MachineSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMachineSet", ctx, name, namespace)
+	ret0, _ := ret[0].(*v1beta1.MachineSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMachineSet indicates an expected call of GetMachineSet
+func (mr *MockClientMockRecorder) GetMachineSet(ctx, name, namespace interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatusPatchMachine", reflect.TypeOf((*MockClient)(nil).StatusPatchMachine), machine, originMachineCopy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMachineSet", reflect.TypeOf((*MockClient)(nil).GetMachineSet), ctx, name, namespace)
+}
+
+// PatchMachineSet mocks base method
+func (m *MockClient) PatchMachineSet(ctx context.Context, machineSet, originMachineSetCopy *v1beta1.MachineSet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchMachineSet", ctx, machineSet, originMachineSetCopy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchMachineSet indicates an expected call of PatchMachineSet
+func (mr *MockClientMockRecorder) PatchMachineSet(ctx, machineSet, originMachineSetCopy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchMachineSet", reflect.TypeOf((*MockClient)(nil).PatchMachineSet), ctx, machineSet, originMachineSetCopy)
 }
 
 // GetSecret mocks base method
-func (m *MockClient) GetSecret(secretName, namespace string) (*v1.Secret, error) {
+func (m *MockClient) GetSecret(ctx context.Context, secretName, namespace string) (*v1.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSecret", secretName, namespace)
+	ret := m.ctrl.Call(m, "GetSecret", ctx, secretName, namespace)
 	ret0, _ := ret[0].(*v1.Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSecret indicates an expected call of GetSecret
-func (mr *MockClientMockRecorder) GetSecret(secretName, namespace interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) GetSecret(ctx, secretName, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockClient)(nil).GetSecret), ctx, secretName, namespace)
+}
+
+// GetConfigMap mocks base method
+func (m *MockClient) GetConfigMap(ctx context.Context, configMapName, namespace string) (*v1.ConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMap", ctx, configMapName, namespace)
+	ret0, _ := ret[0].(*v1.ConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigMap indicates an expected call of GetConfigMap
+func (mr *MockClientMockRecorder) GetConfigMap(ctx, configMapName, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMap", reflect.TypeOf((*MockClient)(nil).GetConfigMap), ctx, configMapName, namespace)
+}
+
+// GetIPPool mocks base method
+func (m *MockClient) GetIPPool(ctx context.Context, name, namespace string) (*v1alpha1.IPPool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPPool", ctx, name, namespace)
+	ret0, _ := ret[0].(*v1alpha1.IPPool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIPPool indicates an expected call of GetIPPool
+func (mr *MockClientMockRecorder) GetIPPool(ctx, name, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPPool", reflect.TypeOf((*MockClient)(nil).GetIPPool), ctx, name, namespace)
+}
+
+// UpdateIPPoolStatus mocks base method
+func (m *MockClient) UpdateIPPoolStatus(ctx context.Context, ipPool *v1alpha1.IPPool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIPPoolStatus", ctx, ipPool)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIPPoolStatus indicates an expected call of UpdateIPPoolStatus
+func (mr *MockClientMockRecorder) UpdateIPPoolStatus(ctx, ipPool interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIPPoolStatus", reflect.TypeOf((*MockClient)(nil).UpdateIPPoolStatus), ctx, ipPool)
+}
+
+// GetMACPool mocks base method
+func (m *MockClient) GetMACPool(ctx context.Context, name, namespace string) (*v1alpha1.MACPool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMACPool", ctx, name, namespace)
+	ret0, _ := ret[0].(*v1alpha1.MACPool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMACPool indicates an expected call of GetMACPool
+func (mr *MockClientMockRecorder) GetMACPool(ctx, name, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMACPool", reflect.TypeOf((*MockClient)(nil).GetMACPool), ctx, name, namespace)
+}
+
+// UpdateMACPoolStatus mocks base method
+func (m *MockClient) UpdateMACPoolStatus(ctx context.Context, macPool *v1alpha1.MACPool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMACPoolStatus", ctx, macPool)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMACPoolStatus indicates an expected call of UpdateMACPoolStatus
+func (mr *MockClientMockRecorder) UpdateMACPoolStatus(ctx, macPool interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockClient)(nil).GetSecret), secretName, namespace)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMACPoolStatus", reflect.TypeOf((*MockClient)(nil).UpdateMACPoolStatus), ctx, macPool)
 }
 
 // GetNamespace mocks base method
-func (m *MockClient) GetNamespace() (string, error) {
+func (m *MockClient) GetNamespace(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetNamespace")
+	ret := m.ctrl.Call(m, "GetNamespace", ctx)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetNamespace indicates an expected call of GetNamespace
-func (mr *MockClientMockRecorder) GetNamespace() *gomock.Call {
+func (mr *MockClientMockRecorder) GetNamespace(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamespace", reflect.TypeOf((*MockClient)(nil).GetNamespace))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamespace", reflect.TypeOf((*MockClient)(nil).GetNamespace), ctx)
 }
 
 // GetInfraID mocks base method
-func (m *MockClient) GetInfraID() (string, error) {
+func (m *MockClient) GetInfraID(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetInfraID")
+	ret := m.ctrl.Call(m, "GetInfraID", ctx)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetInfraID indicates an expected call of GetInfraID
-func (mr *MockClientMockRecorder) GetInfraID() *gomock.Call {
+func (mr *MockClientMockRecorder) GetInfraID(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInfraID", reflect.TypeOf((*MockClient)(nil).GetInfraID))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInfraID", reflect.TypeOf((*MockClient)(nil).GetInfraID), ctx)
 }
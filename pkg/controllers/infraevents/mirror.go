@@ -0,0 +1,221 @@
+package infraevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// DefaultMachineNamespace is the namespace of the tenant-cluster machines
+	DefaultMachineNamespace = "openshift-machine-api"
+	// rewatchPeriod is the delay before watching the infra-cluster events again once a watch ended
+	rewatchPeriod = 10 * time.Second
+	// dedupeTTL is the period during which an event with the same reason and message isn't mirrored again on a machine
+	dedupeTTL = 10 * time.Minute
+	// Each machine is sent a burst of eventBurst events, then one event every eventRateLimitPeriod
+	eventBurst           = 5
+	eventRateLimitPeriod = time.Minute
+	cacheSize            = 4096
+	// relistPeriod is the minimal period between two lists of the infra-cluster objects of the machine VMs
+	relistPeriod = 30 * time.Second
+)
+
+// Mirror re-emits the warning events of the infra-cluster objects of the machine VMs on the tenant-cluster machines,
+// so a VM which can't be scheduled or whose volume can't be bound is reported to tenant admins without infra access
+type Mirror struct {
+	infraClusterClientBuilder infracluster.ClientBuilderFuncType
+	tenantClusterClient       tenantcluster.Client
+	eventRecorder             record.EventRecorder
+	machineNamespace          string
+	// mirrored holds the keys of the events mirrored during the last dedupeTTL
+	mirrored *cache.LRUExpireCache
+	// limiters holds the event rate limiter of each machine
+	limiters *cache.LRUExpireCache
+}
+
+// New returns an infra-cluster events mirror of the machines of machineNamespace
+func New(infraClusterClientBuilder infracluster.ClientBuilderFuncType, tenantClusterClient tenantcluster.Client, eventRecorder record.EventRecorder, machineNamespace string) *Mirror {
+	if machineNamespace == "" {
+		machineNamespace = DefaultMachineNamespace
+	}
+	return &Mirror{
+		infraClusterClientBuilder: infraClusterClientBuilder,
+		tenantClusterClient:       tenantClusterClient,
+		eventRecorder:             eventRecorder,
+		machineNamespace:          machineNamespace,
+		mirrored:                  cache.NewLRUExpireCache(cacheSize),
+		limiters:                  cache.NewLRUExpireCache(cacheSize),
+	}
+}
+
+// Add registers the mirror on the manager, it only runs on the leader
+func Add(mgr manager.Manager, mirror *Mirror) error {
+	return mgr.Add(mirror)
+}
+
+// Start watches the infra-cluster events until stop is closed
+func (m *Mirror) Start(stop <-chan struct{}) error {
+	log := logf.Log.WithName("infraevents-mirror")
+	ctx, cancel := context.WithCancel(logging.IntoContext(context.Background(), log))
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+	wait.Until(func() {
+		if err := m.watch(ctx); err != nil {
+			log.Error(err, "failed to watch infra-cluster events")
+		}
+	}, rewatchPeriod, stop)
+	return nil
+}
+
+// watch mirrors the warning events of the VM namespace which occur after it's called, until the watch ends or ctx is done.
+// Events can't be selected by the labels of their objects, so only the events of the objects labelled with the infra ID
+// of the tenant-cluster are mirrored.
+func (m *Mirror) watch(ctx context.Context) error {
+	vmNamespace, err := m.tenantClusterClient.GetNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	infraID, err := m.tenantClusterClient.GetInfraID(ctx)
+	if err != nil {
+		return err
+	}
+	infraClusterClient, err := m.infraClusterClientBuilder(ctx, m.tenantClusterClient, "", m.machineNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to create infra-cluster client: %w", err)
+	}
+	objects := newInfraObjects(infraClusterClient, vmNamespace, infraID)
+
+	options := k8smetav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String()}
+	// The events which occurred before are skipped, they were mirrored by a previous watch or are stale
+	events, err := infraClusterClient.ListEvents(ctx, vmNamespace, options)
+	if err != nil {
+		return fmt.Errorf("failed to list events of namespace %s: %w", vmNamespace, err)
+	}
+	options.ResourceVersion = events.ResourceVersion
+	watcher, err := infraClusterClient.WatchEvents(ctx, vmNamespace, options)
+	if err != nil {
+		return fmt.Errorf("failed to watch events of namespace %s: %w", vmNamespace, err)
+	}
+	defer watcher.Stop()
+
+	// The events of the objects unknown since they were last listed are mirrored once they are listed again
+	var pending []*corev1.Event
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-retry:
+			retry = nil
+			pending = m.mirrorPendingEvents(ctx, objects, pending)
+		case watchEvent, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			if watchEvent.Type != watch.Added && watchEvent.Type != watch.Modified {
+				continue
+			}
+			event, ok := watchEvent.Object.(*corev1.Event)
+			if !ok {
+				continue
+			}
+			err := m.mirrorEvent(ctx, objects, event)
+			if errors.Is(err, errObjectNotListed) {
+				if len(pending) < cacheSize {
+					pending = append(pending, event)
+				}
+			} else if err != nil {
+				logging.FromContext(ctx).Error(err, "failed to mirror event", "event", event.Name, logging.VMNamespaceKey, event.Namespace)
+			}
+		}
+		if len(pending) > 0 && retry == nil {
+			retry = time.After(time.Until(objects.nextListTime()))
+		}
+	}
+}
+
+// mirrorPendingEvents mirrors the events of the objects which weren't listed yet, once the objects can be listed again.
+// It returns the events which still wait for the objects to be listed. The events of the objects which remain unknown
+// once listed again are dropped, they aren't of a machine VM.
+func (m *Mirror) mirrorPendingEvents(ctx context.Context, objects *infraObjects, pending []*corev1.Event) []*corev1.Event {
+	var stillPending []*corev1.Event
+	lastListTime := objects.listTime
+	for _, event := range pending {
+		err := m.mirrorEvent(ctx, objects, event)
+		if errors.Is(err, errObjectNotListed) {
+			if objects.listTime.Equal(lastListTime) {
+				stillPending = append(stillPending, event)
+			}
+		} else if err != nil {
+			logging.FromContext(ctx).Error(err, "failed to mirror event", "event", event.Name, logging.VMNamespaceKey, event.Namespace)
+		}
+	}
+	return stillPending
+}
+
+// mirrorEvent re-emits a warning event of an infra-cluster object on the machine whose VM the object was created for,
+// when the object is labelled with the infra ID of the tenant-cluster
+func (m *Mirror) mirrorEvent(ctx context.Context, objects *infraObjects, event *corev1.Event) error {
+	if event.Type != corev1.EventTypeWarning {
+		return nil
+	}
+	involvedObject := event.InvolvedObject
+	machineName, ok, err := objects.machineName(ctx, involvedObject.UID, time.Now())
+	if err != nil || !ok {
+		return err
+	}
+	key := fmt.Sprintf("%s/%s/%s", machineName, event.Reason, event.Message)
+	if _, ok := m.mirrored.Get(key); ok {
+		return nil
+	}
+	if !m.limiter(machineName).TryAccept() {
+		logging.FromContext(ctx).V(3).Info("rate limited infra-cluster event", logging.MachineKey, machineName,
+			"reason", event.Reason, "kind", involvedObject.Kind, "name", involvedObject.Name)
+		return nil
+	}
+
+	machine, err := m.tenantClusterClient.GetMachine(ctx, machineName, m.machineNamespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	m.eventRecorder.Eventf(machine, corev1.EventTypeWarning, event.Reason, "%s %s: %s", involvedObject.Kind, involvedObject.Name, event.Message)
+	// A rate limited event isn't deduplicated, so it's mirrored if it occurs again once the machine is sent events again
+	m.mirrored.Add(key, struct{}{}, dedupeTTL)
+	return nil
+}
+
+// limiter returns the event rate limiter of a machine
+func (m *Mirror) limiter(machineName string) flowcontrol.RateLimiter {
+	var limiter flowcontrol.RateLimiter
+	if cached, ok := m.limiters.Get(machineName); ok {
+		limiter = cached.(flowcontrol.RateLimiter)
+	} else {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(float32(1/eventRateLimitPeriod.Seconds()), eventBurst)
+	}
+	// The limiter is kept until its bucket would be refilled
+	m.limiters.Add(machineName, limiter, eventBurst*eventRateLimitPeriod)
+	return limiter
+}
@@ -0,0 +1,288 @@
+package infraevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	mockInfraClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster/mock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/managers/vm"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/utils"
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	machineName      = "machine-test"
+	machineNamespace = "openshift-machine-api"
+	vmNamespace      = "tenant-vms"
+	infraID          = "test-id"
+)
+
+func warningEvent(kind, name, uid, reason, message string) *corev1.Event {
+	return &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name, Namespace: vmNamespace, UID: types.UID(uid)},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Message:        message,
+	}
+}
+
+func objectMetadata(uid, vmName string) k8smetav1.PartialObjectMetadata {
+	object := k8smetav1.PartialObjectMetadata{}
+	object.UID = types.UID(uid)
+	object.Labels = utils.BuildLabels(infraID)
+	object.Labels[vm.VMNameLabel] = vmName
+	return object
+}
+
+// expectListObjectMetadata expects the infra objects to be listed times times, objects holds the objects of each resource
+func expectListObjectMetadata(client *mockInfraClusterClient.MockClient, objects map[string][]k8smetav1.PartialObjectMetadata, times int) {
+	options := k8smetav1.ListOptions{LabelSelector: "tenantcluster-test-id-machine.openshift.io=owned"}
+	for _, resource := range infraObjectResources {
+		list := &k8smetav1.PartialObjectMetadataList{Items: objects[resource.Resource]}
+		client.EXPECT().ListObjectMetadata(gomock.Any(), vmNamespace, resource, options).Return(list, nil).Times(times)
+	}
+}
+
+func TestMirrorEvent(t *testing.T) {
+	normalEvent := warningEvent("VirtualMachineInstance", machineName, "vmi-uid", "Started", "VMI started")
+	normalEvent.Type = corev1.EventTypeNormal
+	var distinctEvents []*corev1.Event
+	var wantDistinctEvents []string
+	for i := 0; i < eventBurst+2; i++ {
+		message := fmt.Sprintf("attempt %d", i)
+		distinctEvents = append(distinctEvents, warningEvent("VirtualMachine", machineName, "vm-uid", "FailedCreate", message))
+		if i < eventBurst {
+			wantDistinctEvents = append(wantDistinctEvents, "Warning FailedCreate VirtualMachine machine-test: "+message)
+		}
+	}
+	objects := map[string][]k8smetav1.PartialObjectMetadata{
+		"virtualmachines":         {objectMetadata("vm-uid", machineName)},
+		"virtualmachineinstances": {objectMetadata("vmi-uid", machineName)},
+		"pods":                    {objectMetadata("pod-uid", machineName)},
+		"datavolumes":             {objectMetadata("dv-uid", machineName)},
+		"persistentvolumeclaims":  {objectMetadata("pvc-uid", machineName)},
+	}
+
+	cases := []struct {
+		name            string
+		events          []*corev1.Event
+		machineNotFound bool
+		listTimes       int
+		wantEvents      []string
+		wantNotListed   int
+	}{
+		{
+			name:       "Mirror a scheduling failure of the virt-launcher pod",
+			events:     []*corev1.Event{warningEvent("Pod", "virt-launcher-machine-test-x7k2p", "pod-uid", "FailedScheduling", "0/3 nodes are available")},
+			listTimes:  1,
+			wantEvents: []string{"Warning FailedScheduling Pod virt-launcher-machine-test-x7k2p: 0/3 nodes are available"},
+		},
+		{
+			name: "Mirror the events of the boot volume",
+			events: []*corev1.Event{
+				warningEvent("PersistentVolumeClaim", "machine-test-bootvolume", "pvc-uid", "ProvisioningFailed", "storageclass not found"),
+				warningEvent("DataVolume", "machine-test-bootvolume", "dv-uid", "Pending", "PVC machine-test-bootvolume Pending"),
+			},
+			listTimes: 1,
+			wantEvents: []string{
+				"Warning ProvisioningFailed PersistentVolumeClaim machine-test-bootvolume: storageclass not found",
+				"Warning Pending DataVolume machine-test-bootvolume: PVC machine-test-bootvolume Pending",
+			},
+		},
+		{
+			name: "De-duplicate a repeated event",
+			events: []*corev1.Event{
+				warningEvent("VirtualMachineInstance", machineName, "vmi-uid", "FailedNetwork", "network-attachment-definition not found"),
+				warningEvent("VirtualMachineInstance", machineName, "vmi-uid", "FailedNetwork", "network-attachment-definition not found"),
+			},
+			listTimes:  1,
+			wantEvents: []string{"Warning FailedNetwork VirtualMachineInstance machine-test: network-attachment-definition not found"},
+		},
+		{
+			name:       "Rate limit the events of a machine",
+			events:     distinctEvents,
+			listTimes:  1,
+			wantEvents: wantDistinctEvents,
+		},
+		{
+			name:   "Skip a normal event",
+			events: []*corev1.Event{normalEvent},
+		},
+		{
+			name: "Skip the events of objects which aren't of a VM of the tenant-cluster, listing them once per relist period",
+			events: []*corev1.Event{
+				warningEvent("Pod", "importer-machine-test-bootvolume", "importer-uid", "BackOff", "Back-off restarting failed container"),
+				warningEvent("VirtualMachine", machineName, "other-vm-uid", "FailedCreate", "quota exceeded"),
+			},
+			listTimes:     1,
+			wantNotListed: 1,
+		},
+		{
+			name:            "Skip a deleted machine",
+			events:          []*corev1.Event{warningEvent("VirtualMachine", machineName, "vm-uid", "FailedCreate", "quota exceeded")},
+			machineNotFound: true,
+			listTimes:       1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+			newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+
+			expectListObjectMetadata(newMockInfraClusterClient, objects, tc.listTimes)
+			if tc.machineNotFound {
+				newMockTenantClusterClient.EXPECT().GetMachine(gomock.Any(), machineName, machineNamespace).Return(nil,
+					apierrors.NewNotFound(schema.GroupResource{Group: "machine.openshift.io", Resource: "machines"}, machineName)).AnyTimes()
+			} else {
+				machine := &machinev1.Machine{}
+				machine.Name = machineName
+				machine.Namespace = machineNamespace
+				newMockTenantClusterClient.EXPECT().GetMachine(gomock.Any(), machineName, machineNamespace).Return(machine, nil).AnyTimes()
+			}
+
+			eventRecorder := record.NewFakeRecorder(len(tc.events))
+			mirror := New(nil, newMockTenantClusterClient, eventRecorder, "")
+			objects := newInfraObjects(newMockInfraClusterClient, vmNamespace, infraID)
+			notListed := 0
+			for _, event := range tc.events {
+				err := mirror.mirrorEvent(context.Background(), objects, event)
+				if errors.Is(err, errObjectNotListed) {
+					notListed++
+					continue
+				}
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, notListed, tc.wantNotListed)
+			close(eventRecorder.Events)
+			var gotEvents []string
+			for event := range eventRecorder.Events {
+				gotEvents = append(gotEvents, event)
+			}
+			assert.DeepEqual(t, gotEvents, tc.wantEvents)
+		})
+	}
+}
+
+func TestMirrorEventRateLimited(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+	newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+
+	expectListObjectMetadata(newMockInfraClusterClient, map[string][]k8smetav1.PartialObjectMetadata{
+		"virtualmachines": {objectMetadata("vm-uid", machineName)},
+	}, 1)
+	newMockTenantClusterClient.EXPECT().GetMachine(gomock.Any(), machineName, machineNamespace).Return(&machinev1.Machine{}, nil).Times(eventBurst)
+
+	eventRecorder := record.NewFakeRecorder(eventBurst + 1)
+	mirror := New(nil, newMockTenantClusterClient, eventRecorder, "")
+	objects := newInfraObjects(newMockInfraClusterClient, vmNamespace, infraID)
+	for i := 0; i <= eventBurst; i++ {
+		event := warningEvent("VirtualMachine", machineName, "vm-uid", "FailedCreate", fmt.Sprintf("attempt %d", i))
+		assert.NilError(t, mirror.mirrorEvent(context.Background(), objects, event))
+	}
+	_, mirrored := mirror.mirrored.Get(fmt.Sprintf("%s/FailedCreate/attempt %d", machineName, eventBurst-1))
+	assert.Assert(t, mirrored)
+	// The rate limited event is mirrored if it occurs again once the machine is sent events again
+	_, mirrored = mirror.mirrored.Get(fmt.Sprintf("%s/FailedCreate/attempt %d", machineName, eventBurst))
+	assert.Assert(t, !mirrored)
+	assert.Equal(t, len(eventRecorder.Events), eventBurst)
+}
+
+func TestMirrorPendingEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+	newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+
+	// The VMI is created after the objects are first listed, and the importer pod isn't of a VM of the tenant-cluster
+	expectListObjectMetadata(newMockInfraClusterClient, map[string][]k8smetav1.PartialObjectMetadata{
+		"virtualmachines": {objectMetadata("vm-uid", machineName)},
+	}, 1)
+	expectListObjectMetadata(newMockInfraClusterClient, map[string][]k8smetav1.PartialObjectMetadata{
+		"virtualmachines":         {objectMetadata("vm-uid", machineName)},
+		"virtualmachineinstances": {objectMetadata("vmi-uid", machineName)},
+	}, 1)
+	machine := &machinev1.Machine{}
+	machine.Name = machineName
+	machine.Namespace = machineNamespace
+	newMockTenantClusterClient.EXPECT().GetMachine(gomock.Any(), machineName, machineNamespace).Return(machine, nil).Times(2)
+
+	eventRecorder := record.NewFakeRecorder(2)
+	mirror := New(nil, newMockTenantClusterClient, eventRecorder, "")
+	objects := newInfraObjects(newMockInfraClusterClient, vmNamespace, infraID)
+	ctx := context.Background()
+	assert.NilError(t, mirror.mirrorEvent(ctx, objects, warningEvent("VirtualMachine", machineName, "vm-uid", "FailedCreate", "quota exceeded")))
+	pending := []*corev1.Event{
+		warningEvent("VirtualMachineInstance", machineName, "vmi-uid", "FailedNetwork", "network-attachment-definition not found"),
+		warningEvent("Pod", "importer-machine-test-bootvolume", "importer-uid", "BackOff", "Back-off restarting failed container"),
+	}
+	for _, event := range pending {
+		assert.Assert(t, errors.Is(mirror.mirrorEvent(ctx, objects, event), errObjectNotListed))
+	}
+
+	// The events wait until the objects can be listed again
+	assert.Equal(t, len(mirror.mirrorPendingEvents(ctx, objects, pending)), len(pending))
+	objects.listTime = objects.listTime.Add(-relistPeriod - time.Second)
+	assert.Equal(t, len(mirror.mirrorPendingEvents(ctx, objects, pending)), 0)
+
+	close(eventRecorder.Events)
+	var gotEvents []string
+	for event := range eventRecorder.Events {
+		gotEvents = append(gotEvents, event)
+	}
+	assert.DeepEqual(t, gotEvents, []string{
+		"Warning FailedCreate VirtualMachine machine-test: quota exceeded",
+		"Warning FailedNetwork VirtualMachineInstance machine-test: network-attachment-definition not found",
+	})
+}
+
+func TestWatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+	newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+
+	newMockTenantClusterClient.EXPECT().GetNamespace(gomock.Any()).Return(vmNamespace, nil)
+	newMockTenantClusterClient.EXPECT().GetInfraID(gomock.Any()).Return(infraID, nil)
+	options := k8smetav1.ListOptions{FieldSelector: "type=Warning"}
+	events := &corev1.EventList{}
+	events.ResourceVersion = "42"
+	newMockInfraClusterClient.EXPECT().ListEvents(gomock.Any(), vmNamespace, options).Return(events, nil)
+	watcher := watch.NewFake()
+	options.ResourceVersion = "42"
+	newMockInfraClusterClient.EXPECT().WatchEvents(gomock.Any(), vmNamespace, options).Return(watcher, nil)
+	expectListObjectMetadata(newMockInfraClusterClient, map[string][]k8smetav1.PartialObjectMetadata{
+		"virtualmachineinstances": {objectMetadata("vmi-uid", machineName)},
+	}, 1)
+	newMockTenantClusterClient.EXPECT().GetMachine(gomock.Any(), machineName, machineNamespace).Return(&machinev1.Machine{}, nil)
+
+	eventRecorder := record.NewFakeRecorder(1)
+	mirror := New(func(_ context.Context, _ tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+		return newMockInfraClusterClient, nil
+	}, newMockTenantClusterClient, eventRecorder, machineNamespace)
+
+	go func() {
+		watcher.Add(warningEvent("VirtualMachineInstance", machineName, "vmi-uid", "FailedNetwork", "network-attachment-definition not found"))
+		watcher.Stop()
+	}()
+	assert.NilError(t, mirror.watch(context.Background()))
+	assert.Equal(t, <-eventRecorder.Events, "Warning FailedNetwork VirtualMachineInstance machine-test: network-attachment-definition not found")
+}
@@ -0,0 +1,87 @@
+package infraevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/managers/vm"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+)
+
+// infraObjectResources are the resources of the infra-cluster objects created for the machine VMs
+var infraObjectResources = []schema.GroupVersionResource{
+	kubevirtapiv1.GroupVersion.WithResource("virtualmachines"),
+	kubevirtapiv1.GroupVersion.WithResource("virtualmachineinstances"),
+	corev1.SchemeGroupVersion.WithResource("pods"),
+	cdiv1.SchemeGroupVersion.WithResource("datavolumes"),
+	corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims"),
+}
+
+// errObjectNotListed is the error of an object unknown until the objects are listed again, since it may have been
+// created after they were listed
+var errObjectNotListed = errors.New("infra-cluster object not listed yet")
+
+// infraObjects indexes the machine names of the infra-cluster objects labelled with the infra ID of the tenant-cluster
+// by their UIDs
+type infraObjects struct {
+	infraClusterClient infracluster.Client
+	namespace          string
+	selector           string
+	machineNames       map[types.UID]string
+	listTime           time.Time
+}
+
+func newInfraObjects(infraClusterClient infracluster.Client, namespace, infraID string) *infraObjects {
+	return &infraObjects{
+		infraClusterClient: infraClusterClient,
+		namespace:          namespace,
+		selector:           labels.SelectorFromSet(utils.BuildLabels(infraID)).String(),
+	}
+}
+
+// machineName returns the machine name of the object uid, and whether it's an object of a machine VM.
+// The objects are listed again on an unknown uid, at most once every relistPeriod, errObjectNotListed is returned
+// for an unknown uid until then.
+func (o *infraObjects) machineName(ctx context.Context, uid types.UID, now time.Time) (string, bool, error) {
+	if machineName, ok := o.machineNames[uid]; ok {
+		return machineName, true, nil
+	}
+	if uid == "" {
+		return "", false, nil
+	}
+	if now.Before(o.nextListTime()) {
+		return "", false, errObjectNotListed
+	}
+	o.listTime = now
+
+	machineNames := map[types.UID]string{}
+	for _, resource := range infraObjectResources {
+		objects, err := o.infraClusterClient.ListObjectMetadata(ctx, o.namespace, resource, k8smetav1.ListOptions{LabelSelector: o.selector})
+		if err != nil {
+			return "", false, fmt.Errorf("failed to list %s of namespace %s: %w", resource.Resource, o.namespace, err)
+		}
+		for _, object := range objects.Items {
+			if machineName := object.Labels[vm.VMNameLabel]; machineName != "" {
+				machineNames[object.UID] = machineName
+			}
+		}
+	}
+	o.machineNames = machineNames
+	machineName, ok := machineNames[uid]
+	return machineName, ok, nil
+}
+
+// nextListTime returns the time from which the objects can be listed again
+func (o *infraObjects) nextListTime() time.Time {
+	return o.listTime.Add(relistPeriod)
+}
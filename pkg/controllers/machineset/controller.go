@@ -0,0 +1,172 @@
+package machineset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
+)
+
+// Capacity annotations of a machine set, read by the cluster autoscaler to scale the machine set from zero
+const (
+	cpuKey    = "machine.openshift.io/vCPU"
+	memoryKey = "machine.openshift.io/memoryMb" // in MiB
+	gpuKey    = "machine.openshift.io/GPU"
+)
+
+// machineSetKey is the key of the machine set name in the log entries of the controller
+const machineSetKey = "machineset"
+
+// Kinds of the provider spec of the KubeVirt machines: the machine manifests also use the
+// KubevirtMachineProviderConfig kind of the other providers, or omit it
+const (
+	kubevirtProviderSpecKind   = "KubevirtMachineProviderSpec"
+	kubevirtProviderConfigKind = "KubevirtMachineProviderConfig"
+)
+
+// Reconciler keeps the capacity annotations of machine sets up to date with their provider spec
+type Reconciler struct {
+	tenantClusterClient tenantcluster.Client
+	eventRecorder       record.EventRecorder
+}
+
+// New returns a machine set reconciler
+func New(tenantClusterClient tenantcluster.Client, eventRecorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		tenantClusterClient: tenantClusterClient,
+		eventRecorder:       eventRecorder,
+	}
+}
+
+// Add registers a machine set controller on the manager
+func Add(mgr manager.Manager, reconciler *Reconciler) error {
+	c, err := controller.New("machineset-controller", mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &machinev1.MachineSet{}}, &handler.EnqueueRequestForObject{})
+}
+
+// Reconcile sets the capacity annotations of a machine set
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log := logf.Log.WithName("machineset-controller").WithValues(machineSetKey, request.Name, logging.NamespaceKey, request.Namespace)
+	ctx := logging.IntoContext(context.Background(), log)
+	machineSet, err := r.tenantClusterClient.GetMachineSet(ctx, request.Name, request.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if machineSet.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	originMachineSetCopy := machineSet.DeepCopy()
+	if err := setCapacityAnnotations(ctx, machineSet); err != nil {
+		// An invalid provider spec is reconciled again once the machine set template is changed
+		log.Error(err, "failed to set capacity annotations")
+		r.eventRecorder.Eventf(machineSet, corev1.EventTypeWarning, "FailedUpdate", "Failed to set capacity annotations: %v", err)
+		return reconcile.Result{}, nil
+	}
+	if reflect.DeepEqual(originMachineSetCopy.Annotations, machineSet.Annotations) {
+		return reconcile.Result{}, nil
+	}
+
+	log.Info("updating capacity annotations")
+	if err := r.tenantClusterClient.PatchMachineSet(ctx, machineSet, originMachineSetCopy); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to patch machine set %s: %w", machineSet.Name, err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// setCapacityAnnotations sets the vCPU, memory and GPU capacity of the machine set machines as annotations.
+// The machine sets of the other providers of the cluster are skipped, their capacity is annotated by their own provider.
+func setCapacityAnnotations(ctx context.Context, machineSet *machinev1.MachineSet) error {
+	isKubevirt, err := isKubevirtProviderSpec(machineSet.Spec.Template.Spec.ProviderSpec.Value)
+	if err != nil {
+		return err
+	}
+	if !isKubevirt {
+		logging.FromContext(ctx).V(4).Info("skipping machine set of another provider")
+		return nil
+	}
+	providerSpec, err := kubevirtproviderv1alpha1.ProviderSpecFromRawExtension(machineSet.Spec.Template.Spec.ProviderSpec.Value)
+	if err != nil {
+		return err
+	}
+	if providerSpec.Instancetype != nil {
+		// The resources of an instancetype are only known to the infra-cluster
+		logging.FromContext(ctx).Info("capacity of instancetype is unknown, removing capacity annotations", "instancetype", providerSpec.Instancetype.Name)
+		for _, key := range []string{cpuKey, memoryKey, gpuKey} {
+			delete(machineSet.Annotations, key)
+		}
+		return nil
+	}
+
+	cpu := providerSpec.RequestedCPU
+	if cpu == 0 {
+		cpu = kubevirtproviderv1alpha1.DefaultRequestedCPU
+	}
+	requestedMemory := providerSpec.RequestedMemory
+	if requestedMemory == "" {
+		requestedMemory = kubevirtproviderv1alpha1.DefaultRequestedMemory
+	}
+	memory, err := apiresource.ParseQuantity(requestedMemory)
+	if err != nil {
+		return fmt.Errorf("invalid RequestedMemory %q: %w", requestedMemory, err)
+	}
+
+	if machineSet.Annotations == nil {
+		machineSet.Annotations = make(map[string]string)
+	}
+	machineSet.Annotations[cpuKey] = fmt.Sprint(cpu)
+	machineSet.Annotations[memoryKey] = fmt.Sprint(memory.Value() / (1024 * 1024))
+	// GPU passthrough is not supported, so machines have no GPUs
+	machineSet.Annotations[gpuKey] = "0"
+	return nil
+}
+
+// isKubevirtProviderSpec returns whether a provider spec is of a kind of the KubeVirt provider spec, or of no kind,
+// in the KubeVirt provider config API group, matched regardless of its case, whose version may be omitted
+func isKubevirtProviderSpec(rawExtension *runtime.RawExtension) (bool, error) {
+	if rawExtension == nil {
+		return false, nil
+	}
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(rawExtension.Raw, &typeMeta); err != nil {
+		return false, fmt.Errorf("error unmarshalling providerSpec: %v", err)
+	}
+	switch typeMeta.Kind {
+	case kubevirtProviderSpecKind, kubevirtProviderConfigKind, "":
+	default:
+		return false, nil
+	}
+	if typeMeta.APIVersion == "" {
+		return true, nil
+	}
+	groupVersion, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+	if err != nil {
+		return false, nil
+	}
+	return strings.EqualFold(groupVersion.Group, kubevirtproviderv1alpha1.SchemeGroupVersion.Group), nil
+}
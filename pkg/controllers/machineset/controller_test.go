@@ -0,0 +1,187 @@
+package machineset
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	machineSetName      = "machineset-test"
+	machineSetNamespace = "default"
+)
+
+func TestReconcile(t *testing.T) {
+	cases := []struct {
+		name            string
+		notFound        bool
+		providerSpec    *kubevirtproviderv1alpha1.KubevirtMachineProviderSpec
+		rawProviderSpec string
+		annotations     map[string]string
+		wantPatch       bool
+		wantAnnotations map[string]string
+		wantEvent       bool
+	}{
+		{
+			name:         "Annotate the default capacity",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{},
+			wantPatch:    true,
+			wantAnnotations: map[string]string{
+				cpuKey:    "1",
+				memoryKey: "1953",
+				gpuKey:    "0",
+			},
+		},
+		{
+			name: "Annotate the requested capacity",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				RequestedCPU:    4,
+				RequestedMemory: "8Gi",
+			},
+			annotations: map[string]string{"existing": "annotation", cpuKey: "1"},
+			wantPatch:   true,
+			wantAnnotations: map[string]string{
+				"existing": "annotation",
+				cpuKey:     "4",
+				memoryKey:  "8192",
+				gpuKey:     "0",
+			},
+		},
+		{
+			name: "Annotate a machine set whose provider spec has no API version",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				TypeMeta:     metav1.TypeMeta{Kind: kubevirtProviderSpecKind},
+				RequestedCPU: 2,
+			},
+			wantPatch: true,
+			wantAnnotations: map[string]string{
+				cpuKey:    "2",
+				memoryKey: "1953",
+				gpuKey:    "0",
+			},
+		},
+		{
+			name:            "Annotate a machine set whose provider spec is of the provider config kind",
+			rawProviderSpec: `{"apiVersion":"Kubevirtproviderconfig.openshift.io/v1beta1","kind":"KubevirtMachineProviderConfig","requestedCPU":2,"requestedMemory":"4Gi"}`,
+			wantPatch:       true,
+			wantAnnotations: map[string]string{cpuKey: "2", memoryKey: "4096", gpuKey: "0"},
+		},
+		{
+			name:            "Annotate a machine set whose provider spec has no kind",
+			rawProviderSpec: `{"requestedCPU":2,"requestedMemory":"2048M"}`,
+			wantPatch:       true,
+			wantAnnotations: map[string]string{cpuKey: "2", memoryKey: "1953", gpuKey: "0"},
+		},
+		{
+			name:            "Skip a machine set of another provider",
+			rawProviderSpec: `{"apiVersion":"awsproviderconfig.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`,
+			annotations:     map[string]string{cpuKey: "2", memoryKey: "8192", gpuKey: "0"},
+			wantAnnotations: map[string]string{cpuKey: "2", memoryKey: "8192", gpuKey: "0"},
+		},
+		{
+			name: "Skip a machine set whose provider spec kind is of another API group",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				TypeMeta: metav1.TypeMeta{APIVersion: "example.com/v1", Kind: kubevirtProviderSpecKind},
+			},
+			annotations:     map[string]string{"existing": "annotation"},
+			wantAnnotations: map[string]string{"existing": "annotation"},
+		},
+		{
+			name: "Skip a machine set with up to date annotations",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				RequestedCPU:    2,
+				RequestedMemory: "4Gi",
+			},
+			annotations: map[string]string{cpuKey: "2", memoryKey: "4096", gpuKey: "0"},
+			wantAnnotations: map[string]string{
+				cpuKey:    "2",
+				memoryKey: "4096",
+				gpuKey:    "0",
+			},
+		},
+		{
+			name: "Skip a machine set with an instancetype",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				Instancetype: &kubevirtproviderv1alpha1.InstancetypeMatcher{Name: "u1.medium"},
+			},
+		},
+		{
+			name: "Remove the capacity of a machine set changed to an instancetype",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				Instancetype: &kubevirtproviderv1alpha1.InstancetypeMatcher{Name: "u1.medium"},
+			},
+			annotations:     map[string]string{"existing": "annotation", cpuKey: "2", memoryKey: "4096", gpuKey: "0"},
+			wantPatch:       true,
+			wantAnnotations: map[string]string{"existing": "annotation"},
+		},
+		{
+			name: "Report an invalid requested memory",
+			providerSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				RequestedMemory: "lots",
+			},
+			wantEvent: true,
+		},
+		{
+			name:     "Ignore a deleted machine set",
+			notFound: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machineSet := &machinev1.MachineSet{}
+			machineSet.Name = machineSetName
+			machineSet.Namespace = machineSetNamespace
+			machineSet.Annotations = tc.annotations
+			if tc.providerSpec != nil {
+				if tc.providerSpec.Kind == "" {
+					tc.providerSpec.TypeMeta = metav1.TypeMeta{APIVersion: kubevirtproviderv1alpha1.SchemeGroupVersion.String(), Kind: kubevirtProviderSpecKind}
+				}
+				providerSpec, err := kubevirtproviderv1alpha1.RawExtensionFromProviderSpec(tc.providerSpec)
+				if err != nil {
+					t.Fatalf("Unable to build provider spec with error: %v", err)
+				}
+				machineSet.Spec.Template.Spec.ProviderSpec.Value = providerSpec
+			}
+			if tc.rawProviderSpec != "" {
+				machineSet.Spec.Template.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: []byte(tc.rawProviderSpec)}
+			}
+
+			if tc.notFound {
+				newMockTenantClusterClient.EXPECT().GetMachineSet(gomock.Any(), machineSetName, machineSetNamespace).Return(nil,
+					apierrors.NewNotFound(schema.GroupResource{Group: "machine.openshift.io", Resource: "machinesets"}, machineSetName))
+			} else {
+				newMockTenantClusterClient.EXPECT().GetMachineSet(gomock.Any(), machineSetName, machineSetNamespace).Return(machineSet, nil)
+			}
+			patchTimes := 0
+			if tc.wantPatch {
+				patchTimes = 1
+			}
+			newMockTenantClusterClient.EXPECT().PatchMachineSet(gomock.Any(), machineSet, gomock.Any()).Return(nil).Times(patchTimes)
+
+			eventRecorder := record.NewFakeRecorder(10)
+			reconciler := New(newMockTenantClusterClient, eventRecorder)
+			result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: machineSetNamespace, Name: machineSetName}})
+			assert.NilError(t, err)
+			assert.Equal(t, result, reconcile.Result{})
+			assert.Equal(t, len(eventRecorder.Events) > 0, tc.wantEvent)
+			if tc.wantAnnotations != nil {
+				assert.DeepEqual(t, machineSet.Annotations, tc.wantAnnotations)
+			}
+		})
+	}
+}
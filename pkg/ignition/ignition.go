@@ -0,0 +1,107 @@
+// Package ignition edits Ignition configs of spec version 2 and 3.
+// The config is handled as generic JSON, so fields unknown to this package and the original version are preserved.
+package ignition
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Config is a parsed Ignition config
+type Config struct {
+	raw          map[string]interface{}
+	majorVersion int
+}
+
+// Parse parses an Ignition config of spec version 2 or 3
+func Parse(data []byte) (*Config, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("user data is not an ignition config: %w", err)
+	}
+	ignitionSection, ok := raw["ignition"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("user data is not an ignition config: missing ignition section")
+	}
+	version, ok := ignitionSection["version"].(string)
+	if !ok {
+		return nil, fmt.Errorf("user data is not an ignition config: missing ignition version")
+	}
+
+	config := &Config{raw: raw}
+	switch {
+	case strings.HasPrefix(version, "2."):
+		config.majorVersion = 2
+	case strings.HasPrefix(version, "3."):
+		config.majorVersion = 3
+	default:
+		return nil, fmt.Errorf("unsupported ignition version %q, expected 2.x or 3.x", version)
+	}
+	return config, nil
+}
+
+// Version returns the spec version of the config
+func (c *Config) Version() string {
+	return c.raw["ignition"].(map[string]interface{})["version"].(string)
+}
+
+// AddFile adds a file to the config, replacing a file of the same path
+func (c *Config) AddFile(path string, mode int, contents []byte) {
+	file := map[string]interface{}{
+		"path": path,
+		"mode": mode,
+		"contents": map[string]interface{}{
+			"source": "data:;base64," + base64.StdEncoding.EncodeToString(contents),
+		},
+	}
+	if c.majorVersion == 2 {
+		file["filesystem"] = "root"
+	} else {
+		file["overwrite"] = true
+	}
+
+	storage := c.section("storage")
+	storage["files"] = replaceByKey(storage["files"], "path", file)
+}
+
+// AddUnit adds a systemd unit to the config, replacing a unit of the same name
+func (c *Config) AddUnit(name string, enabled bool, contents string) {
+	unit := map[string]interface{}{
+		"name":     name,
+		"enabled":  enabled,
+		"contents": contents,
+	}
+
+	systemd := c.section("systemd")
+	systemd["units"] = replaceByKey(systemd["units"], "name", unit)
+}
+
+// Marshal returns the JSON of the config
+func (c *Config) Marshal() ([]byte, error) {
+	return json.Marshal(c.raw)
+}
+
+// section returns the top-level section of the config, adding it when missing
+func (c *Config) section(name string) map[string]interface{} {
+	section, ok := c.raw[name].(map[string]interface{})
+	if !ok {
+		section = map[string]interface{}{}
+		c.raw[name] = section
+	}
+	return section
+}
+
+// replaceByKey returns the list with the entry replacing the entries with the same value of key
+func replaceByKey(list interface{}, key string, entry map[string]interface{}) []interface{} {
+	entries, _ := list.([]interface{})
+	result := make([]interface{}, 0, len(entries)+1)
+	for _, existing := range entries {
+		if existingEntry, ok := existing.(map[string]interface{}); ok && existingEntry[key] == entry[key] {
+			continue
+		}
+		result = append(result, existing)
+	}
+	return append(result, entry)
+}
@@ -0,0 +1,93 @@
+package ignition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name        string
+		data        string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "Ignition v2",
+			data:        `{"ignition":{"version":"2.2.0"}}`,
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "Ignition v3",
+			data:        `{"ignition":{"version":"3.1.0","config":{"merge":[{"source":"https://api-int:22623/config/worker"}]}}}`,
+			wantVersion: "3.1.0",
+		},
+		{
+			name:    "Cloud config",
+			data:    "#cloud-config\nhostname: test",
+			wantErr: "user data is not an ignition config: invalid character '#' looking for beginning of value",
+		},
+		{
+			name:    "JSON without ignition section",
+			data:    `{"storage":{}}`,
+			wantErr: "user data is not an ignition config: missing ignition section",
+		},
+		{
+			name:    "Unsupported version",
+			data:    `{"ignition":{"version":"1.0.0"}}`,
+			wantErr: `unsupported ignition version "1.0.0", expected 2.x or 3.x`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := Parse([]byte(tc.data))
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, config.Version(), tc.wantVersion)
+		})
+	}
+}
+
+func TestAugment(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		wantJSON string
+	}{
+		{
+			name: "Ignition v2",
+			data: `{"ignition":{"version":"2.2.0"},"storage":{"files":[{"filesystem":"root","path":"/etc/hostname","mode":420,"contents":{"source":"data:,old"}},{"filesystem":"root","path":"/etc/motd","mode":420,"contents":{"source":"data:,hi"}}]}}`,
+			wantJSON: `{"ignition":{"version":"2.2.0"},` +
+				`"storage":{"files":[{"filesystem":"root","path":"/etc/motd","mode":420,"contents":{"source":"data:,hi"}},` +
+				`{"filesystem":"root","path":"/etc/hostname","mode":420,"contents":{"source":"data:;base64,bWFjaGluZS0w"}}]},` +
+				`"systemd":{"units":[{"name":"test.service","enabled":true,"contents":"[Service]"}]}}`,
+		},
+		{
+			name: "Ignition v3",
+			data: `{"ignition":{"version":"3.1.0"},"systemd":{"units":[{"name":"test.service","enabled":false}]}}`,
+			wantJSON: `{"ignition":{"version":"3.1.0"},` +
+				`"storage":{"files":[{"overwrite":true,"path":"/etc/hostname","mode":420,"contents":{"source":"data:;base64,bWFjaGluZS0w"}}]},` +
+				`"systemd":{"units":[{"name":"test.service","enabled":true,"contents":"[Service]"}]}}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := Parse([]byte(tc.data))
+			assert.NilError(t, err)
+			config.AddFile("/etc/hostname", 0644, []byte("machine-0"))
+			config.AddUnit("test.service", true, "[Service]")
+
+			data, err := config.Marshal()
+			assert.NilError(t, err)
+			var got, want interface{}
+			assert.NilError(t, json.Unmarshal(data, &got))
+			assert.NilError(t, json.Unmarshal([]byte(tc.wantJSON), &want))
+			assert.DeepEqual(t, got, want)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Package ipam allocates the static addresses of IPPools and the MAC addresses of MACPools to machines.
+// The allocations are recorded in the pool status only, so the caller persists the pool after a change.
+package ipam
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+)
+
+// Allocate returns the address of the pool allocated to the machine, in CIDR notation.
+// A machine has a single address of a pool: its existing allocation is returned again, otherwise the first free
+// address of the pool is allocated to it and allocated is true.
+func Allocate(pool *kubevirtproviderv1alpha1.IPPool, machineName string) (address string, allocated bool, err error) {
+	_, subnet, err := net.ParseCIDR(pool.Spec.CIDR)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid CIDR of pool %s: %w", pool.Name, err)
+	}
+	prefixLength, _ := subnet.Mask.Size()
+
+	for ip, owner := range pool.Status.Allocations {
+		if owner == machineName {
+			return fmt.Sprintf("%s/%d", ip, prefixLength), false, nil
+		}
+	}
+
+	excluded, err := parseExclusions(pool)
+	if err != nil {
+		return "", false, err
+	}
+	broadcast := lastAddress(subnet)
+	for ip := nextAddress(subnet.IP); subnet.Contains(ip); ip = nextAddress(ip) {
+		if ip.To4() != nil && ip.Equal(broadcast) {
+			break
+		}
+		if _, ok := pool.Status.Allocations[ip.String()]; ok || excluded(ip) {
+			continue
+		}
+		if pool.Status.Allocations == nil {
+			pool.Status.Allocations = map[string]string{}
+		}
+		pool.Status.Allocations[ip.String()] = machineName
+		return fmt.Sprintf("%s/%d", ip, prefixLength), true, nil
+	}
+	return "", false, fmt.Errorf("pool %s has no free address", pool.Name)
+}
+
+// Release releases the addresses of the pool allocated to the machine, and returns true if the pool was changed
+func Release(pool *kubevirtproviderv1alpha1.IPPool, machineName string) bool {
+	released := false
+	for ip, owner := range pool.Status.Allocations {
+		if owner == machineName {
+			delete(pool.Status.Allocations, ip)
+			released = true
+		}
+	}
+	return released
+}
+
+// parseExclusions returns a function matching the gateway and the excluded addresses of the pool
+func parseExclusions(pool *kubevirtproviderv1alpha1.IPPool) (func(net.IP) bool, error) {
+	type addressRange struct{ first, last net.IP }
+	var ranges []addressRange
+	exclusions := pool.Spec.Exclusions
+	if pool.Spec.Gateway != "" {
+		exclusions = append([]string{pool.Spec.Gateway}, exclusions...)
+	}
+	for _, exclusion := range exclusions {
+		bounds := strings.SplitN(exclusion, "-", 2)
+		first := net.ParseIP(strings.TrimSpace(bounds[0]))
+		last := first
+		if len(bounds) == 2 {
+			last = net.ParseIP(strings.TrimSpace(bounds[1]))
+		}
+		if first == nil || last == nil {
+			return nil, fmt.Errorf("invalid exclusion %q of pool %s", exclusion, pool.Name)
+		}
+		ranges = append(ranges, addressRange{first: first.To16(), last: last.To16()})
+	}
+	return func(ip net.IP) bool {
+		ip = ip.To16()
+		for _, r := range ranges {
+			if bytes.Compare(ip, r.first) >= 0 && bytes.Compare(ip, r.last) <= 0 {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func nextAddress(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+func lastAddress(subnet *net.IPNet) net.IP {
+	last := make(net.IP, len(subnet.IP))
+	for i := range subnet.IP {
+		last[i] = subnet.IP[i] | ^subnet.Mask[i]
+	}
+	return last
+}
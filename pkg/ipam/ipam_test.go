@@ -0,0 +1,95 @@
+package ipam
+
+import (
+	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllocate(t *testing.T) {
+	cases := []struct {
+		name            string
+		spec            kubevirtproviderv1alpha1.IPPoolSpec
+		allocations     map[string]string
+		wantAddress     string
+		wantAllocated   bool
+		wantAllocations map[string]string
+		wantErr         string
+	}{
+		{
+			name:            "Allocate the first address",
+			spec:            kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0/24"},
+			wantAddress:     "10.0.0.1/24",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"10.0.0.1": "machine-0"},
+		},
+		{
+			name:            "Skip the gateway, exclusions and allocated addresses",
+			spec:            kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", Exclusions: []string{"10.0.0.2-10.0.0.4", "10.0.0.6"}},
+			allocations:     map[string]string{"10.0.0.5": "machine-1"},
+			wantAddress:     "10.0.0.7/24",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"10.0.0.5": "machine-1", "10.0.0.7": "machine-0"},
+		},
+		{
+			name:            "Return the existing allocation",
+			spec:            kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0/24"},
+			allocations:     map[string]string{"10.0.0.1": "machine-1", "10.0.0.9": "machine-0"},
+			wantAddress:     "10.0.0.9/24",
+			wantAllocations: map[string]string{"10.0.0.1": "machine-1", "10.0.0.9": "machine-0"},
+		},
+		{
+			name:            "Allocate an IPv6 address",
+			spec:            kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "fd00::/64", Gateway: "fd00::1"},
+			wantAddress:     "fd00::2/64",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"fd00::2": "machine-0"},
+		},
+		{
+			name:            "Fail on an exhausted pool without the broadcast address",
+			spec:            kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0/30"},
+			allocations:     map[string]string{"10.0.0.1": "machine-1", "10.0.0.2": "machine-2"},
+			wantAllocations: map[string]string{"10.0.0.1": "machine-1", "10.0.0.2": "machine-2"},
+			wantErr:         "pool pool has no free address",
+		},
+		{
+			name:    "Fail on an invalid CIDR",
+			spec:    kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0"},
+			wantErr: "invalid CIDR of pool pool: invalid CIDR address: 10.0.0.0",
+		},
+		{
+			name:    "Fail on an invalid exclusion",
+			spec:    kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0/24", Exclusions: []string{"10.0.0.2-"}},
+			wantErr: `invalid exclusion "10.0.0.2-" of pool pool`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := &kubevirtproviderv1alpha1.IPPool{
+				ObjectMeta: metav1.ObjectMeta{Name: "pool"},
+				Spec:       tc.spec,
+				Status:     kubevirtproviderv1alpha1.IPPoolStatus{Allocations: tc.allocations},
+			}
+			address, allocated, err := Allocate(pool, "machine-0")
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, address, tc.wantAddress)
+			assert.Equal(t, allocated, tc.wantAllocated)
+			assert.DeepEqual(t, pool.Status.Allocations, tc.wantAllocations)
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	pool := &kubevirtproviderv1alpha1.IPPool{
+		Status: kubevirtproviderv1alpha1.IPPoolStatus{Allocations: map[string]string{"10.0.0.1": "machine-0", "10.0.0.2": "machine-1"}},
+	}
+	assert.Equal(t, Release(pool, "machine-0"), true)
+	assert.DeepEqual(t, pool.Status.Allocations, map[string]string{"10.0.0.2": "machine-1"})
+	assert.Equal(t, Release(pool, "machine-0"), false)
+}
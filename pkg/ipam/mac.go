@@ -0,0 +1,138 @@
+package ipam
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"strings"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+)
+
+const macAddressLength = 6
+
+// AllocateMAC returns the MAC address of the pool allocated to the machine.
+// A machine has a single address of a pool: its existing allocation is returned again, otherwise a free address is
+// allocated to it and allocated is true. The search for a free address starts at an offset of the pool derived from
+// the seed, so a machine likely gets the same address again after the pool allocations are lost, and allocated
+// addresses are skipped, so machines whose seeds collide get distinct addresses.
+func AllocateMAC(pool *kubevirtproviderv1alpha1.MACPool, machineName, seed string) (address string, allocated bool, err error) {
+	first, last, err := parseMACRange(pool)
+	if err != nil {
+		return "", false, err
+	}
+
+	for macAddress, owner := range pool.Status.Allocations {
+		if owner == machineName {
+			return macAddress, false, nil
+		}
+	}
+
+	size := last - first + 1
+	if uint64(len(pool.Status.Allocations)) >= size {
+		return "", false, fmt.Errorf("pool %s has no free address", pool.Name)
+	}
+	offset := macOffset(seed, size)
+	for i := uint64(0); i < size; i++ {
+		macAddress := formatMAC(first + (offset+i)%size)
+		if _, ok := pool.Status.Allocations[macAddress]; ok {
+			continue
+		}
+		if pool.Status.Allocations == nil {
+			pool.Status.Allocations = map[string]string{}
+		}
+		pool.Status.Allocations[macAddress] = machineName
+		return macAddress, true, nil
+	}
+	return "", false, fmt.Errorf("pool %s has no free address", pool.Name)
+}
+
+// ReleaseMAC releases the addresses of the pool allocated to the machine, and returns true if the pool was changed
+func ReleaseMAC(pool *kubevirtproviderv1alpha1.MACPool, machineName string) bool {
+	released := false
+	for macAddress, owner := range pool.Status.Allocations {
+		if owner == machineName {
+			delete(pool.Status.Allocations, macAddress)
+			released = true
+		}
+	}
+	return released
+}
+
+// macOffset returns the offset of the seed in a pool of size addresses
+func macOffset(seed string, size uint64) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	return hash.Sum64() % size
+}
+
+// parseMACRange returns the first and last addresses of the pool, as integers.
+// The addresses of the pool have to be unicast, so the range can't include an address of a multicast first octet.
+func parseMACRange(pool *kubevirtproviderv1alpha1.MACPool) (first, last uint64, err error) {
+	switch {
+	case pool.Spec.Prefix != "" && pool.Spec.Range != "":
+		return 0, 0, fmt.Errorf("pool %s has both a prefix and a range", pool.Name)
+	case pool.Spec.Prefix != "":
+		first, last, err = parseMACPrefix(pool.Spec.Prefix)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid prefix %q of pool %s: %w", pool.Spec.Prefix, pool.Name, err)
+		}
+	case pool.Spec.Range != "":
+		bounds := strings.SplitN(pool.Spec.Range, "-", 2)
+		if len(bounds) == 2 {
+			first, err = parseMAC(strings.TrimSpace(bounds[0]))
+			if err == nil {
+				last, err = parseMAC(strings.TrimSpace(bounds[1]))
+			}
+		}
+		if len(bounds) != 2 || err != nil || first > last {
+			return 0, 0, fmt.Errorf("invalid range %q of pool %s", pool.Spec.Range, pool.Name)
+		}
+	default:
+		return 0, 0, fmt.Errorf("pool %s has neither a prefix nor a range", pool.Name)
+	}
+
+	for octet := first >> 40; octet <= last>>40; octet++ {
+		if octet&1 != 0 {
+			return 0, 0, fmt.Errorf("pool %s includes multicast addresses", pool.Name)
+		}
+	}
+	return first, last, nil
+}
+
+// parseMACPrefix returns the first and last addresses of a prefix of one to five octets
+func parseMACPrefix(prefix string) (first, last uint64, err error) {
+	parts := strings.Split(prefix, ":")
+	if len(parts) >= macAddressLength {
+		return 0, 0, fmt.Errorf("prefix has to be shorter than %d octets", macAddressLength)
+	}
+	for _, part := range parts {
+		octet, err := strconv.ParseUint(part, 16, 8)
+		if len(part) != 2 || err != nil {
+			return 0, 0, fmt.Errorf("invalid octet %q", part)
+		}
+		first = first<<8 | octet
+	}
+	suffixBits := uint(8 * (macAddressLength - len(parts)))
+	first <<= suffixBits
+	return first, first | (1<<suffixBits - 1), nil
+}
+
+func parseMAC(macAddress string) (uint64, error) {
+	hardwareAddr, err := net.ParseMAC(macAddress)
+	if err != nil {
+		return 0, err
+	}
+	if len(hardwareAddr) != macAddressLength {
+		return 0, fmt.Errorf("%q isn't a MAC-48 address", macAddress)
+	}
+	return binary.BigEndian.Uint64(append([]byte{0, 0}, hardwareAddr...)), nil
+}
+
+func formatMAC(address uint64) string {
+	octets := make([]byte, 8)
+	binary.BigEndian.PutUint64(octets, address)
+	return net.HardwareAddr(octets[2:]).String()
+}
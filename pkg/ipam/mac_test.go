@@ -0,0 +1,156 @@
+package ipam
+
+import (
+	"fmt"
+	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllocateMAC(t *testing.T) {
+	cases := []struct {
+		name            string
+		spec            kubevirtproviderv1alpha1.MACPoolSpec
+		allocations     map[string]string
+		wantAddress     string
+		wantAllocated   bool
+		wantAllocations map[string]string
+		wantErr         string
+	}{
+		{
+			name:            "Allocate the address of the seed offset of a prefix",
+			spec:            kubevirtproviderv1alpha1.MACPoolSpec{Prefix: "02:00:00"},
+			wantAddress:     "02:00:00:8c:b7:5a",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"02:00:00:8c:b7:5a": "machine-0"},
+		},
+		{
+			name:            "Allocate the address of the seed offset of a range",
+			spec:            kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:10:00-02:00:00:00:1f:ff"},
+			wantAddress:     "02:00:00:00:17:5a",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"02:00:00:00:17:5a": "machine-0"},
+		},
+		{
+			name:            "Skip an allocated address",
+			spec:            kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:10:00-02:00:00:00:1f:ff"},
+			allocations:     map[string]string{"02:00:00:00:17:5a": "machine-1"},
+			wantAddress:     "02:00:00:00:17:5b",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"02:00:00:00:17:5a": "machine-1", "02:00:00:00:17:5b": "machine-0"},
+		},
+		{
+			name:            "Wrap around the end of the range",
+			spec:            kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:00:01-02:00:00:00:00:02"},
+			allocations:     map[string]string{"02:00:00:00:00:02": "machine-1"},
+			wantAddress:     "02:00:00:00:00:01",
+			wantAllocated:   true,
+			wantAllocations: map[string]string{"02:00:00:00:00:01": "machine-0", "02:00:00:00:00:02": "machine-1"},
+		},
+		{
+			name:            "Return the existing allocation",
+			spec:            kubevirtproviderv1alpha1.MACPoolSpec{Prefix: "02:00:00"},
+			allocations:     map[string]string{"02:00:00:12:34:56": "machine-0"},
+			wantAddress:     "02:00:00:12:34:56",
+			wantAllocations: map[string]string{"02:00:00:12:34:56": "machine-0"},
+		},
+		{
+			name:            "Fail on an exhausted pool",
+			spec:            kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:00:01-02:00:00:00:00:02"},
+			allocations:     map[string]string{"02:00:00:00:00:01": "machine-1", "02:00:00:00:00:02": "machine-2"},
+			wantAllocations: map[string]string{"02:00:00:00:00:01": "machine-1", "02:00:00:00:00:02": "machine-2"},
+			wantErr:         "pool pool has no free address",
+		},
+		{
+			name:    "Fail on a multicast prefix",
+			spec:    kubevirtproviderv1alpha1.MACPoolSpec{Prefix: "01:00:5e"},
+			wantErr: "pool pool includes multicast addresses",
+		},
+		{
+			name:    "Fail on a range including multicast addresses",
+			spec:    kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:00:00-04:00:00:00:00:00"},
+			wantErr: "pool pool includes multicast addresses",
+		},
+		{
+			name:    "Fail on an invalid octet",
+			spec:    kubevirtproviderv1alpha1.MACPoolSpec{Prefix: "02:0g"},
+			wantErr: `invalid prefix "02:0g" of pool pool: invalid octet "0g"`,
+		},
+		{
+			name:    "Fail on a full address prefix",
+			spec:    kubevirtproviderv1alpha1.MACPoolSpec{Prefix: "02:00:00:00:00:01"},
+			wantErr: `invalid prefix "02:00:00:00:00:01" of pool pool: prefix has to be shorter than 6 octets`,
+		},
+		{
+			name:    "Fail on a reversed range",
+			spec:    kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:00:02-02:00:00:00:00:01"},
+			wantErr: `invalid range "02:00:00:00:00:02-02:00:00:00:00:01" of pool pool`,
+		},
+		{
+			name:    "Fail on both a prefix and a range",
+			spec:    kubevirtproviderv1alpha1.MACPoolSpec{Prefix: "02:00:00", Range: "02:00:00:00:00:01-02:00:00:00:00:02"},
+			wantErr: "pool pool has both a prefix and a range",
+		},
+		{
+			name:    "Fail without prefix and range",
+			wantErr: "pool pool has neither a prefix nor a range",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := &kubevirtproviderv1alpha1.MACPool{
+				ObjectMeta: metav1.ObjectMeta{Name: "pool"},
+				Spec:       tc.spec,
+				Status:     kubevirtproviderv1alpha1.MACPoolStatus{Allocations: tc.allocations},
+			}
+			address, allocated, err := AllocateMAC(pool, "machine-0", "8f1c2c3e-5b7a-4f0e-9a51-6d1e2f3a4b5c")
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, address, tc.wantAddress)
+			assert.Equal(t, allocated, tc.wantAllocated)
+			assert.DeepEqual(t, pool.Status.Allocations, tc.wantAllocations)
+		})
+	}
+}
+
+func TestAllocateMACOfCollidingSeeds(t *testing.T) {
+	pool := &kubevirtproviderv1alpha1.MACPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool"},
+		Spec:       kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:00:00-02:00:00:00:00:0f"},
+	}
+
+	// Find two seeds of the same offset in the pool of 16 addresses
+	offsets := map[uint64]string{}
+	var seeds []string
+	for i := 0; len(seeds) == 0; i++ {
+		seed := fmt.Sprintf("uid-%d", i)
+		offset := macOffset(seed, 16)
+		if collidingSeed, ok := offsets[offset]; ok {
+			seeds = []string{collidingSeed, seed}
+		}
+		offsets[offset] = seed
+	}
+
+	firstAddress, allocated, err := AllocateMAC(pool, "machine-0", seeds[0])
+	assert.NilError(t, err)
+	assert.Assert(t, allocated)
+	secondAddress, allocated, err := AllocateMAC(pool, "machine-1", seeds[1])
+	assert.NilError(t, err)
+	assert.Assert(t, allocated)
+	assert.Assert(t, firstAddress != secondAddress, "machines of colliding seeds got the same address %s", firstAddress)
+	assert.DeepEqual(t, pool.Status.Allocations, map[string]string{firstAddress: "machine-0", secondAddress: "machine-1"})
+}
+
+func TestReleaseMAC(t *testing.T) {
+	pool := &kubevirtproviderv1alpha1.MACPool{
+		Status: kubevirtproviderv1alpha1.MACPoolStatus{Allocations: map[string]string{"02:00:00:00:00:01": "machine-0", "02:00:00:00:00:02": "machine-1"}},
+	}
+	assert.Equal(t, ReleaseMAC(pool, "machine-0"), true)
+	assert.DeepEqual(t, pool.Status.Allocations, map[string]string{"02:00:00:00:00:02": "machine-1"})
+	assert.Equal(t, ReleaseMAC(pool, "machine-0"), false)
+}
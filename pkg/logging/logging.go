@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	crzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	// FormatJSON writes a JSON object per log entry
+	FormatJSON = "json"
+	// FormatConsole writes a human readable line per log entry
+	FormatConsole = "console"
+)
+
+// The keys of the fields which correlate the log entries of a machine
+const (
+	MachineKey     = "machine"
+	NamespaceKey   = "namespace"
+	OperationKey   = "operation"
+	InfraIDKey     = "infraID"
+	VMNamespaceKey = "vmNamespace"
+)
+
+type loggerKey struct{}
+
+// New returns a zap logger writing its entries in format, which logs the entries up to verbosity
+func New(format string, verbosity int) (logr.Logger, error) {
+	var encoder zapcore.Encoder
+	switch format {
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected %q or %q", format, FormatJSON, FormatConsole)
+	}
+	if verbosity < 0 {
+		return nil, fmt.Errorf("invalid log verbosity %d, expected a positive verbosity", verbosity)
+	}
+	// The verbosity V(n) of a logr logger is the zap level -n
+	level := zap.NewAtomicLevelAt(zapcore.Level(-verbosity))
+	return crzap.New(crzap.Encoder(encoder), crzap.Level(&level)), nil
+}
+
+// IntoContext returns a copy of ctx carrying log
+func IntoContext(ctx context.Context, log logr.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext returns the logger carried by ctx, or the controller-runtime logger when ctx doesn't carry one
+func FromContext(ctx context.Context) logr.Logger {
+	if ctx != nil {
+		if log, ok := ctx.Value(loggerKey{}).(logr.Logger); ok {
+			return log
+		}
+	}
+	return logf.Log
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		format    string
+		verbosity int
+		wantErr   string
+	}{
+		{
+			name:   "JSON logger",
+			format: FormatJSON,
+		},
+		{
+			name:      "Verbose console logger",
+			format:    FormatConsole,
+			verbosity: 4,
+		},
+		{
+			name:    "Invalid format",
+			format:  "xml",
+			wantErr: `invalid log format "xml", expected "json" or "console"`,
+		},
+		{
+			name:      "Invalid verbosity",
+			format:    FormatJSON,
+			verbosity: -1,
+			wantErr:   "invalid log verbosity -1, expected a positive verbosity",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			log, err := New(tc.format, tc.verbosity)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Assert(t, log.V(tc.verbosity).Enabled())
+			assert.Assert(t, !log.V(tc.verbosity+1).Enabled())
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	log := logf.NullLogger{}.WithValues(MachineKey, "machine-test")
+
+	assert.Equal(t, FromContext(IntoContext(context.Background(), log)), log)
+	assert.Equal(t, FromContext(context.Background()), logf.Log)
+	assert.Equal(t, FromContext(nil), logf.Log)
+}
@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// backoffBaseDelay is the delay before retrying the first failed operation on a machine
+	backoffBaseDelay = 5 * time.Second
+	// backoffMaxDelay caps the delay before retrying the operations on a machine which keep failing
+	backoffMaxDelay = 5 * time.Minute
+	// backoffJitter is the fraction of the delay randomly taken off it, so failing machines don't retry together
+	backoffJitter = 0.2
+)
+
+// backoffDelay returns the jittered exponential delay before retrying after consecutive failures,
+// doubled by each failure up to backoffMaxDelay
+func backoffDelay(failures int32) time.Duration {
+	delay := backoffBaseDelay
+	for i := int32(1); i < failures && delay < backoffMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > backoffMaxDelay {
+		delay = backoffMaxDelay
+	}
+	return delay - time.Duration(rand.Float64()*backoffJitter*float64(delay))
+}
+
+// requeueWithBackoff records a failed operation on the machine in the provider status, and delays the requeue
+// of err by the backoff of its consecutive failures, or by the delay of err when it's longer, like a delay asked
+// for by the infra-cluster. Errors other than a requeue are returned as is.
+func (s *machineScope) requeueWithBackoff(err error, now time.Time) error {
+	var requeueErr *machinecontroller.RequeueAfterError
+	if !errors.As(err, &requeueErr) {
+		return err
+	}
+
+	backoff := s.machineProviderStatus.Backoff
+	if backoff == nil {
+		backoff = &kubevirtproviderv1alpha1.BackoffStatus{}
+		s.machineProviderStatus.Backoff = backoff
+	}
+	backoff.Failures++
+	delay := backoffDelay(backoff.Failures)
+	if requeueErr.RequeueAfter > delay {
+		delay = requeueErr.RequeueAfter
+	}
+	nextAttemptTime := metav1.NewTime(now.Add(delay))
+	backoff.Delay = metav1.Duration{Duration: delay}
+	backoff.NextAttemptTime = &nextAttemptTime
+
+	s.logger().Info("operation failed, retrying with backoff", "failures", backoff.Failures, "delay", delay)
+	return &machinecontroller.RequeueAfterError{RequeueAfter: delay}
+}
+
+// resetBackoff forgets the failed operations on the machine after a successful one
+func (s *machineScope) resetBackoff() {
+	s.machineProviderStatus.Backoff = nil
+}
+
+// waitForBackoff returns a requeue until the next attempt of a failed operation on the machine,
+// since the machine is also reconciled on its own changes, like the update of its provider status
+func (s *machineScope) waitForBackoff(now time.Time) error {
+	backoff := s.machineProviderStatus.Backoff
+	if backoff == nil || backoff.NextAttemptTime == nil || !now.Before(backoff.NextAttemptTime.Time) {
+		return nil
+	}
+	s.logger().Info("waiting for the next attempt", "nextAttemptTime", backoff.NextAttemptTime.Time)
+	return &machinecontroller.RequeueAfterError{RequeueAfter: backoff.NextAttemptTime.Sub(now)}
+}
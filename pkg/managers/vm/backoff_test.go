@@ -0,0 +1,146 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	cases := []struct {
+		name     string
+		failures int32
+		want     time.Duration
+	}{
+		{
+			name:     "Base delay of the first failure",
+			failures: 1,
+			want:     backoffBaseDelay,
+		},
+		{
+			name:     "Doubled delay of each failure",
+			failures: 4,
+			want:     8 * backoffBaseDelay,
+		},
+		{
+			name:     "Capped delay of many failures",
+			failures: 1000,
+			want:     backoffMaxDelay,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				got := backoffDelay(tc.failures)
+				assert.Assert(t, got <= tc.want, "delay %v is longer than %v", got, tc.want)
+				assert.Assert(t, float64(got) >= (1-backoffJitter)*float64(tc.want), "delay %v is too jittered from %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequeueWithBackoff(t *testing.T) {
+	now := time.Now()
+	otherErr := errors.New("failed to hash VM")
+	cases := []struct {
+		name             string
+		backoff          *kubevirtproviderv1alpha1.BackoffStatus
+		err              error
+		wantErr          error
+		wantFailures     int32
+		wantRequeueAfter time.Duration
+	}{
+		{
+			name:             "Back off the first failure",
+			err:              &machinecontroller.RequeueAfterError{RequeueAfter: time.Second},
+			wantFailures:     1,
+			wantRequeueAfter: backoffBaseDelay,
+		},
+		{
+			name:             "Back off consecutive failures",
+			backoff:          &kubevirtproviderv1alpha1.BackoffStatus{Failures: 4},
+			err:              &machinecontroller.RequeueAfterError{RequeueAfter: time.Second},
+			wantFailures:     5,
+			wantRequeueAfter: 16 * backoffBaseDelay,
+		},
+		{
+			name:             "Honor a longer requeue delay",
+			err:              &machinecontroller.RequeueAfterError{RequeueAfter: 10 * time.Minute},
+			wantFailures:     1,
+			wantRequeueAfter: 10 * time.Minute,
+		},
+		{
+			name:    "Return other errors",
+			backoff: &kubevirtproviderv1alpha1.BackoffStatus{Failures: 2},
+			err:     otherErr,
+			wantErr: otherErr,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine:               initializeMachine(t, nil, "", false),
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{Backoff: tc.backoff},
+			}
+			err := machineScope.requeueWithBackoff(tc.err, now)
+			if tc.wantErr != nil {
+				assert.Equal(t, err, tc.wantErr)
+				assert.DeepEqual(t, machineScope.machineProviderStatus.Backoff, tc.backoff)
+				return
+			}
+
+			requeueErr, ok := err.(*machinecontroller.RequeueAfterError)
+			assert.Assert(t, ok, "expected a RequeueAfterError, got %v", err)
+			assert.Assert(t, requeueErr.RequeueAfter <= tc.wantRequeueAfter)
+			assert.Assert(t, float64(requeueErr.RequeueAfter) >= (1-backoffJitter)*float64(tc.wantRequeueAfter))
+
+			backoff := machineScope.machineProviderStatus.Backoff
+			assert.Equal(t, backoff.Failures, tc.wantFailures)
+			assert.Equal(t, backoff.Delay.Duration, requeueErr.RequeueAfter)
+			assert.Equal(t, backoff.NextAttemptTime.Time, now.Add(requeueErr.RequeueAfter))
+		})
+	}
+}
+
+func TestWaitForBackoff(t *testing.T) {
+	now := time.Now()
+	later := metav1.NewTime(now.Add(time.Minute))
+	earlier := metav1.NewTime(now.Add(-time.Minute))
+	cases := []struct {
+		name             string
+		backoff          *kubevirtproviderv1alpha1.BackoffStatus
+		wantRequeueAfter time.Duration
+	}{
+		{
+			name: "Attempt a machine without failures",
+		},
+		{
+			name:    "Attempt a machine after its backoff",
+			backoff: &kubevirtproviderv1alpha1.BackoffStatus{Failures: 1, NextAttemptTime: &earlier},
+		},
+		{
+			name:             "Wait for the backoff of a machine",
+			backoff:          &kubevirtproviderv1alpha1.BackoffStatus{Failures: 1, NextAttemptTime: &later},
+			wantRequeueAfter: time.Minute,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine:               initializeMachine(t, nil, "", false),
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{Backoff: tc.backoff},
+			}
+			err := machineScope.waitForBackoff(now)
+			if tc.wantRequeueAfter == 0 {
+				assert.NilError(t, err)
+				return
+			}
+			assert.DeepEqual(t, err, &machinecontroller.RequeueAfterError{RequeueAfter: tc.wantRequeueAfter})
+		})
+	}
+}
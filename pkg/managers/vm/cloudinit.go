@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"fmt"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// networkData is the cloud-init network config version 2 of a machine
+type networkData struct {
+	Version   int                            `json:"version"`
+	Ethernets map[string]networkDataEthernet `json:"ethernets"`
+}
+
+type networkDataEthernet struct {
+	DHCP4       bool                    `json:"dhcp4,omitempty"`
+	DHCP6       bool                    `json:"dhcp6,omitempty"`
+	Addresses   []string                `json:"addresses,omitempty"`
+	Gateway4    string                  `json:"gateway4,omitempty"`
+	Gateway6    string                  `json:"gateway6,omitempty"`
+	Nameservers *networkDataNameservers `json:"nameservers,omitempty"`
+}
+
+type networkDataNameservers struct {
+	Addresses []string `json:"addresses"`
+}
+
+func (s *machineScope) getUserDataFormat() (string, error) {
+	switch userDataFormat := s.machineProviderSpec.UserDataFormat; userDataFormat {
+	case "":
+		return kubevirtproviderv1alpha1.UserDataFormatIgnition, nil
+	case kubevirtproviderv1alpha1.UserDataFormatIgnition:
+		return userDataFormat, nil
+	case kubevirtproviderv1alpha1.UserDataFormatCloudConfig:
+		if len(s.machineProviderSpec.IgnitionFiles) > 0 || len(s.machineProviderSpec.IgnitionUnits) > 0 {
+			return "", machinecontroller.InvalidMachineConfiguration("%v: IgnitionFiles and IgnitionUnits require the %v user data format",
+				s.machine.GetName(), kubevirtproviderv1alpha1.UserDataFormatIgnition)
+		}
+		return userDataFormat, nil
+	default:
+		return "", machinecontroller.InvalidMachineConfiguration("%v: Value of UserDataFormat, can be only one of: %v, %v", s.machine.GetName(),
+			kubevirtproviderv1alpha1.UserDataFormatIgnition, kubevirtproviderv1alpha1.UserDataFormatCloudConfig)
+	}
+}
+
+// buildCloudInitVolumeSource returns the volume source of the user data secret, and of the network data
+// of cloud-config user data
+func (s *machineScope) buildCloudInitVolumeSource() (kubevirtapiv1.VolumeSource, error) {
+	userDataFormat, err := s.getUserDataFormat()
+	if err != nil {
+		return kubevirtapiv1.VolumeSource{}, err
+	}
+	secretRef := &corev1.LocalObjectReference{Name: buildUserDataSecretName(s.machine.GetName())}
+	var networkDataSecretRef *corev1.LocalObjectReference
+	if userDataFormat == kubevirtproviderv1alpha1.UserDataFormatCloudConfig && s.hasStaticNetworkConfig() {
+		networkDataSecretRef = secretRef
+	}
+
+	switch s.machineProviderSpec.CloudInitDataSource {
+	case "", kubevirtproviderv1alpha1.CloudInitDataSourceConfigDrive:
+		return kubevirtapiv1.VolumeSource{
+			CloudInitConfigDrive: &kubevirtapiv1.CloudInitConfigDriveSource{
+				UserDataSecretRef:    secretRef,
+				NetworkDataSecretRef: networkDataSecretRef,
+			},
+		}, nil
+	case kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud:
+		if userDataFormat == kubevirtproviderv1alpha1.UserDataFormatIgnition {
+			return kubevirtapiv1.VolumeSource{}, machinecontroller.InvalidMachineConfiguration("%v: Ignition user data requires the %v data source",
+				s.machine.GetName(), kubevirtproviderv1alpha1.CloudInitDataSourceConfigDrive)
+		}
+		return kubevirtapiv1.VolumeSource{
+			CloudInitNoCloud: &kubevirtapiv1.CloudInitNoCloudSource{
+				UserDataSecretRef:    secretRef,
+				NetworkDataSecretRef: networkDataSecretRef,
+			},
+		}, nil
+	default:
+		return kubevirtapiv1.VolumeSource{}, machinecontroller.InvalidMachineConfiguration("%v: Value of CloudInitDataSource, can be only one of: %v, %v", s.machine.GetName(),
+			kubevirtproviderv1alpha1.CloudInitDataSourceConfigDrive, kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud)
+	}
+}
+
+// buildNetworkData returns the cloud-init network data configuring the static addresses of the interfaces,
+// an enabled address family without addresses is configured by DHCP
+func buildNetworkData(interfaces []interfaceConfig) (string, error) {
+	ethernets := make(map[string]networkDataEthernet, len(interfaces))
+	for _, iface := range interfaces {
+		ethernet := networkDataEthernet{
+			DHCP4:     !iface.ipv4.disabled && len(iface.ipv4.addresses) == 0,
+			DHCP6:     !iface.ipv6.disabled && len(iface.ipv6.addresses) == 0,
+			Addresses: append(append([]string{}, iface.ipv4.addresses...), iface.ipv6.addresses...),
+			Gateway4:  iface.ipv4.gateway,
+			Gateway6:  iface.ipv6.gateway,
+		}
+		if len(ethernet.Addresses) == 0 {
+			ethernet.Addresses = nil
+		}
+		if dnsServers := append(append([]string{}, iface.ipv4.dnsServers...), iface.ipv6.dnsServers...); len(dnsServers) > 0 {
+			ethernet.Nameservers = &networkDataNameservers{Addresses: dnsServers}
+		}
+		ethernets[iface.name] = ethernet
+	}
+
+	data, err := yaml.Marshal(networkData{
+		Version:   2,
+		Ethernets: ethernets,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal network data: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,187 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestBuildCloudInitVolumeSource(t *testing.T) {
+	secretRef := &corev1.LocalObjectReference{Name: "machine-test-userdata"}
+	networkConfig := &kubevirtproviderv1alpha1.NetworkConfig{Addresses: []string{"10.0.0.5/24"}}
+	cases := []struct {
+		name         string
+		providerSpec kubevirtproviderv1alpha1.KubevirtMachineProviderSpec
+		want         kubevirtapiv1.VolumeSource
+		wantErr      string
+	}{
+		{
+			name: "Ignition in a config drive by default",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				NetworkConfig: networkConfig,
+			},
+			want: kubevirtapiv1.VolumeSource{
+				CloudInitConfigDrive: &kubevirtapiv1.CloudInitConfigDriveSource{UserDataSecretRef: secretRef},
+			},
+		},
+		{
+			name: "Cloud config in a config drive with network data",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat: kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				NetworkConfig:  networkConfig,
+			},
+			want: kubevirtapiv1.VolumeSource{
+				CloudInitConfigDrive: &kubevirtapiv1.CloudInitConfigDriveSource{UserDataSecretRef: secretRef, NetworkDataSecretRef: secretRef},
+			},
+		},
+		{
+			name: "Cloud config in NoCloud without network data",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat:      kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				CloudInitDataSource: kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud,
+			},
+			want: kubevirtapiv1.VolumeSource{
+				CloudInitNoCloud: &kubevirtapiv1.CloudInitNoCloudSource{UserDataSecretRef: secretRef},
+			},
+		},
+		{
+			name: "Fail on ignition in NoCloud",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				CloudInitDataSource: kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud,
+			},
+			wantErr: "machine-test: Ignition user data requires the ConfigDrive data source",
+		},
+		{
+			name: "Fail on ignition files of cloud config",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat: kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				IgnitionFiles:  []kubevirtproviderv1alpha1.IgnitionFile{{Path: "/etc/motd"}},
+			},
+			wantErr: "machine-test: IgnitionFiles and IgnitionUnits require the Ignition user data format",
+		},
+		{
+			name: "Fail on an unknown user data format",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat: "Kickstart",
+			},
+			wantErr: "machine-test: Value of UserDataFormat, can be only one of: Ignition, CloudConfig",
+		},
+		{
+			name: "Fail on an unknown data source",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				CloudInitDataSource: "Metadata",
+			},
+			wantErr: "machine-test: Value of CloudInitDataSource, can be only one of: ConfigDrive, NoCloud",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine:             initializeMachine(t, nil, "", false),
+				machineProviderSpec: &tc.providerSpec,
+			}
+			volumeSource, err := machineScope.buildCloudInitVolumeSource()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, volumeSource, tc.want)
+		})
+	}
+}
+
+func TestBuildNetworkData(t *testing.T) {
+	cases := []struct {
+		name          string
+		networkConfig *kubevirtproviderv1alpha1.NetworkConfig
+		families      []corev1.IPFamily
+		ipAddresses   []kubevirtproviderv1alpha1.IPAddressStatus
+		want          string
+	}{
+		{
+			name: "Static IPv4 with DHCPv6",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+				Addresses:  []string{"10.0.0.5/24"},
+				Gateway:    "10.0.0.1",
+				DNSServers: []string{"10.0.0.1"},
+			},
+			want: "ethernets:\n  enp1s0:\n    addresses:\n    - 10.0.0.5/24\n    dhcp6: true\n    gateway4: 10.0.0.1\n" +
+				"    nameservers:\n      addresses:\n      - 10.0.0.1\nversion: 2\n",
+		},
+		{
+			name: "Static dual stack on a named interface",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+				Interface: "eth0",
+				Addresses: []string{"fd00::5/64", "10.0.0.5/24"},
+				Gateway:   "fd00::1",
+			},
+			want: "ethernets:\n  eth0:\n    addresses:\n    - 10.0.0.5/24\n    - fd00::5/64\n    gateway6: fd00::1\nversion: 2\n",
+		},
+		{
+			name: "Static IPv6 single stack without DHCPv4",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+				Addresses: []string{"fd00::5/64"},
+				Gateways:  []string{"fd00::1"},
+			},
+			families: []corev1.IPFamily{corev1.IPv6Protocol},
+			want:     "ethernets:\n  enp1s0:\n    addresses:\n    - fd00::5/64\n    gateway6: fd00::1\nversion: 2\n",
+		},
+		{
+			name:          "Allocated addresses of two interfaces",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{DNSServers: []string{"10.0.0.53"}},
+			ipAddresses: []kubevirtproviderv1alpha1.IPAddressStatus{
+				{Pool: "workers", Interface: "enp1s0", Address: "10.0.0.7/24", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.53", "10.0.0.54"}},
+				{Pool: "storage", Interface: "enp2s0", Address: "10.1.0.7/24"},
+			},
+			want: "ethernets:\n  enp1s0:\n    addresses:\n    - 10.0.0.7/24\n    dhcp6: true\n    gateway4: 10.0.0.1\n" +
+				"    nameservers:\n      addresses:\n      - 10.0.0.53\n      - 10.0.0.54\n" +
+				"  enp2s0:\n    addresses:\n    - 10.1.0.7/24\n    dhcp6: true\nversion: 2\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine:               initializeMachine(t, nil, "", false),
+				machineProviderSpec:   &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{NetworkConfig: tc.networkConfig, IPFamilies: tc.families},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{IPAddresses: tc.ipAddresses},
+			}
+			interfaces, err := machineScope.buildInterfaceConfigs()
+			assert.NilError(t, err)
+			networkData, err := buildNetworkData(interfaces)
+			assert.NilError(t, err)
+			assert.Equal(t, networkData, tc.want)
+		})
+	}
+}
+
+func TestBuildCloudConfigUserDataSecret(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+	machine := initializeMachine(t, nil, "", false)
+	cloudConfig := "#cloud-config\npackages:\n- qemu-guest-agent\n"
+	secret := &corev1.Secret{Data: map[string][]byte{userDataKey: []byte(cloudConfig)}}
+	newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(secret, nil)
+
+	machineScope := &machineScope{
+		machine:               machine,
+		tenantClusterClient:   newMockTenantClusterClient,
+		machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{},
+		machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+			IgnitionSecretName: workerUserDataSecretName,
+			UserDataFormat:     kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+			NetworkConfig:      &kubevirtproviderv1alpha1.NetworkConfig{},
+		},
+	}
+	userDataSecret, err := machineScope.buildUserDataSecret()
+	assert.NilError(t, err)
+	assert.Equal(t, string(userDataSecret.Data[userDataSecretKey]), cloudConfig)
+	assert.Equal(t, string(userDataSecret.Data[networkDataSecretKey]), "ethernets:\n  enp1s0:\n    dhcp4: true\n    dhcp6: true\nversion: 2\n")
+}
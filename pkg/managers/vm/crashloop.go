@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"fmt"
+	"time"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	defaultRestartBudgetMaxRestarts = 5
+	defaultRestartBudgetWindow      = 10 * time.Minute
+	// crashLoopMachineError is the error reason of a machine failed by the restart budget
+	crashLoopMachineError machinev1.MachineStatusError = "VMICrashLoop"
+	// machinePhaseFailed is the phase of a machine the machine controller doesn't reconcile anymore
+	machinePhaseFailed = "Failed"
+	// crashLoopRequeueAfter is the delay of the reconcile of a machine failed by the restart budget,
+	// the machine controller skips it once it sees the failed phase
+	crashLoopRequeueAfter = time.Minute
+)
+
+// getRestartBudget returns the maximum number of VMI restarts of the machine within the restart window,
+// and the window, which also bounds the recent restarts without restart budget
+func (s *machineScope) getRestartBudget() (int, time.Duration) {
+	maxRestarts, window := defaultRestartBudgetMaxRestarts, defaultRestartBudgetWindow
+	if budget := s.machineProviderSpec.RestartBudget; budget != nil {
+		if budget.MaxRestarts > 0 {
+			maxRestarts = int(budget.MaxRestarts)
+		}
+		if budget.Window != nil && budget.Window.Duration > 0 {
+			window = budget.Window.Duration
+		}
+	}
+	return maxRestarts, window
+}
+
+// trackVMIRestarts records the restarts of the machine VMI counted by the start failures of the VM status, and drops the
+// recent restarts out of the restart window. KubeVirt counts the consecutive failed VMIs of the VM, which it restarts
+// with the Always and RerunOnFailure run strategies, and resets the count once a VMI runs successfully.
+// The VMIs stopped or restarted by the controller didn't fail, so they aren't counted.
+func (s *machineScope) trackVMIRestarts(vm *kubevirtapiv1.VirtualMachine, now time.Time) {
+	restarts := s.machineProviderStatus.VMIRestarts
+	if restarts == nil {
+		restarts = &kubevirtproviderv1alpha1.VMIRestartsStatus{}
+		s.machineProviderStatus.VMIRestarts = restarts
+	}
+
+	if failure := vm.Status.StartFailure; failure == nil {
+		restarts.ConsecutiveFailCount = 0
+	} else if failure.LastFailedVMIUID != "" && failure.LastFailedVMIUID != restarts.LastFailedVMIUID {
+		// The failures between two reconciles are all counted, or the last one once the count was reset
+		newRestarts := failure.ConsecutiveFailCount - int(restarts.ConsecutiveFailCount)
+		if newRestarts <= 0 {
+			newRestarts = 1
+		}
+		s.logger().Info("VMI failed and was restarted", "failedUID", failure.LastFailedVMIUID, "consecutiveFailures", failure.ConsecutiveFailCount)
+		restarts.Count += int32(newRestarts)
+		for i := 0; i < newRestarts; i++ {
+			restarts.Recent = append(restarts.Recent, metav1.NewTime(now))
+		}
+		restarts.LastFailedVMIUID = failure.LastFailedVMIUID
+		restarts.ConsecutiveFailCount = int32(failure.ConsecutiveFailCount)
+	}
+
+	_, window := s.getRestartBudget()
+	recent := restarts.Recent[:0]
+	for _, restart := range restarts.Recent {
+		if now.Sub(restart.Time) < window {
+			recent = append(recent, restart)
+		}
+	}
+	restarts.Recent = recent
+	if len(restarts.Recent) == 0 {
+		restarts.Recent = nil
+	}
+}
+
+// failOnCrashLoop fails the machine once the recent restarts of its VMI exceed its restart budget. The machine controller
+// only fails the machines of an invalid configuration itself, so the machine phase is set to Failed here, and the machine
+// isn't reconciled anymore, which leaves it to be remediated. The error reason is cleared once the machine is back within
+// its budget, like when its phase was reset after its restart budget was raised.
+func (m *manager) failOnCrashLoop(machineScope *machineScope) error {
+	restarts := machineScope.machineProviderStatus.VMIRestarts
+	maxRestarts, window := machineScope.getRestartBudget()
+	if machineScope.machineProviderSpec.RestartBudget == nil || restarts == nil || len(restarts.Recent) <= maxRestarts {
+		if reason := machineScope.machine.Status.ErrorReason; reason != nil && *reason == crashLoopMachineError {
+			machineScope.logger().Info("VMI is back within the restart budget")
+			machineScope.machine.Status.ErrorReason = nil
+			machineScope.machine.Status.ErrorMessage = nil
+		}
+		return nil
+	}
+
+	message := fmt.Sprintf("VMI restarted %d times within %s, more than the %d restarts of the machine restart budget, the guest is crash looping",
+		len(restarts.Recent), window, maxRestarts)
+	if reason := machineScope.machine.Status.ErrorReason; reason == nil || *reason != crashLoopMachineError {
+		machineScope.logger().Info("VMI is crash looping", "restarts", len(restarts.Recent), "window", window, "maxRestarts", maxRestarts)
+		m.eventRecorder.Eventf(machineScope.machine, corev1.EventTypeWarning, "CrashLoop", "%s", message)
+	}
+
+	reason := crashLoopMachineError
+	phase := machinePhaseFailed
+	now := metav1.Now()
+	machineScope.machine.Status.ErrorReason = &reason
+	machineScope.machine.Status.ErrorMessage = &message
+	machineScope.machine.Status.Phase = &phase
+	machineScope.machine.Status.LastUpdated = &now
+	return &machinecontroller.RequeueAfterError{RequeueAfter: crashLoopRequeueAfter}
+}
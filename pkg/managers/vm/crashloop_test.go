@@ -0,0 +1,171 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestTrackVMIRestarts(t *testing.T) {
+	now := time.Now()
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+	recently := metav1.NewTime(now.Add(-time.Minute))
+	cases := []struct {
+		name         string
+		restarts     *kubevirtproviderv1alpha1.VMIRestartsStatus
+		startFailure *kubevirtapiv1.VirtualMachineStartFailure
+		wantRestarts *kubevirtproviderv1alpha1.VMIRestartsStatus
+	}{
+		{
+			name:         "Without failure",
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{},
+		},
+		{
+			name:         "Count the first failure",
+			startFailure: &kubevirtapiv1.VirtualMachineStartFailure{ConsecutiveFailCount: 1, LastFailedVMIUID: "vmi-1"},
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{
+				LastFailedVMIUID: "vmi-1", ConsecutiveFailCount: 1, Count: 1, Recent: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+		{
+			name:         "Count the failures between two reconciles",
+			restarts:     &kubevirtproviderv1alpha1.VMIRestartsStatus{LastFailedVMIUID: "vmi-1", ConsecutiveFailCount: 1, Count: 1, Recent: []metav1.Time{recently}},
+			startFailure: &kubevirtapiv1.VirtualMachineStartFailure{ConsecutiveFailCount: 3, LastFailedVMIUID: "vmi-3"},
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{
+				LastFailedVMIUID: "vmi-3", ConsecutiveFailCount: 3, Count: 3, Recent: []metav1.Time{recently, metav1.NewTime(now), metav1.NewTime(now)},
+			},
+		},
+		{
+			name:         "Count a failure once",
+			restarts:     &kubevirtproviderv1alpha1.VMIRestartsStatus{LastFailedVMIUID: "vmi-1", ConsecutiveFailCount: 1, Count: 1, Recent: []metav1.Time{recently}},
+			startFailure: &kubevirtapiv1.VirtualMachineStartFailure{ConsecutiveFailCount: 1, LastFailedVMIUID: "vmi-1"},
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{
+				LastFailedVMIUID: "vmi-1", ConsecutiveFailCount: 1, Count: 1, Recent: []metav1.Time{recently},
+			},
+		},
+		{
+			name:         "Count a failure after a reset of the consecutive failures",
+			restarts:     &kubevirtproviderv1alpha1.VMIRestartsStatus{LastFailedVMIUID: "vmi-1", ConsecutiveFailCount: 2, Count: 2},
+			startFailure: &kubevirtapiv1.VirtualMachineStartFailure{ConsecutiveFailCount: 1, LastFailedVMIUID: "vmi-2"},
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{
+				LastFailedVMIUID: "vmi-2", ConsecutiveFailCount: 1, Count: 3, Recent: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+		{
+			name:     "Reset the consecutive failures of a VMI running successfully",
+			restarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{LastFailedVMIUID: "vmi-1", ConsecutiveFailCount: 2, Count: 2, Recent: []metav1.Time{recently}},
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{
+				LastFailedVMIUID: "vmi-1", Count: 2, Recent: []metav1.Time{recently},
+			},
+		},
+		{
+			name:     "Drop the restarts out of the window",
+			restarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{LastFailedVMIUID: "vmi-2", Count: 2, Recent: []metav1.Time{longAgo, recently}},
+			wantRestarts: &kubevirtproviderv1alpha1.VMIRestartsStatus{
+				LastFailedVMIUID: "vmi-2", Count: 2, Recent: []metav1.Time{recently},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				ctx:                   context.Background(),
+				machine:               initializeMachine(t, nil, "", false),
+				machineProviderSpec:   &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{VMIRestarts: tc.restarts},
+			}
+			vm := &kubevirtapiv1.VirtualMachine{Status: kubevirtapiv1.VirtualMachineStatus{StartFailure: tc.startFailure}}
+			machineScope.trackVMIRestarts(vm, now)
+
+			assert.DeepEqual(t, machineScope.machineProviderStatus.VMIRestarts, tc.wantRestarts)
+		})
+	}
+}
+
+func TestFailOnCrashLoop(t *testing.T) {
+	recently := metav1.NewTime(time.Now().Add(-time.Minute))
+	crashLoopReason := machinev1.MachineStatusError("VMICrashLoop")
+	cases := []struct {
+		name          string
+		restartBudget *kubevirtproviderv1alpha1.RestartBudget
+		recent        int
+		errorReason   *machinev1.MachineStatusError
+		wantFailed    bool
+		wantEvents    int
+		wantReason    *machinev1.MachineStatusError
+	}{
+		{
+			name:   "Without restart budget",
+			recent: 10,
+		},
+		{
+			name:          "Within the default budget",
+			restartBudget: &kubevirtproviderv1alpha1.RestartBudget{},
+			recent:        5,
+		},
+		{
+			name:          "Fail beyond the budget",
+			restartBudget: &kubevirtproviderv1alpha1.RestartBudget{MaxRestarts: 2, Window: &metav1.Duration{Duration: 5 * time.Minute}},
+			recent:        3,
+			wantFailed:    true,
+			wantEvents:    1,
+			wantReason:    &crashLoopReason,
+		},
+		{
+			name:          "Report a crash looping machine once",
+			restartBudget: &kubevirtproviderv1alpha1.RestartBudget{MaxRestarts: 2, Window: &metav1.Duration{Duration: 5 * time.Minute}},
+			recent:        4,
+			errorReason:   &crashLoopReason,
+			wantFailed:    true,
+			wantReason:    &crashLoopReason,
+		},
+		{
+			name:          "Clear the error reason back within the budget",
+			restartBudget: &kubevirtproviderv1alpha1.RestartBudget{MaxRestarts: 5},
+			recent:        3,
+			errorReason:   &crashLoopReason,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			restarts := &kubevirtproviderv1alpha1.VMIRestartsStatus{LastFailedVMIUID: "vmi", Count: int32(tc.recent)}
+			for i := 0; i < tc.recent; i++ {
+				restarts.Recent = append(restarts.Recent, recently)
+			}
+			machine := initializeMachine(t, nil, "", false)
+			machine.Status.ErrorReason = tc.errorReason
+			machineScope := &machineScope{
+				machine:               machine,
+				machineProviderSpec:   &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{RestartBudget: tc.restartBudget},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{VMIRestarts: restarts},
+			}
+			eventRecorder := record.NewFakeRecorder(10)
+			providerVMInstance := &manager{eventRecorder: eventRecorder}
+
+			err := providerVMInstance.failOnCrashLoop(machineScope)
+			assert.DeepEqual(t, machineScope.machine.Status.ErrorReason, tc.wantReason)
+			assert.Equal(t, len(eventRecorder.Events), tc.wantEvents)
+			if !tc.wantFailed {
+				assert.NilError(t, err)
+				assert.Assert(t, machineScope.machine.Status.Phase == nil)
+				assert.Assert(t, machineScope.machine.Status.ErrorMessage == nil)
+				return
+			}
+			// The machine controller doesn't reconcile the failed machine anymore once it's requeued
+			requeueErr, ok := err.(*machinecontroller.RequeueAfterError)
+			assert.Assert(t, ok, "expected a requeue after error, got %v", err)
+			assert.Equal(t, requeueErr.RequeueAfter, time.Minute)
+			assert.Equal(t, *machineScope.machine.Status.Phase, "Failed")
+			assert.Assert(t, strings.Contains(*machineScope.machine.Status.ErrorMessage, "the guest is crash looping"))
+		})
+	}
+}
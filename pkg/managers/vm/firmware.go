@@ -0,0 +1,80 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// Secure Boot requires SMM, which QEMU only emulates on q35 machine types, like q35 or pc-q35-rhel8.2.0
+const secureBootMachineType = "q35"
+
+// getFirmware returns the validated firmware of the machine, nil by default
+func (s *machineScope) getFirmware() (*kubevirtproviderv1alpha1.FirmwareConfig, error) {
+	firmware := s.machineProviderSpec.Firmware
+	if firmware == nil {
+		return nil, nil
+	}
+	switch firmware.Bootloader {
+	case "", kubevirtproviderv1alpha1.BootloaderBIOS:
+		if firmware.SecureBoot || firmware.PersistentEFI {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: Firmware secureBoot and persistentEFI require the %v bootloader",
+				s.machine.GetName(), kubevirtproviderv1alpha1.BootloaderUEFI)
+		}
+	case kubevirtproviderv1alpha1.BootloaderUEFI:
+		if firmware.SecureBoot && firmware.MachineType != "" && !strings.Contains(firmware.MachineType, secureBootMachineType) {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: Firmware secureBoot requires a %v machine type, not %v",
+				s.machine.GetName(), secureBootMachineType, firmware.MachineType)
+		}
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: Value of Firmware bootloader, can be only one of: %v, %v",
+			s.machine.GetName(), kubevirtproviderv1alpha1.BootloaderBIOS, kubevirtproviderv1alpha1.BootloaderUEFI)
+	}
+	return firmware, nil
+}
+
+// buildDomainFirmware sets the bootloader, the persistent EFI and TPM state, the SMBIOS identity and the machine type
+// of the machine firmware on the domain. Secure Boot is always set with the UEFI bootloader, as kubevirt enables it by default.
+func (s *machineScope) buildDomainFirmware(domain *kubevirtapiv1.DomainSpec) error {
+	firmware, err := s.getFirmware()
+	if err != nil || firmware == nil {
+		return err
+	}
+	if firmware.MachineType != "" {
+		domain.Machine = &kubevirtapiv1.Machine{Type: firmware.MachineType}
+	}
+
+	domainFirmware := &kubevirtapiv1.Firmware{}
+	if firmware.Bootloader == kubevirtproviderv1alpha1.BootloaderUEFI {
+		secureBoot := firmware.SecureBoot
+		efi := &kubevirtapiv1.EFI{SecureBoot: &secureBoot}
+		if firmware.PersistentEFI {
+			persistent := true
+			efi.Persistent = &persistent
+		}
+		domainFirmware.Bootloader = &kubevirtapiv1.Bootloader{EFI: efi}
+	}
+	if firmware.SecureBoot {
+		enabled := true
+		domain.Features = &kubevirtapiv1.Features{SMM: &kubevirtapiv1.FeatureState{Enabled: &enabled}}
+	}
+	if firmware.PersistentTPM {
+		persistent := true
+		domain.Devices.TPM = &kubevirtapiv1.TPMDevice{Persistent: &persistent}
+	}
+	if firmware.SMBIOSIdentity {
+		uid := s.machine.GetUID()
+		if uid == "" {
+			return fmt.Errorf("%v: the SMBIOS identity requires the machine UID", s.machine.GetName())
+		}
+		domainFirmware.UUID = uid
+		domainFirmware.Serial = string(uid)
+	}
+	if domainFirmware.Bootloader != nil || domainFirmware.UUID != "" {
+		domain.Firmware = domainFirmware
+	}
+	return nil
+}
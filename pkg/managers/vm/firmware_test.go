@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestBuildDomainFirmware(t *testing.T) {
+	enabled := true
+	disabled := false
+	cases := []struct {
+		name     string
+		firmware *kubevirtproviderv1alpha1.FirmwareConfig
+		want     kubevirtapiv1.DomainSpec
+		wantErr  string
+	}{
+		{
+			name: "Without firmware",
+		},
+		{
+			name:     "BIOS with an SMBIOS identity",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{SMBIOSIdentity: true, MachineType: "pc-i440fx-2.12"},
+			want: kubevirtapiv1.DomainSpec{
+				Machine:  &kubevirtapiv1.Machine{Type: "pc-i440fx-2.12"},
+				Firmware: &kubevirtapiv1.Firmware{UUID: "machine-uid", Serial: "machine-uid"},
+			},
+		},
+		{
+			name: "UEFI with Secure Boot",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{
+				Bootloader:    kubevirtproviderv1alpha1.BootloaderUEFI,
+				SecureBoot:    true,
+				PersistentEFI: true,
+				MachineType:   "pc-q35-rhel8.2.0",
+			},
+			want: kubevirtapiv1.DomainSpec{
+				Machine:  &kubevirtapiv1.Machine{Type: "pc-q35-rhel8.2.0"},
+				Firmware: &kubevirtapiv1.Firmware{Bootloader: &kubevirtapiv1.Bootloader{EFI: &kubevirtapiv1.EFI{SecureBoot: &enabled, Persistent: &enabled}}},
+				Features: &kubevirtapiv1.Features{SMM: &kubevirtapiv1.FeatureState{Enabled: &enabled}},
+			},
+		},
+		{
+			name:     "UEFI disables Secure Boot",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{Bootloader: kubevirtproviderv1alpha1.BootloaderUEFI},
+			want: kubevirtapiv1.DomainSpec{
+				Firmware: &kubevirtapiv1.Firmware{Bootloader: &kubevirtapiv1.Bootloader{EFI: &kubevirtapiv1.EFI{SecureBoot: &disabled}}},
+			},
+		},
+		{
+			name:     "BIOS with a persistent TPM",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{Bootloader: kubevirtproviderv1alpha1.BootloaderBIOS, PersistentTPM: true},
+			want: kubevirtapiv1.DomainSpec{
+				Devices: kubevirtapiv1.Devices{TPM: &kubevirtapiv1.TPMDevice{Persistent: &enabled}},
+			},
+		},
+		{
+			name:     "Fail on Secure Boot with BIOS",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{SecureBoot: true},
+			wantErr:  "machine-test: Firmware secureBoot and persistentEFI require the UEFI bootloader",
+		},
+		{
+			name:     "Fail on Secure Boot without a q35 machine type",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{Bootloader: kubevirtproviderv1alpha1.BootloaderUEFI, SecureBoot: true, MachineType: "pc"},
+			wantErr:  "machine-test: Firmware secureBoot requires a q35 machine type, not pc",
+		},
+		{
+			name:     "Fail on an unknown bootloader",
+			firmware: &kubevirtproviderv1alpha1.FirmwareConfig{Bootloader: "coreboot"},
+			wantErr:  "machine-test: Value of Firmware bootloader, can be only one of: BIOS, UEFI",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := initializeMachine(t, nil, "", false)
+			machine.UID = "machine-uid"
+			machineScope := &machineScope{
+				machine:             machine,
+				machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{Firmware: tc.firmware},
+			}
+			domain := kubevirtapiv1.DomainSpec{}
+			err := machineScope.buildDomainFirmware(&domain)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, domain, tc.want)
+		})
+	}
+}
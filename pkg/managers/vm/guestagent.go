@@ -0,0 +1,66 @@
+package vm
+
+import (
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// isGuestAgentConnected returns true if the vmi reports a connected QEMU guest agent
+func isGuestAgentConnected(vmi *kubevirtapiv1.VirtualMachineInstance) bool {
+	if vmi == nil {
+		return false
+	}
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubevirtapiv1.VirtualMachineInstanceAgentConnected {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// setGuestAgentStatus reports the guest agent connection, the guest OS and the guest interfaces of the vmi in the provider status
+func (s *machineScope) setGuestAgentStatus(vmi *kubevirtapiv1.VirtualMachineInstance) {
+	s.machineProviderStatus.GuestOSInfo = nil
+	s.machineProviderStatus.GuestInterfaces = nil
+	s.machineProviderStatus.Conditions = setKubevirtMachineProviderCondition(guestAgentCondition(vmi), s.machineProviderStatus.Conditions)
+	if vmi == nil {
+		return
+	}
+
+	if vmi.Status.GuestOSInfo != (kubevirtapiv1.VirtualMachineInstanceGuestOSInfo{}) {
+		guestOSInfo := vmi.Status.GuestOSInfo
+		s.machineProviderStatus.GuestOSInfo = &guestOSInfo
+	}
+	for _, i := range vmi.Status.Interfaces {
+		if i.InterfaceName == "" {
+			continue
+		}
+		s.machineProviderStatus.GuestInterfaces = append(s.machineProviderStatus.GuestInterfaces, kubevirtproviderv1alpha1.GuestInterfaceStatus{
+			Network:       i.Name,
+			InterfaceName: i.InterfaceName,
+			MAC:           i.MAC,
+		})
+	}
+}
+
+// guestAgentCondition returns the guest agent connected condition of a vmi
+func guestAgentCondition(vmi *kubevirtapiv1.VirtualMachineInstance) kubevirtapiv1.VirtualMachineCondition {
+	condition := kubevirtapiv1.VirtualMachineCondition{
+		Type:    guestAgentConnectedCondition,
+		Status:  corev1.ConditionFalse,
+		Reason:  "AgentNotConnected",
+		Message: "QEMU guest agent isn't connected, the addresses of bridged interfaces may be missing",
+	}
+	switch {
+	case vmi == nil:
+		condition.Status = corev1.ConditionUnknown
+		condition.Reason = "VMINotFound"
+		condition.Message = "Machine instance has no VMI"
+	case isGuestAgentConnected(vmi):
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "AgentConnected"
+		condition.Message = "QEMU guest agent is connected"
+	}
+	return condition
+}
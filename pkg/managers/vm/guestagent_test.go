@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestSetGuestAgentStatus(t *testing.T) {
+	guestOSInfo := kubevirtapiv1.VirtualMachineInstanceGuestOSInfo{Name: "Red Hat Enterprise Linux CoreOS", VersionID: "4.6"}
+	cases := []struct {
+		name                string
+		vmi                 *kubevirtapiv1.VirtualMachineInstance
+		wantStatus          corev1.ConditionStatus
+		wantReason          string
+		wantGuestOSInfo     *kubevirtapiv1.VirtualMachineInstanceGuestOSInfo
+		wantGuestInterfaces []kubevirtproviderv1alpha1.GuestInterfaceStatus
+	}{
+		{
+			name:       "Without vmi",
+			wantStatus: corev1.ConditionUnknown,
+			wantReason: "VMINotFound",
+		},
+		{
+			name: "Agent not connected",
+			vmi: &kubevirtapiv1.VirtualMachineInstance{
+				Status: kubevirtapiv1.VirtualMachineInstanceStatus{
+					Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{{Name: podNetworkName, IP: "10.128.0.5"}},
+				},
+			},
+			wantStatus: corev1.ConditionFalse,
+			wantReason: "AgentNotConnected",
+		},
+		{
+			name: "Agent connected",
+			vmi: &kubevirtapiv1.VirtualMachineInstance{
+				Status: kubevirtapiv1.VirtualMachineInstanceStatus{
+					Conditions: []kubevirtapiv1.VirtualMachineInstanceCondition{
+						{Type: kubevirtapiv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionTrue},
+					},
+					GuestOSInfo: guestOSInfo,
+					Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+						{Name: mainNetworkName, InterfaceName: "enp1s0", MAC: "02:00:00:d4:49:69", IPs: []string{"192.168.1.10"}},
+						{InterfaceName: "cni0", IPs: []string{"10.88.0.1"}},
+					},
+				},
+			},
+			wantStatus:      corev1.ConditionTrue,
+			wantReason:      "AgentConnected",
+			wantGuestOSInfo: &guestOSInfo,
+			wantGuestInterfaces: []kubevirtproviderv1alpha1.GuestInterfaceStatus{
+				{Network: mainNetworkName, InterfaceName: "enp1s0", MAC: "02:00:00:d4:49:69"},
+				{InterfaceName: "cni0"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine: initializeMachine(t, nil, "", false),
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{
+					GuestOSInfo:     &kubevirtapiv1.VirtualMachineInstanceGuestOSInfo{Name: "stale"},
+					GuestInterfaces: []kubevirtproviderv1alpha1.GuestInterfaceStatus{{InterfaceName: "stale"}},
+				},
+			}
+			machineScope.setGuestAgentStatus(tc.vmi)
+
+			condition := findProviderCondition(machineScope.machineProviderStatus.Conditions, guestAgentConnectedCondition)
+			assert.Assert(t, condition != nil)
+			assert.Equal(t, condition.Status, tc.wantStatus)
+			assert.Equal(t, condition.Reason, tc.wantReason)
+			assert.DeepEqual(t, machineScope.machineProviderStatus.GuestOSInfo, tc.wantGuestOSInfo)
+			assert.DeepEqual(t, machineScope.machineProviderStatus.GuestInterfaces, tc.wantGuestInterfaces)
+		})
+	}
+}
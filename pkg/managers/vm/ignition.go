@@ -0,0 +1,161 @@
+package vm
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/ignition"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	hostnamePath                 = "/etc/hostname"
+	networkManagerConnectionsDir = "/etc/NetworkManager/system-connections"
+	defaultNetworkInterface      = "enp1s0"
+	defaultIgnitionFileMode      = 0644
+	// NetworkManager ignores keyfiles readable by other users
+	networkManagerKeyfileMode = 0600
+)
+
+// augmentIgnition adds the content of the machine to its ignition user data: its hostname,
+// its static network configuration and the files and units of the provider spec
+func (s *machineScope) augmentIgnition(userData string) (string, error) {
+	config, err := ignition.Parse([]byte(userData))
+	if err != nil {
+		return "", machinecontroller.InvalidMachineConfiguration("%v: user data of secret %s: %v", s.machine.GetName(), s.machineProviderSpec.IgnitionSecretName, err)
+	}
+
+	config.AddFile(hostnamePath, defaultIgnitionFileMode, []byte(s.machine.GetName()+"\n"))
+
+	interfaces, err := s.buildInterfaceConfigs()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range interfaces {
+		config.AddFile(path.Join(networkManagerConnectionsDir, iface.name+".nmconnection"), networkManagerKeyfileMode, []byte(buildNetworkManagerKeyfile(iface)))
+	}
+
+	for _, file := range s.machineProviderSpec.IgnitionFiles {
+		content, found, err := s.getContent(file.ContentFrom)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			continue
+		}
+		mode := defaultIgnitionFileMode
+		if file.Mode != nil {
+			mode = *file.Mode
+		}
+		config.AddFile(file.Path, mode, content)
+	}
+
+	for _, unit := range s.machineProviderSpec.IgnitionUnits {
+		content, found, err := s.getContent(unit.ContentFrom)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			continue
+		}
+		enabled := true
+		if unit.Enabled != nil {
+			enabled = *unit.Enabled
+		}
+		config.AddUnit(unit.Name, enabled, string(content))
+	}
+
+	data, err := config.Marshal()
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ignition: %w", err)
+	}
+	return string(data), nil
+}
+
+// getContent returns the content of a secret or config map key in the machine namespace.
+// A missing optional key isn't found, without error.
+func (s *machineScope) getContent(source kubevirtproviderv1alpha1.ContentSource) ([]byte, bool, error) {
+	namespace := s.machine.GetNamespace()
+	switch {
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+		secret, err := s.tenantClusterClient.GetSecret(s.ctx, ref.Name, namespace)
+		if err != nil {
+			if apimachineryerrors.IsNotFound(err) {
+				if optional {
+					return nil, false, nil
+				}
+				return nil, false, machinecontroller.InvalidMachineConfiguration("%v: ignition content secret %s/%s not found", s.machine.GetName(), namespace, ref.Name)
+			}
+			return nil, false, err
+		}
+		if content, ok := secret.Data[ref.Key]; ok {
+			return content, true, nil
+		}
+		if optional {
+			return nil, false, nil
+		}
+		return nil, false, machinecontroller.InvalidMachineConfiguration("%v: ignition content secret %s/%s doesn't contain the key %s", s.machine.GetName(), namespace, ref.Name, ref.Key)
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+		configMap, err := s.tenantClusterClient.GetConfigMap(s.ctx, ref.Name, namespace)
+		if err != nil {
+			if apimachineryerrors.IsNotFound(err) {
+				if optional {
+					return nil, false, nil
+				}
+				return nil, false, machinecontroller.InvalidMachineConfiguration("%v: ignition content config map %s/%s not found", s.machine.GetName(), namespace, ref.Name)
+			}
+			return nil, false, err
+		}
+		if content, ok := configMap.Data[ref.Key]; ok {
+			return []byte(content), true, nil
+		}
+		if content, ok := configMap.BinaryData[ref.Key]; ok {
+			return content, true, nil
+		}
+		if optional {
+			return nil, false, nil
+		}
+		return nil, false, machinecontroller.InvalidMachineConfiguration("%v: ignition content config map %s/%s doesn't contain the key %s", s.machine.GetName(), namespace, ref.Name, ref.Key)
+	default:
+		return nil, false, machinecontroller.InvalidMachineConfiguration("%v: ignition content requires a secretKeyRef or a configMapKeyRef", s.machine.GetName())
+	}
+}
+
+// buildNetworkManagerKeyfile returns a NetworkManager keyfile configuring the static addresses of the interface
+func buildNetworkManagerKeyfile(iface interfaceConfig) string {
+	keyfile := &strings.Builder{}
+	fmt.Fprintf(keyfile, "[connection]\nid=%s\ntype=ethernet\ninterface-name=%s\n", iface.name, iface.name)
+	writeKeyfileIPSection(keyfile, "ipv4", iface.ipv4)
+	writeKeyfileIPSection(keyfile, "ipv6", iface.ipv6)
+	return keyfile.String()
+}
+
+// writeKeyfileIPSection writes an ip section of a keyfile, an address family without addresses is configured automatically
+func writeKeyfileIPSection(keyfile *strings.Builder, section string, family addressFamilyConfig) {
+	fmt.Fprintf(keyfile, "\n[%s]\n", section)
+	if family.disabled {
+		keyfile.WriteString("method=disabled\n")
+		return
+	}
+	if len(family.addresses) == 0 {
+		keyfile.WriteString("method=auto\n")
+		return
+	}
+	keyfile.WriteString("method=manual\n")
+	for i, address := range family.addresses {
+		fmt.Fprintf(keyfile, "address%d=%s\n", i+1, address)
+	}
+	if family.gateway != "" {
+		fmt.Fprintf(keyfile, "gateway=%s\n", family.gateway)
+	}
+	if len(family.dnsServers) > 0 {
+		fmt.Fprintf(keyfile, "dns=%s;\n", strings.Join(family.dnsServers, ";"))
+	}
+}
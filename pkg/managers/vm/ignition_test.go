@@ -0,0 +1,227 @@
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAugmentIgnition(t *testing.T) {
+	optional := true
+	disabled := false
+	cases := []struct {
+		name         string
+		userData     string
+		providerSpec kubevirtproviderv1alpha1.KubevirtMachineProviderSpec
+		ipAddresses  []kubevirtproviderv1alpha1.IPAddressStatus
+		wantFiles    map[string]string
+		wantUnits    map[string]bool
+		wantErr      string
+	}{
+		{
+			name:      "Set the hostname",
+			userData:  `{"ignition":{"version":"2.2.0"}}`,
+			wantFiles: map[string]string{hostnamePath: "machine-test\n"},
+		},
+		{
+			name:     "Add a NetworkManager keyfile",
+			userData: `{"ignition":{"version":"3.1.0"}}`,
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				NetworkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+					Addresses: []string{"10.0.0.5/24"},
+					Gateway:   "10.0.0.1",
+				},
+			},
+			wantFiles: map[string]string{
+				hostnamePath: "machine-test\n",
+				"/etc/NetworkManager/system-connections/enp1s0.nmconnection": "[connection]\nid=enp1s0\ntype=ethernet\ninterface-name=enp1s0\n\n" +
+					"[ipv4]\nmethod=manual\naddress1=10.0.0.5/24\ngateway=10.0.0.1\n\n[ipv6]\nmethod=auto\n",
+			},
+		},
+		{
+			name:     "Add a NetworkManager keyfile of the allocated addresses",
+			userData: `{"ignition":{"version":"3.1.0"}}`,
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				IPPools: []kubevirtproviderv1alpha1.IPPoolReference{{Name: "workers"}},
+			},
+			ipAddresses: []kubevirtproviderv1alpha1.IPAddressStatus{
+				{Pool: "workers", Interface: "enp1s0", Address: "10.0.0.7/24", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.1"}},
+			},
+			wantFiles: map[string]string{
+				hostnamePath: "machine-test\n",
+				"/etc/NetworkManager/system-connections/enp1s0.nmconnection": "[connection]\nid=enp1s0\ntype=ethernet\ninterface-name=enp1s0\n\n" +
+					"[ipv4]\nmethod=manual\naddress1=10.0.0.7/24\ngateway=10.0.0.1\ndns=10.0.0.1;\n\n[ipv6]\nmethod=auto\n",
+			},
+		},
+		{
+			name:     "Add files and units of secrets and config maps",
+			userData: `{"ignition":{"version":"3.1.0"}}`,
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				IgnitionFiles: []kubevirtproviderv1alpha1.IgnitionFile{
+					{
+						Path: "/etc/motd",
+						ContentFrom: kubevirtproviderv1alpha1.ContentSource{
+							ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}, Key: "motd"},
+						},
+					},
+					{
+						Path: "/etc/optional",
+						ContentFrom: kubevirtproviderv1alpha1.ContentSource{
+							ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}, Key: "missing", Optional: &optional},
+						},
+					},
+				},
+				IgnitionUnits: []kubevirtproviderv1alpha1.IgnitionUnit{
+					{
+						Name:    "agent.service",
+						Enabled: &disabled,
+						ContentFrom: kubevirtproviderv1alpha1.ContentSource{
+							SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: workerUserDataSecretName}, Key: "unit"},
+						},
+					},
+				},
+			},
+			wantFiles: map[string]string{hostnamePath: "machine-test\n", "/etc/motd": "welcome"},
+			wantUnits: map[string]bool{"agent.service": false},
+		},
+		{
+			name:     "Fail on a missing content key",
+			userData: `{"ignition":{"version":"3.1.0"}}`,
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				IgnitionFiles: []kubevirtproviderv1alpha1.IgnitionFile{
+					{
+						Path: "/etc/motd",
+						ContentFrom: kubevirtproviderv1alpha1.ContentSource{
+							ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}, Key: "missing"},
+						},
+					},
+				},
+			},
+			wantErr: "machine-test: ignition content config map default/extra doesn't contain the key missing",
+		},
+		{
+			name:     "Fail on an invalid address",
+			userData: `{"ignition":{"version":"3.1.0"}}`,
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				NetworkConfig: &kubevirtproviderv1alpha1.NetworkConfig{Addresses: []string{"10.0.0.5"}},
+			},
+			wantErr: `machine-test: NetworkConfig: invalid address "10.0.0.5": invalid CIDR address: 10.0.0.5`,
+		},
+		{
+			name:     "Fail on user data which isn't ignition",
+			userData: "#cloud-config",
+			wantErr:  "machine-test: user data of secret worker-user-data: user data is not an ignition config: invalid character '#' looking for beginning of value",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			tc.providerSpec.IgnitionSecretName = workerUserDataSecretName
+			machineScope := &machineScope{
+				machine:             machine,
+				machineProviderSpec: &tc.providerSpec,
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{
+					IPAddresses: tc.ipAddresses,
+				},
+				tenantClusterClient: newMockTenantClusterClient,
+			}
+
+			configMap := &corev1.ConfigMap{Data: map[string]string{"motd": "welcome"}}
+			newMockTenantClusterClient.EXPECT().GetConfigMap(gomock.Any(), "extra", machine.Namespace).Return(configMap, nil).AnyTimes()
+			secret := &corev1.Secret{Data: map[string][]byte{"unit": []byte("[Service]")}}
+			newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(secret, nil).AnyTimes()
+
+			userData, err := machineScope.augmentIgnition(tc.userData)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var config struct {
+				Storage struct {
+					Files []struct {
+						Path     string `json:"path"`
+						Contents struct {
+							Source string `json:"source"`
+						} `json:"contents"`
+					} `json:"files"`
+				} `json:"storage"`
+				Systemd struct {
+					Units []struct {
+						Name    string `json:"name"`
+						Enabled bool   `json:"enabled"`
+					} `json:"units"`
+				} `json:"systemd"`
+			}
+			assert.NilError(t, json.Unmarshal([]byte(userData), &config))
+
+			files := map[string]string{}
+			for _, file := range config.Storage.Files {
+				content, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(file.Contents.Source, "data:;base64,"))
+				assert.NilError(t, err)
+				files[file.Path] = string(content)
+			}
+			assert.DeepEqual(t, files, tc.wantFiles)
+
+			units := map[string]bool{}
+			for _, unit := range config.Systemd.Units {
+				units[unit.Name] = unit.Enabled
+			}
+			if tc.wantUnits == nil {
+				tc.wantUnits = map[string]bool{}
+			}
+			assert.DeepEqual(t, units, tc.wantUnits)
+		})
+	}
+}
+
+func TestBuildNetworkManagerKeyfile(t *testing.T) {
+	cases := []struct {
+		name          string
+		networkConfig *kubevirtproviderv1alpha1.NetworkConfig
+		families      []corev1.IPFamily
+		want          string
+	}{
+		{
+			name: "Dual stack",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+				Addresses:  []string{"192.168.1.10/24", "fd00::10/64"},
+				Gateway:    "fd00::1",
+				Gateways:   []string{"192.168.1.1"},
+				DNSServers: []string{"192.168.1.1", "1.1.1.1", "fd00::53"},
+			},
+			families: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+			want: "[connection]\nid=eth1\ntype=ethernet\ninterface-name=eth1\n\n" +
+				"[ipv4]\nmethod=manual\naddress1=192.168.1.10/24\ngateway=192.168.1.1\ndns=192.168.1.1;1.1.1.1;\n\n" +
+				"[ipv6]\nmethod=manual\naddress1=fd00::10/64\ngateway=fd00::1\ndns=fd00::53;\n",
+		},
+		{
+			name: "Single stack IPv6 disables IPv4",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+				Addresses: []string{"fd00::10/64"},
+			},
+			families: []corev1.IPFamily{corev1.IPv6Protocol},
+			want: "[connection]\nid=eth1\ntype=ethernet\ninterface-name=eth1\n\n" +
+				"[ipv4]\nmethod=disabled\n\n[ipv6]\nmethod=manual\naddress1=fd00::10/64\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			iface, err := newInterfaceConfig("eth1", tc.networkConfig, tc.families)
+			assert.NilError(t, err)
+			assert.Equal(t, buildNetworkManagerKeyfile(iface), tc.want)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package vm
+
+import (
+	"fmt"
+	"net"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/ipam"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// allocateIPAddresses allocates the static addresses of the machine from its IPPools, and records them in the provider status.
+// An allocation is persisted in the pool status before it's used, and the update of a pool which was changed
+// since it was read fails on conflict, so an address is never allocated twice.
+func (s *machineScope) allocateIPAddresses() error {
+	families, err := s.getIPFamilies()
+	if err != nil {
+		return err
+	}
+	var ipAddresses []kubevirtproviderv1alpha1.IPAddressStatus
+	pools := map[string]bool{}
+	for _, poolRef := range s.machineProviderSpec.IPPools {
+		if poolRef.Name == "" || pools[poolRef.Name] {
+			return machinecontroller.InvalidMachineConfiguration("%v: IPPools requires distinct pool names", s.machine.GetName())
+		}
+		pools[poolRef.Name] = true
+
+		pool, err := s.tenantClusterClient.GetIPPool(s.ctx, poolRef.Name, s.machine.GetNamespace())
+		if err != nil {
+			if apimachineryerrors.IsNotFound(err) {
+				return machinecontroller.InvalidMachineConfiguration("%v: IPPool %s/%s not found", s.machine.GetName(), s.machine.GetNamespace(), poolRef.Name)
+			}
+			return fmt.Errorf("failed to get IPPool %s: %w", poolRef.Name, err)
+		}
+		if poolIP, _, err := net.ParseCIDR(pool.Spec.CIDR); err == nil && !hasIPFamily(families, ipFamily(poolIP)) {
+			return machinecontroller.InvalidMachineConfiguration("%v: IPPool %s of CIDR %s isn't of IPFamilies %v",
+				s.machine.GetName(), poolRef.Name, pool.Spec.CIDR, families)
+		}
+		address, allocated, err := ipam.Allocate(pool, s.machine.GetName())
+		if err != nil {
+			return err
+		}
+		if allocated {
+			if err := s.tenantClusterClient.UpdateIPPoolStatus(s.ctx, pool); err != nil {
+				return fmt.Errorf("failed to allocate address %s of IPPool %s: %w", address, poolRef.Name, err)
+			}
+			s.logger().Info("allocated address", "address", address, "ipPool", poolRef.Name)
+		}
+
+		iface := poolRef.Interface
+		if iface == "" {
+			iface = s.getDefaultInterface()
+		}
+		ipAddresses = append(ipAddresses, kubevirtproviderv1alpha1.IPAddressStatus{
+			Pool:       poolRef.Name,
+			Interface:  iface,
+			Address:    address,
+			Gateway:    pool.Spec.Gateway,
+			DNSServers: pool.Spec.DNSServers,
+		})
+	}
+	s.machineProviderStatus.IPAddresses = ipAddresses
+	return nil
+}
+
+// releaseIPAddresses releases the addresses allocated to the machine from the IPPools of its spec and its provider status
+func (s *machineScope) releaseIPAddresses() error {
+	var poolNames []string
+	for _, poolRef := range s.machineProviderSpec.IPPools {
+		poolNames = append(poolNames, poolRef.Name)
+	}
+	for _, ipAddress := range s.machineProviderStatus.IPAddresses {
+		if !containsString(poolNames, ipAddress.Pool) {
+			poolNames = append(poolNames, ipAddress.Pool)
+		}
+	}
+
+	for _, poolName := range poolNames {
+		pool, err := s.tenantClusterClient.GetIPPool(s.ctx, poolName, s.machine.GetNamespace())
+		if err != nil {
+			if apimachineryerrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get IPPool %s: %w", poolName, err)
+		}
+		if !ipam.Release(pool, s.machine.GetName()) {
+			continue
+		}
+		if err := s.tenantClusterClient.UpdateIPPoolStatus(s.ctx, pool); err != nil {
+			return fmt.Errorf("failed to release the addresses of IPPool %s: %w", poolName, err)
+		}
+		s.logger().Info("released the addresses", "ipPool", poolName)
+	}
+	s.machineProviderStatus.IPAddresses = nil
+	return nil
+}
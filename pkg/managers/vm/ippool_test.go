@@ -0,0 +1,131 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllocateIPAddresses(t *testing.T) {
+	cases := []struct {
+		name            string
+		families        []corev1.IPFamily
+		allocations     map[string]string
+		getErr          error
+		updateErr       error
+		wantUpdate      bool
+		wantIPAddresses []kubevirtproviderv1alpha1.IPAddressStatus
+		wantErr         string
+	}{
+		{
+			name:       "Allocate and record a new address",
+			wantUpdate: true,
+			wantIPAddresses: []kubevirtproviderv1alpha1.IPAddressStatus{
+				{Pool: "workers", Interface: "enp1s0", Address: "10.0.0.2/24", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.1"}},
+			},
+		},
+		{
+			name:        "Keep the allocated address",
+			allocations: map[string]string{"10.0.0.2": "machine-other", "10.0.0.3": "machine-test"},
+			wantIPAddresses: []kubevirtproviderv1alpha1.IPAddressStatus{
+				{Pool: "workers", Interface: "enp1s0", Address: "10.0.0.3/24", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.1"}},
+			},
+		},
+		{
+			name:       "Fail on a conflicting allocation",
+			wantUpdate: true,
+			updateErr:  errors.New("the object has been modified"),
+			wantErr:    "failed to allocate address 10.0.0.2/24 of IPPool workers: the object has been modified",
+		},
+		{
+			name:    "Fail on a missing pool",
+			getErr:  stubNotFoundError(),
+			wantErr: "machine-test: IPPool default/workers not found",
+		},
+		{
+			name:     "Fail on a pool of another family",
+			families: []corev1.IPFamily{corev1.IPv6Protocol},
+			wantErr:  "machine-test: IPPool workers of CIDR 10.0.0.0/24 isn't of IPFamilies [IPv6]",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			var pool *kubevirtproviderv1alpha1.IPPool
+			if tc.getErr == nil {
+				pool = &kubevirtproviderv1alpha1.IPPool{
+					ObjectMeta: k8smetav1.ObjectMeta{Name: "workers", Namespace: machine.Namespace},
+					Spec:       kubevirtproviderv1alpha1.IPPoolSpec{CIDR: "10.0.0.0/24", Gateway: "10.0.0.1", DNSServers: []string{"10.0.0.1"}},
+					Status:     kubevirtproviderv1alpha1.IPPoolStatus{Allocations: tc.allocations},
+				}
+			}
+			newMockTenantClusterClient.EXPECT().GetIPPool(gomock.Any(), "workers", machine.Namespace).Return(pool, tc.getErr)
+			updateTimes := 0
+			if tc.wantUpdate {
+				updateTimes = 1
+			}
+			newMockTenantClusterClient.EXPECT().UpdateIPPoolStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updatedPool *kubevirtproviderv1alpha1.IPPool) error {
+				assert.Equal(t, updatedPool.Status.Allocations["10.0.0.2"], "machine-test")
+				return tc.updateErr
+			}).Times(updateTimes)
+
+			machineScope := &machineScope{
+				machine:             machine,
+				tenantClusterClient: newMockTenantClusterClient,
+				machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+					IPFamilies: tc.families,
+					IPPools:    []kubevirtproviderv1alpha1.IPPoolReference{{Name: "workers"}},
+				},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{},
+			}
+			err := machineScope.allocateIPAddresses()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, machineScope.machineProviderStatus.IPAddresses, tc.wantIPAddresses)
+		})
+	}
+}
+
+func TestReleaseIPAddresses(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+	machine := initializeMachine(t, nil, "", false)
+	pool := &kubevirtproviderv1alpha1.IPPool{
+		ObjectMeta: k8smetav1.ObjectMeta{Name: "workers", Namespace: machine.Namespace},
+		Status:     kubevirtproviderv1alpha1.IPPoolStatus{Allocations: map[string]string{"10.0.0.2": "machine-test", "10.0.0.3": "machine-other"}},
+	}
+	newMockTenantClusterClient.EXPECT().GetIPPool(gomock.Any(), "workers", machine.Namespace).Return(pool, nil)
+	// The pool of the status is released too, even though it was removed from the spec
+	newMockTenantClusterClient.EXPECT().GetIPPool(gomock.Any(), "removed", machine.Namespace).Return(nil, stubNotFoundError())
+	newMockTenantClusterClient.EXPECT().UpdateIPPoolStatus(gomock.Any(), pool).Return(nil)
+
+	machineScope := &machineScope{
+		machine:             machine,
+		tenantClusterClient: newMockTenantClusterClient,
+		machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+			IPPools: []kubevirtproviderv1alpha1.IPPoolReference{{Name: "workers"}},
+		},
+		machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{
+			IPAddresses: []kubevirtproviderv1alpha1.IPAddressStatus{{Pool: "removed", Interface: "enp1s0", Address: "10.1.0.2/24"}},
+		},
+	}
+	assert.NilError(t, machineScope.releaseIPAddresses())
+	assert.DeepEqual(t, pool.Status.Allocations, map[string]string{"10.0.0.3": "machine-other"})
+	assert.Assert(t, machineScope.machineProviderStatus.IPAddresses == nil)
+}
@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/ipam"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// allocateMACAddress allocates the MAC address of the main interface of the machine from its MACPool, and records it
+// in the provider status. As with the IPPools, the allocation is persisted in the pool status before it's used, and
+// the update of a pool which was changed since it was read fails on conflict, so an address is never allocated twice.
+func (s *machineScope) allocateMACAddress() error {
+	poolName := s.machineProviderSpec.MACPool
+	if poolName == "" {
+		return nil
+	}
+
+	pool, err := s.tenantClusterClient.GetMACPool(s.ctx, poolName, s.machine.GetNamespace())
+	if err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			return machinecontroller.InvalidMachineConfiguration("%v: MACPool %s/%s not found", s.machine.GetName(), s.machine.GetNamespace(), poolName)
+		}
+		return fmt.Errorf("failed to get MACPool %s: %w", poolName, err)
+	}
+	// The search of a free address starts at an offset derived from the machine UID, which is stable across reconciles
+	seed := string(s.machine.GetUID())
+	if seed == "" {
+		seed = s.machine.GetNamespace() + "/" + s.machine.GetName()
+	}
+	macAddress, allocated, err := ipam.AllocateMAC(pool, s.machine.GetName(), seed)
+	if err != nil {
+		return machinecontroller.InvalidMachineConfiguration("%v: MACPool: %v", s.machine.GetName(), err)
+	}
+	if allocated {
+		if err := s.tenantClusterClient.UpdateMACPoolStatus(s.ctx, pool); err != nil {
+			return fmt.Errorf("failed to allocate MAC address %s of MACPool %s: %w", macAddress, poolName, err)
+		}
+		s.logger().Info("allocated MAC address", "macAddress", macAddress, "macPool", poolName)
+	}
+
+	s.machineProviderStatus.MACAddress = macAddress
+	s.machineProviderStatus.MACPool = poolName
+	return nil
+}
+
+// releaseMACAddress releases the MAC address allocated to the machine from the MACPools of its spec and its provider status
+func (s *machineScope) releaseMACAddress() error {
+	var poolNames []string
+	for _, poolName := range []string{s.machineProviderSpec.MACPool, s.machineProviderStatus.MACPool} {
+		if poolName != "" && !containsString(poolNames, poolName) {
+			poolNames = append(poolNames, poolName)
+		}
+	}
+
+	for _, poolName := range poolNames {
+		pool, err := s.tenantClusterClient.GetMACPool(s.ctx, poolName, s.machine.GetNamespace())
+		if err != nil {
+			if apimachineryerrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get MACPool %s: %w", poolName, err)
+		}
+		if !ipam.ReleaseMAC(pool, s.machine.GetName()) {
+			continue
+		}
+		if err := s.tenantClusterClient.UpdateMACPoolStatus(s.ctx, pool); err != nil {
+			return fmt.Errorf("failed to release the MAC address of MACPool %s: %w", poolName, err)
+		}
+		s.logger().Info("released the MAC address", "macPool", poolName)
+	}
+	s.machineProviderStatus.MACAddress = ""
+	s.machineProviderStatus.MACPool = ""
+	return nil
+}
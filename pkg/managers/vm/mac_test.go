@@ -0,0 +1,139 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllocateMACAddress(t *testing.T) {
+	cases := []struct {
+		name           string
+		poolName       string
+		allocations    map[string]string
+		getErr         error
+		updateErr      error
+		wantUpdate     bool
+		wantMACAddress string
+		wantErr        string
+	}{
+		{
+			name: "Without MACPool",
+		},
+		{
+			name:           "Allocate and record a new address",
+			poolName:       "workers",
+			wantUpdate:     true,
+			wantMACAddress: "02:00:00:00:00:0a",
+		},
+		{
+			name:           "Keep the allocated address",
+			poolName:       "workers",
+			allocations:    map[string]string{"02:00:00:00:00:01": "machine-test"},
+			wantMACAddress: "02:00:00:00:00:01",
+		},
+		{
+			name:           "Skip the address allocated to another machine",
+			poolName:       "workers",
+			allocations:    map[string]string{"02:00:00:00:00:0a": "machine-other"},
+			wantUpdate:     true,
+			wantMACAddress: "02:00:00:00:00:0b",
+		},
+		{
+			name:       "Fail on a conflicting allocation",
+			poolName:   "workers",
+			wantUpdate: true,
+			updateErr:  errors.New("the object has been modified"),
+			wantErr:    "failed to allocate MAC address 02:00:00:00:00:0a of MACPool workers: the object has been modified",
+		},
+		{
+			name:     "Fail on a missing pool",
+			poolName: "workers",
+			getErr:   stubNotFoundError(),
+			wantErr:  "machine-test: MACPool default/workers not found",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			machine.UID = "8f1c2c3e-5b7a-4f0e-9a51-6d1e2f3a4b5c"
+			wantAllocations := len(tc.allocations) + 1
+			var pool *kubevirtproviderv1alpha1.MACPool
+			if tc.getErr == nil {
+				pool = &kubevirtproviderv1alpha1.MACPool{
+					ObjectMeta: k8smetav1.ObjectMeta{Name: "workers", Namespace: machine.Namespace},
+					Spec:       kubevirtproviderv1alpha1.MACPoolSpec{Range: "02:00:00:00:00:00-02:00:00:00:00:0f"},
+					Status:     kubevirtproviderv1alpha1.MACPoolStatus{Allocations: tc.allocations},
+				}
+			}
+			getTimes := 0
+			if tc.poolName != "" {
+				getTimes = 1
+			}
+			newMockTenantClusterClient.EXPECT().GetMACPool(gomock.Any(), "workers", machine.Namespace).Return(pool, tc.getErr).Times(getTimes)
+			updateTimes := 0
+			if tc.wantUpdate {
+				updateTimes = 1
+			}
+			newMockTenantClusterClient.EXPECT().UpdateMACPoolStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updatedPool *kubevirtproviderv1alpha1.MACPool) error {
+				assert.Equal(t, len(updatedPool.Status.Allocations), wantAllocations)
+				return tc.updateErr
+			}).Times(updateTimes)
+
+			machineScope := &machineScope{
+				machine:               machine,
+				tenantClusterClient:   newMockTenantClusterClient,
+				machineProviderSpec:   &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{MACPool: tc.poolName},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{},
+			}
+			err := machineScope.allocateMACAddress()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, machineScope.machineProviderStatus.MACAddress, tc.wantMACAddress)
+			if tc.wantMACAddress != "" {
+				assert.Equal(t, pool.Status.Allocations[tc.wantMACAddress], "machine-test")
+				assert.Equal(t, machineScope.machineProviderStatus.MACPool, "workers")
+			}
+		})
+	}
+}
+
+func TestReleaseMACAddress(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+	machine := initializeMachine(t, nil, "", false)
+	pool := &kubevirtproviderv1alpha1.MACPool{
+		ObjectMeta: k8smetav1.ObjectMeta{Name: "workers", Namespace: machine.Namespace},
+		Status:     kubevirtproviderv1alpha1.MACPoolStatus{Allocations: map[string]string{"02:00:00:00:00:01": "machine-test", "02:00:00:00:00:02": "machine-other"}},
+	}
+	newMockTenantClusterClient.EXPECT().GetMACPool(gomock.Any(), "workers", machine.Namespace).Return(pool, nil)
+	// The pool of the status is released too, even though it was removed from the spec
+	newMockTenantClusterClient.EXPECT().GetMACPool(gomock.Any(), "removed", machine.Namespace).Return(nil, stubNotFoundError())
+	newMockTenantClusterClient.EXPECT().UpdateMACPoolStatus(gomock.Any(), pool).Return(nil)
+
+	machineScope := &machineScope{
+		machine:               machine,
+		tenantClusterClient:   newMockTenantClusterClient,
+		machineProviderSpec:   &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{MACPool: "workers"},
+		machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{MACAddress: "02:00:00:00:01:01", MACPool: "removed"},
+	}
+	assert.NilError(t, machineScope.releaseMACAddress())
+	assert.DeepEqual(t, pool.Status.Allocations, map[string]string{"02:00:00:00:00:02": "machine-other"})
+	assert.Equal(t, machineScope.machineProviderStatus.MACAddress, "")
+	assert.Equal(t, machineScope.machineProviderStatus.MACPool, "")
+}
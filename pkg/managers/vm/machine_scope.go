@@ -1,54 +1,88 @@
 package vm
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 
+	"github.com/go-logr/logr"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 
 	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/logging"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/utils"
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apiresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"k8s.io/klog"
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
-	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1alpha1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 type machineState string
 
+// Machine instance states, set in the instance state annotation and the provider status
 const (
-	vmNotCreated      machineState = "vmNotCreated"
-	vmCreatedNotReady machineState = "vmWasCreatedButNotReady"
-	vmCreatedAndReady machineState = "vmWasCreatedAndReady"
+	vmNotCreated machineState = "vmNotCreated"
+	// vmStopped is the state of a VM without vmi, that isn't expected to run
+	vmStopped              machineState = "vmStopped"
+	dataVolumeProvisioning machineState = "dataVolumeProvisioning"
+	dataVolumeFailed       machineState = "dataVolumeFailed"
+	vmiPending             machineState = "vmiPending"
+	vmiScheduling          machineState = "vmiScheduling"
+	vmiScheduled           machineState = "vmiScheduled"
+	vmiRunning             machineState = "vmiRunning"
+	vmiSucceeded           machineState = "vmiSucceeded"
+	vmiFailed              machineState = "vmiFailed"
+	vmiUnknown             machineState = "vmiUnknown"
+	// vmiNotReady is the state of a running vmi, whose readiness probe fails
+	vmiNotReady machineState = "vmiNotReady"
 )
 
 const (
-	defaultRequestedMemory            = "2048M"
+	defaultRequestedMemory            = kubevirtproviderv1alpha1.DefaultRequestedMemory
 	defaultRequestedStorage           = "35Gi"
 	defaultPersistentVolumeAccessMode = corev1.ReadWriteMany
 	defaultDataVolumeDiskName         = "datavolumedisk1"
 	defaultCloudInitVolumeDiskName    = "cloudinitdisk"
 	defaultBootVolumeDiskName         = "bootvolume"
 	kubevirtIdAnnotationKey           = "VmId"
+	migrateAnnotationKey              = "kubevirt.machine.openshift.io/migrate"
+	restartAnnotationKey              = "kubevirt.machine.openshift.io/restart"
+	appliedHashAnnotationKey          = "kubevirt.machine.openshift.io/applied-hash"
+	powerStateAnnotationKey           = "kubevirt.machine.openshift.io/power-state"
 	userDataKey                       = "userData"
-	defaultBus                        = "virtio"
-	APIVersion                        = "kubevirt.io/v1alpha3"
-	Kind                              = "VirtualMachine"
-	mainNetworkName                   = "main"
-	podNetworkName                    = "pod-network"
+	// userDataSecretKey is the key of the user data in the infra-cluster secret read by the VM cloud-init volume
+	userDataSecretKey           = "userdata"
+	networkDataSecretKey        = "networkdata"
+	defaultUserDataSecretSuffix = "userdata"
+	defaultBus                  = "virtio"
+	APIVersion                  = "kubevirt.io/v1"
+	Kind                        = "VirtualMachine"
+	mainNetworkName             = "main"
+	podNetworkName              = "pod-network"
+)
+
+const (
+	// machineAPIAnnotationPrefix is the prefix of the machine annotations of the machine API, like the instance state
+	machineAPIAnnotationPrefix = "machine.openshift.io/"
+	// controllerAnnotationPrefix is the prefix of the machine annotations of the controller, like the operation requests
+	controllerAnnotationPrefix = "kubevirt.machine.openshift.io/"
 )
 
 const providerIDFormat = "kubevirt://%s/%s"
 
+// VMNameLabel labels the infra-cluster objects of a VM with its name, which is the name of its machine
+const VMNameLabel = "kubevirt.io/vm"
+
 type machineScope struct {
+	ctx                   context.Context
 	infraClusterClient    infracluster.Client
 	tenantClusterClient   tenantcluster.Client
 	machine               *machinev1.Machine
@@ -59,7 +93,7 @@ type machineScope struct {
 	infraID               string
 }
 
-func newMachineScope(machine *machinev1.Machine, tenantClusterClient tenantcluster.Client, infraClusterClientBuilder infracluster.ClientBuilderFuncType) (*machineScope, error) {
+func newMachineScope(ctx context.Context, machine *machinev1.Machine, tenantClusterClient tenantcluster.Client, infraClusterClientBuilder infracluster.ClientBuilderFuncType) (*machineScope, error) {
 	if err := validateMachine(*machine); err != nil {
 		return nil, fmt.Errorf("%v: failed validating machine provider spec: %w", machine.GetName(), err)
 	}
@@ -74,21 +108,26 @@ func newMachineScope(machine *machinev1.Machine, tenantClusterClient tenantclust
 		return nil, machinecontroller.InvalidMachineConfiguration("failed to get machine provider status: %v", err.Error())
 	}
 
-	infraClusterClient, err := infraClusterClientBuilder(tenantClusterClient, providerSpec.CredentialsSecretName, machine.GetNamespace())
+	infraClusterClient, err := infraClusterClientBuilder(ctx, tenantClusterClient, providerSpec.CredentialsSecretName, machine.GetNamespace())
 	if err != nil {
 		return nil, machinecontroller.InvalidMachineConfiguration("failed to create aKubeVirt client: %v", err.Error())
 	}
 
-	vmNamespace, err := tenantClusterClient.GetNamespace()
+	vmNamespace, err := tenantClusterClient.GetNamespace(ctx)
 	if err != nil {
 		return nil, err
 	}
-	infraID, err := tenantClusterClient.GetInfraID()
+	infraID, err := tenantClusterClient.GetInfraID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// The logger of the scope correlates the log entries of the machine with its infra-cluster objects,
+	// and is carried by its context to the clients
+	log := logging.FromContext(ctx).WithValues(logging.InfraIDKey, infraID, logging.VMNamespaceKey, vmNamespace)
+
 	return &machineScope{
+		ctx:                   logging.IntoContext(ctx, log),
 		infraClusterClient:    infraClusterClient,
 		tenantClusterClient:   tenantClusterClient,
 		machine:               machine,
@@ -116,9 +155,12 @@ func (s *machineScope) createVirtualMachineFromMachine() (*kubevirtapiv1.Virtual
 	if err := s.assertMandatoryParams(); err != nil {
 		return nil, err
 	}
-	runAlways := kubevirtapiv1.RunStrategyAlways
+	runStrategy, err := s.getRunStrategy()
+	if err != nil {
+		return nil, err
+	}
 
-	vmiTemplate, err := s.buildVMITemplate(s.vmNamespace)
+	vmiTemplate, err := s.buildVMITemplate()
 	if err != nil {
 		return nil, err
 	}
@@ -142,12 +184,29 @@ func (s *machineScope) createVirtualMachineFromMachine() (*kubevirtapiv1.Virtual
 				s.machine.GetName(), corev1.ReadWriteMany, corev1.ReadOnlyMany, corev1.ReadWriteOnce)
 		}
 	}
+	switch s.machineProviderSpec.RestartPolicy {
+	case "", kubevirtproviderv1alpha1.RestartPolicyNever, kubevirtproviderv1alpha1.RestartPolicyManual, kubevirtproviderv1alpha1.RestartPolicyImmediate:
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: Value of RestartPolicy, can be only one of: %v, %v, %v",
+			s.machine.GetName(), kubevirtproviderv1alpha1.RestartPolicyNever, kubevirtproviderv1alpha1.RestartPolicyManual, kubevirtproviderv1alpha1.RestartPolicyImmediate)
+	}
+	if vmiTemplate.Spec.EvictionStrategy != nil && *vmiTemplate.Spec.EvictionStrategy == kubevirtapiv1.EvictionStrategyLiveMigrate && PVCAccessMode != corev1.ReadWriteMany {
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: EvictionStrategy %v requires PersistentVolumeAccessMode %v",
+			s.machine.GetName(), kubevirtapiv1.EvictionStrategyLiveMigrate, corev1.ReadWriteMany)
+	}
+
+	instancetype, preference, err := s.buildMatchers()
+	if err != nil {
+		return nil, err
+	}
 
 	virtualMachine := kubevirtapiv1.VirtualMachine{
 		Spec: kubevirtapiv1.VirtualMachineSpec{
-			RunStrategy: &runAlways,
-			DataVolumeTemplates: []cdiv1.DataVolume{
-				*buildBootVolumeDataVolumeTemplate(s.machine.GetName(), s.machineProviderSpec.SourcePvcName, s.vmNamespace, s.machineProviderSpec.StorageClassName, pvcRequestsStorage, PVCAccessMode, utils.BuildLabels(s.infraID)),
+			RunStrategy:  &runStrategy,
+			Instancetype: instancetype,
+			Preference:   preference,
+			DataVolumeTemplates: []kubevirtapiv1.DataVolumeTemplateSpec{
+				*buildBootVolumeDataVolumeTemplate(s.machine.GetName(), s.machineProviderSpec.SourcePvcName, s.vmNamespace, s.machineProviderSpec.StorageClassName, pvcRequestsStorage, PVCAccessMode, s.buildInfraObjectLabels()),
 			},
 			Template: vmiTemplate,
 		},
@@ -157,6 +216,9 @@ func (s *machineScope) createVirtualMachineFromMachine() (*kubevirtapiv1.Virtual
 	for k, v := range s.machine.Labels {
 		labels[k] = v
 	}
+	labels[VMNameLabel] = s.machine.Name
+
+	annotations := s.buildVMAnnotations()
 
 	virtualMachine.APIVersion = APIVersion
 	virtualMachine.Kind = Kind
@@ -164,7 +226,7 @@ func (s *machineScope) createVirtualMachineFromMachine() (*kubevirtapiv1.Virtual
 		Name:            s.machine.Name,
 		Namespace:       s.vmNamespace,
 		Labels:          labels,
-		Annotations:     s.machine.Annotations,
+		Annotations:     annotations,
 		OwnerReferences: nil,
 		ClusterName:     s.machine.ClusterName,
 	}
@@ -172,6 +234,118 @@ func (s *machineScope) createVirtualMachineFromMachine() (*kubevirtapiv1.Virtual
 	return &virtualMachine, nil
 }
 
+// buildInfraObjectLabels returns the labels of the infra-cluster objects created for the VM: the VMI and its
+// virt-launcher pods, and the boot volume DataVolume and its PVC
+func (s *machineScope) buildInfraObjectLabels() map[string]string {
+	labels := utils.BuildLabels(s.infraID)
+	labels[VMNameLabel] = s.machine.GetName()
+	return labels
+}
+
+// buildVMAnnotations returns the machine annotations copied to the VM, nil without any.
+// The annotations of the machine API and of the controller, like the instance state and the requests of operations on
+// the machine, aren't copied: they change without changing the VM, which would be applied again on each change.
+func (s *machineScope) buildVMAnnotations() map[string]string {
+	var annotations map[string]string
+	for k, v := range s.machine.Annotations {
+		if k == kubevirtIdAnnotationKey || strings.HasPrefix(k, machineAPIAnnotationPrefix) || strings.HasPrefix(k, controllerAnnotationPrefix) {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// buildMatchers returns the VM instancetype and preference matchers of the machine, nil when they aren't set
+func (s *machineScope) buildMatchers() (*kubevirtapiv1.InstancetypeMatcher, *kubevirtapiv1.PreferenceMatcher, error) {
+	var instancetypeMatcher *kubevirtapiv1.InstancetypeMatcher
+	if instancetype := s.machineProviderSpec.Instancetype; instancetype != nil {
+		if s.machineProviderSpec.RequestedCPU != 0 || s.machineProviderSpec.RequestedMemory != "" {
+			return nil, nil, machinecontroller.InvalidMachineConfiguration("%v: Instancetype can't be set with RequestedCPU or RequestedMemory", s.machine.GetName())
+		}
+		kind := instancetype.Kind
+		if kind == "" {
+			kind = kubevirtproviderv1alpha1.ClusterInstancetypeKind
+		}
+		if instancetype.Name == "" || (kind != kubevirtproviderv1alpha1.ClusterInstancetypeKind && kind != kubevirtproviderv1alpha1.InstancetypeKind) {
+			return nil, nil, machinecontroller.InvalidMachineConfiguration("%v: Instancetype requires a name and a kind of: %v, %v", s.machine.GetName(),
+				kubevirtproviderv1alpha1.ClusterInstancetypeKind, kubevirtproviderv1alpha1.InstancetypeKind)
+		}
+		instancetypeMatcher = &kubevirtapiv1.InstancetypeMatcher{Kind: kind, Name: instancetype.Name}
+	}
+	var preferenceMatcher *kubevirtapiv1.PreferenceMatcher
+	if preference := s.machineProviderSpec.Preference; preference != nil {
+		kind := preference.Kind
+		if kind == "" {
+			kind = kubevirtproviderv1alpha1.ClusterPreferenceKind
+		}
+		if preference.Name == "" || (kind != kubevirtproviderv1alpha1.ClusterPreferenceKind && kind != kubevirtproviderv1alpha1.PreferenceKind) {
+			return nil, nil, machinecontroller.InvalidMachineConfiguration("%v: Preference requires a name and a kind of: %v, %v", s.machine.GetName(),
+				kubevirtproviderv1alpha1.ClusterPreferenceKind, kubevirtproviderv1alpha1.PreferenceKind)
+		}
+		preferenceMatcher = &kubevirtapiv1.PreferenceMatcher{Kind: kind, Name: preference.Name}
+	}
+	return instancetypeMatcher, preferenceMatcher, nil
+}
+
+// getInstanceType returns the instance type of the machine, which is the instancetype name,
+// or "<cpu>c-<memory>" for a machine sized by RequestedCPU and RequestedMemory
+func (s *machineScope) getInstanceType() string {
+	if s.machineProviderSpec.Instancetype != nil {
+		return s.machineProviderSpec.Instancetype.Name
+	}
+	cpu := s.machineProviderSpec.RequestedCPU
+	if cpu == 0 {
+		cpu = kubevirtproviderv1alpha1.DefaultRequestedCPU
+	}
+	memory, err := apiresource.ParseQuantity(s.getRequestedMemory())
+	if err != nil {
+		return fmt.Sprintf("%dc-%s", cpu, s.getRequestedMemory())
+	}
+	return fmt.Sprintf("%dc-%s", cpu, memory.String())
+}
+
+func (s *machineScope) getRequestedMemory() string {
+	if s.machineProviderSpec.RequestedMemory == "" {
+		return defaultRequestedMemory
+	}
+	return s.machineProviderSpec.RequestedMemory
+}
+
+// getRunStrategy returns the VM run strategy of the machine.
+// When the machine is annotated with a power state, the controller drives the VM power through the
+// infra-cluster client, so the VM runs with the Manual strategy.
+func (s *machineScope) getRunStrategy() (kubevirtapiv1.VirtualMachineRunStrategy, error) {
+	if powerState, ok := s.machine.GetAnnotations()[powerStateAnnotationKey]; ok {
+		switch powerState {
+		case powerStateRunning, powerStateStopped, powerStatePaused:
+			return kubevirtapiv1.RunStrategyManual, nil
+		default:
+			return "", machinecontroller.InvalidMachineConfiguration("%v: Value of %v annotation, can be only one of: %v, %v, %v",
+				s.machine.GetName(), powerStateAnnotationKey, powerStateRunning, powerStateStopped, powerStatePaused)
+		}
+	}
+
+	runStrategy := kubevirtapiv1.VirtualMachineRunStrategy(s.machineProviderSpec.RunStrategy)
+	switch runStrategy {
+	case "":
+		return kubevirtapiv1.RunStrategyAlways, nil
+	case kubevirtapiv1.RunStrategyAlways, kubevirtapiv1.RunStrategyHalted, kubevirtapiv1.RunStrategyManual, kubevirtapiv1.RunStrategyRerunOnFailure:
+		return runStrategy, nil
+	default:
+		return "", machinecontroller.InvalidMachineConfiguration("%v: Value of RunStrategy, can be only one of: %v, %v, %v, %v", s.machine.GetName(),
+			kubevirtapiv1.RunStrategyAlways, kubevirtapiv1.RunStrategyHalted, kubevirtapiv1.RunStrategyManual, kubevirtapiv1.RunStrategyRerunOnFailure)
+	}
+}
+
+// logger returns the logger of the machine, carried by the context of the scope
+func (s *machineScope) logger() logr.Logger {
+	return logging.FromContext(s.ctx)
+}
+
 func (s *machineScope) getMachineName() string {
 	return s.machine.GetName()
 }
@@ -191,12 +365,12 @@ func (s *machineScope) setProviderID(vm *kubevirtapiv1.VirtualMachine) {
 	providerID := formatProviderID(s.getMachineNamespace(), vm.GetName())
 
 	if existingProviderID != nil && *existingProviderID == providerID {
-		klog.Infof("%s: ProviderID already set in the machine Spec with value:%s", s.getMachineName(), *existingProviderID)
+		s.logger().Info("ProviderID already set in the machine Spec", "providerID", *existingProviderID)
 		return
 	}
 
 	s.machine.Spec.ProviderID = &providerID
-	klog.Infof("%s: ProviderID set at machine spec: %s", s.getMachineName(), providerID)
+	s.logger().Info("ProviderID set at machine spec", "providerID", providerID)
 }
 
 // updateAllowed validates that updates come in the right order
@@ -212,6 +386,10 @@ func buildCloudInitVolumeDiskName(virtualMachineName string) string {
 	return buildVolumeName(virtualMachineName, defaultCloudInitVolumeDiskName)
 }
 
+func buildUserDataSecretName(virtualMachineName string) string {
+	return buildVolumeName(virtualMachineName, defaultUserDataSecretSuffix)
+}
+
 func buildBootVolumeName(virtualMachineName string) string {
 	return buildVolumeName(virtualMachineName, defaultBootVolumeDiskName)
 }
@@ -220,21 +398,42 @@ func buildVolumeName(virtualMachineName, suffixVolumeName string) string {
 	return fmt.Sprintf("%s-%s", virtualMachineName, suffixVolumeName)
 }
 
-func (s *machineScope) buildVMITemplate(namespace string) (*kubevirtapiv1.VirtualMachineInstanceTemplateSpec, error) {
+func (s *machineScope) buildVMITemplate() (*kubevirtapiv1.VirtualMachineInstanceTemplateSpec, error) {
 	virtualMachineName := s.machine.GetName()
 
 	template := &kubevirtapiv1.VirtualMachineInstanceTemplateSpec{}
 
 	template.ObjectMeta = metav1.ObjectMeta{
-		Labels: map[string]string{"kubevirt.io/vm": virtualMachineName, "name": virtualMachineName},
+		Labels: s.buildInfraObjectLabels(),
 	}
+	template.ObjectMeta.Labels["name"] = virtualMachineName
 
-	userData, err := s.getUserData(namespace)
+	template.Spec = kubevirtapiv1.VirtualMachineInstanceSpec{}
+	if s.machineProviderSpec.EvictionStrategy != "" {
+		evictionStrategy := kubevirtapiv1.EvictionStrategy(s.machineProviderSpec.EvictionStrategy)
+		if evictionStrategy != kubevirtapiv1.EvictionStrategyLiveMigrate {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: Value of EvictionStrategy, can be only: %v",
+				s.machine.GetName(), kubevirtapiv1.EvictionStrategyLiveMigrate)
+		}
+		template.Spec.EvictionStrategy = &evictionStrategy
+	}
+	template.Spec.StartStrategy = s.getStartStrategy()
+	if err := s.buildVMIProbes(&template.Spec); err != nil {
+		return nil, err
+	}
+	accessCredentials, err := s.buildAccessCredentials()
+	if err != nil {
+		return nil, err
+	}
+	template.Spec.AccessCredentials = accessCredentials
+	podNetworkIPv6CIDR, err := s.getPodNetworkIPv6CIDR()
+	if err != nil {
+		return nil, err
+	}
+	cloudInitVolumeSource, err := s.buildCloudInitVolumeSource()
 	if err != nil {
 		return nil, err
 	}
-
-	template.Spec = kubevirtapiv1.VirtualMachineInstanceSpec{}
 	template.Spec.Volumes = []kubevirtapiv1.Volume{
 		{
 			Name: buildDataVolumeDiskName(virtualMachineName),
@@ -245,12 +444,8 @@ func (s *machineScope) buildVMITemplate(namespace string) (*kubevirtapiv1.Virtua
 			},
 		},
 		{
-			Name: buildCloudInitVolumeDiskName(virtualMachineName),
-			VolumeSource: kubevirtapiv1.VolumeSource{
-				CloudInitConfigDrive: &kubevirtapiv1.CloudInitConfigDriveSource{
-					UserData: userData,
-				},
-			},
+			Name:         buildCloudInitVolumeDiskName(virtualMachineName),
+			VolumeSource: cloudInitVolumeSource,
 		},
 	}
 	multusNetwork := &kubevirtapiv1.MultusNetwork{
@@ -266,28 +461,26 @@ func (s *machineScope) buildVMITemplate(namespace string) (*kubevirtapiv1.Virtua
 		{
 			Name: podNetworkName,
 			NetworkSource: kubevirtapiv1.NetworkSource{
-				Pod: &kubevirtapiv1.PodNetwork{},
+				Pod: &kubevirtapiv1.PodNetwork{VMIPv6NetworkCIDR: podNetworkIPv6CIDR},
 			},
 		},
 	}
 
 	template.Spec.Domain = kubevirtapiv1.DomainSpec{}
 
-	requests := corev1.ResourceList{}
-
-	requestedMemory := s.machineProviderSpec.RequestedMemory
-	if requestedMemory == "" {
-		requestedMemory = defaultRequestedMemory
-	}
+	// The resources of a VM with an instancetype are set by the instancetype
+	if s.machineProviderSpec.Instancetype == nil {
+		requests := corev1.ResourceList{}
 
-	requests[corev1.ResourceMemory] = apiresource.MustParse(requestedMemory)
+		requests[corev1.ResourceMemory] = apiresource.MustParse(s.getRequestedMemory())
 
-	if s.machineProviderSpec.RequestedCPU != 0 {
-		requests[corev1.ResourceCPU] = apiresource.MustParse(fmt.Sprint(s.machineProviderSpec.RequestedCPU))
-	}
+		if s.machineProviderSpec.RequestedCPU != 0 {
+			requests[corev1.ResourceCPU] = apiresource.MustParse(fmt.Sprint(s.machineProviderSpec.RequestedCPU))
+		}
 
-	template.Spec.Domain.Resources = kubevirtapiv1.ResourceRequirements{
-		Requests: requests,
+		template.Spec.Domain.Resources = kubevirtapiv1.ResourceRequirements{
+			Requests: requests,
+		}
 	}
 	template.Spec.Domain.Devices = kubevirtapiv1.Devices{
 		Disks: []kubevirtapiv1.Disk{
@@ -314,6 +507,7 @@ func (s *machineScope) buildVMITemplate(namespace string) (*kubevirtapiv1.Virtua
 				InterfaceBindingMethod: kubevirtapiv1.InterfaceBindingMethod{
 					Bridge: &kubevirtapiv1.InterfaceBridge{},
 				},
+				MacAddress: s.machineProviderStatus.MACAddress,
 			},
 			{
 				Name: podNetworkName,
@@ -323,13 +517,17 @@ func (s *machineScope) buildVMITemplate(namespace string) (*kubevirtapiv1.Virtua
 			},
 		},
 	}
+	if err := s.buildDomainFirmware(&template.Spec.Domain); err != nil {
+		return nil, err
+	}
 
 	return template, nil
 }
 
-func (s *machineScope) getUserData(namespace string) (string, error) {
+func (s *machineScope) getUserData() (string, error) {
 	secretName := s.machineProviderSpec.IgnitionSecretName
-	userDataSecret, err := s.tenantClusterClient.GetSecret(secretName, s.machine.GetNamespace())
+	namespace := s.machine.GetNamespace()
+	userDataSecret, err := s.tenantClusterClient.GetSecret(s.ctx, secretName, namespace)
 	if err != nil {
 		if apimachineryerrors.IsNotFound(err) {
 			return "", machinecontroller.InvalidMachineConfiguration("Tenant-cluster credentials secret %s/%s: %v not found", namespace, secretName, err)
@@ -344,8 +542,68 @@ func (s *machineScope) getUserData(namespace string) (string, error) {
 	return userData, nil
 }
 
+// buildUserDataSecret returns the infra-cluster secret holding the user data of the machine VM
+func (s *machineScope) buildUserDataSecret() (*corev1.Secret, error) {
+	userData, err := s.getUserData()
+	if err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{}
+	userDataFormat, err := s.getUserDataFormat()
+	if err != nil {
+		return nil, err
+	}
+	switch userDataFormat {
+	case kubevirtproviderv1alpha1.UserDataFormatIgnition:
+		userData, err = s.augmentIgnition(userData)
+		if err != nil {
+			return nil, err
+		}
+	case kubevirtproviderv1alpha1.UserDataFormatCloudConfig:
+		if s.hasStaticNetworkConfig() {
+			interfaces, err := s.buildInterfaceConfigs()
+			if err != nil {
+				return nil, err
+			}
+			networkData, err := buildNetworkData(interfaces)
+			if err != nil {
+				return nil, err
+			}
+			data[networkDataSecretKey] = []byte(networkData)
+		}
+	}
+	data[userDataSecretKey] = []byte(userData)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      buildUserDataSecretName(s.machine.GetName()),
+			Namespace: s.vmNamespace,
+			Labels:    utils.BuildLabels(s.infraID),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}, nil
+}
+
+// buildVMSecrets returns the infra-cluster secrets of the machine VM: its user data, and the copy of its SSH keys
+func (s *machineScope) buildVMSecrets() ([]*corev1.Secret, error) {
+	userDataSecret, err := s.buildUserDataSecret()
+	if err != nil {
+		return nil, err
+	}
+	secrets := []*corev1.Secret{userDataSecret}
+	if s.machineProviderSpec.SSHKeys != nil {
+		sshKeysSecret, err := s.buildSSHKeysSecret()
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, sshKeysSecret)
+	}
+	return secrets, nil
+}
+
 func buildBootVolumeDataVolumeTemplate(virtualMachineName, pvcName, dvNamespace, storageClassName,
-	pvcRequestsStorage string, accessMode corev1.PersistentVolumeAccessMode, labels map[string]string) *cdiv1.DataVolume {
+	pvcRequestsStorage string, accessMode corev1.PersistentVolumeAccessMode, labels map[string]string) *kubevirtapiv1.DataVolumeTemplateSpec {
 
 	persistentVolumeClaimSpec := corev1.PersistentVolumeClaimSpec{
 		AccessModes: []corev1.PersistentVolumeAccessMode{
@@ -362,7 +620,7 @@ func buildBootVolumeDataVolumeTemplate(virtualMachineName, pvcName, dvNamespace,
 		persistentVolumeClaimSpec.StorageClassName = &storageClassName
 	}
 
-	return &cdiv1.DataVolume{
+	return &kubevirtapiv1.DataVolumeTemplateSpec{
 		TypeMeta: metav1.TypeMeta{APIVersion: cdiv1.SchemeGroupVersion.String()},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      buildBootVolumeName(virtualMachineName),
@@ -370,7 +628,7 @@ func buildBootVolumeDataVolumeTemplate(virtualMachineName, pvcName, dvNamespace,
 			Labels:    labels,
 		},
 		Spec: cdiv1.DataVolumeSpec{
-			Source: cdiv1.DataVolumeSource{
+			Source: &cdiv1.DataVolumeSource{
 				PVC: &cdiv1.DataVolumeSourcePVC{
 					Name:      pvcName,
 					Namespace: dvNamespace,
@@ -381,22 +639,25 @@ func buildBootVolumeDataVolumeTemplate(virtualMachineName, pvcName, dvNamespace,
 	}
 }
 
-func (s *machineScope) SyncMachineFromVm(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance) error {
+func (s *machineScope) SyncMachineFromVm(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance, dv *cdiv1.DataVolume) error {
 	s.setProviderID(vm)
 
-	if err := s.setMachineAnnotationsAndLabels(vm); err != nil {
+	state := machineInstanceState(vm, vmi, dv)
+	if err := s.setMachineAnnotationsAndLabels(vm, state); err != nil {
 		return fmt.Errorf("failed to set machine cloud provider specifics: %w", err)
 	}
 
 	if err := s.setProviderStatus(vm, vmi, conditionSuccess()); err != nil {
 		return machinecontroller.InvalidMachineConfiguration("failed to set machine provider status: %v", err.Error())
 	}
+	s.setInstanceStatus(state, vmi, dv)
+	s.setGuestAgentStatus(vmi)
 
-	klog.Infof("Updated machine %s", s.getMachineName())
+	s.logger().Info("Updated machine")
 	return nil
 }
 
-func (s *machineScope) setMachineAnnotationsAndLabels(vm *kubevirtapiv1.VirtualMachine) error {
+func (s *machineScope) setMachineAnnotationsAndLabels(vm *kubevirtapiv1.VirtualMachine, vmState machineState) error {
 	if vm == nil {
 		return nil
 	}
@@ -413,15 +674,7 @@ func (s *machineScope) setMachineAnnotationsAndLabels(vm *kubevirtapiv1.VirtualM
 		s.machine.Annotations = make(map[string]string)
 	}
 	vmId := vm.UID
-	vmType := vm.Spec.Template.Spec.Domain.Machine.Type
-
-	vmState := vmNotCreated
-	if vm.Status.Created {
-		vmState = vmCreatedNotReady
-		if vm.Status.Ready {
-			vmState = vmCreatedAndReady
-		}
-	}
+	vmType := s.getInstanceType()
 
 	s.machine.ObjectMeta.Annotations[kubevirtIdAnnotationKey] = string(vmId)
 	s.machine.Labels[machinecontroller.MachineInstanceTypeLabelName] = vmType
@@ -430,10 +683,105 @@ func (s *machineScope) setMachineAnnotationsAndLabels(vm *kubevirtapiv1.VirtualM
 	return nil
 }
 
+// setInstanceStatus reports the instance state, the phases it is derived from and the instance health in the provider status
+func (s *machineScope) setInstanceStatus(state machineState, vmi *kubevirtapiv1.VirtualMachineInstance, dv *cdiv1.DataVolume) {
+	s.machineProviderStatus.InstanceState = string(state)
+	s.machineProviderStatus.VMIPhase = ""
+	if vmi != nil {
+		s.machineProviderStatus.VMIPhase = vmi.Status.Phase
+	}
+	s.machineProviderStatus.DataVolumePhase = ""
+	if dv != nil {
+		s.machineProviderStatus.DataVolumePhase = dv.Status.Phase
+	}
+	s.machineProviderStatus.Conditions = setKubevirtMachineProviderCondition(instanceHealthCondition(state, vmi), s.machineProviderStatus.Conditions)
+}
+
+// machineInstanceState returns the state of the machine instance.
+// The vmi phase takes precedence, the boot volume phase is reported while the vmi doesn't exist.
+func machineInstanceState(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance, dv *cdiv1.DataVolume) machineState {
+	if vm == nil {
+		return vmNotCreated
+	}
+	if vmi != nil {
+		switch vmi.Status.Phase {
+		case kubevirtapiv1.Scheduling:
+			return vmiScheduling
+		case kubevirtapiv1.Scheduled:
+			return vmiScheduled
+		case kubevirtapiv1.Running:
+			if isVMINotReady(vmi) {
+				return vmiNotReady
+			}
+			return vmiRunning
+		case kubevirtapiv1.Succeeded:
+			return vmiSucceeded
+		case kubevirtapiv1.Failed:
+			return vmiFailed
+		case kubevirtapiv1.Unknown:
+			return vmiUnknown
+		default:
+			return vmiPending
+		}
+	}
+	if dv != nil {
+		switch dv.Status.Phase {
+		case cdiv1.Succeeded:
+		case cdiv1.Failed:
+			return dataVolumeFailed
+		default:
+			return dataVolumeProvisioning
+		}
+	}
+	if !isVMExpectedToRun(vm) {
+		return vmStopped
+	}
+	return vmiPending
+}
+
+// isVMINotReady returns true if the vmi reports it isn't ready, which is the case while its readiness probe fails
+func isVMINotReady(vmi *kubevirtapiv1.VirtualMachineInstance) bool {
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubevirtapiv1.VirtualMachineInstanceReady {
+			return condition.Status == corev1.ConditionFalse
+		}
+	}
+	return false
+}
+
+// isVMExpectedToRun returns true if the VM controller creates a vmi for the VM
+func isVMExpectedToRun(vm *kubevirtapiv1.VirtualMachine) bool {
+	if len(vm.Status.StateChangeRequests) > 0 {
+		return true
+	}
+	if vm.Spec.Running != nil {
+		return *vm.Spec.Running
+	}
+	if vm.Spec.RunStrategy == nil {
+		return false
+	}
+	switch *vm.Spec.RunStrategy {
+	case kubevirtapiv1.RunStrategyAlways, kubevirtapiv1.RunStrategyRerunOnFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// isInstancePending returns true while the machine instance is on its way to run
+func isInstancePending(state machineState) bool {
+	switch state {
+	case vmNotCreated, dataVolumeProvisioning, vmiPending, vmiScheduling, vmiScheduled:
+		return true
+	default:
+		return false
+	}
+}
+
 // Patch patches the machine spec and machine status after reconciling.
 func (s *machineScope) patchMachine() error {
 
-	klog.V(3).Infof("%v: patching machine", s.machine.GetName())
+	s.logger().V(3).Info("patching machine")
 
 	providerStatus, err := kubevirtproviderv1alpha1.RawExtensionFromProviderStatus(s.machineProviderStatus)
 	if err != nil {
@@ -443,53 +791,55 @@ func (s *machineScope) patchMachine() error {
 
 	// patch machine
 	statusCopy := *s.machine.Status.DeepCopy()
-	if err := s.tenantClusterClient.PatchMachine(s.machine, s.originMachineCopy); err != nil {
-		klog.Errorf("Failed to patch machine %q: %v", s.machine.GetName(), err)
+	if err := s.tenantClusterClient.PatchMachine(s.ctx, s.machine, s.originMachineCopy); err != nil {
+		s.logger().Error(err, "Failed to patch machine")
 		return err
 	}
 
 	s.machine.Status = statusCopy
 
 	// patch status
-	if err := s.tenantClusterClient.StatusPatchMachine(s.machine, s.originMachineCopy); err != nil {
-		klog.Errorf("Failed to patch machine status %q: %v", s.machine.GetName(), err)
+	if err := s.tenantClusterClient.StatusPatchMachine(s.ctx, s.machine, s.originMachineCopy); err != nil {
+		s.logger().Error(err, "Failed to patch machine status")
 		return err
 	}
 
 	return nil
 }
 
-func machineProviderStatusFromVirtualMachine(virtualMachine *kubevirtapiv1.VirtualMachine) *kubevirtproviderv1alpha1.KubevirtMachineProviderStatus {
-	return &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{
-		VirtualMachineStatus: virtualMachine.Status,
-	}
-}
-
 func (s *machineScope) setProviderStatus(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance, condition kubevirtapiv1.VirtualMachineCondition) error {
 	if vm == nil {
-		klog.Infof("%s: couldn't calculate KubeVirt status - the provided vm is empty", s.machine.GetName())
+		s.logger().Info("couldn't calculate KubeVirt status - the provided vm is empty")
 		return nil
 	}
-	klog.Infof("%s: Updating status", s.machine.GetName())
+	s.logger().Info("Updating status")
 	var networkAddresses []corev1.NodeAddress
-	s.machineProviderStatus = machineProviderStatusFromVirtualMachine(vm)
+	providerConditions := providerOwnedConditions(s.machineProviderStatus.Conditions)
+	s.machineProviderStatus.VirtualMachineStatus = *vm.Status.DeepCopy()
+	s.machineProviderStatus.Conditions = append(s.machineProviderStatus.Conditions, providerConditions...)
 
-	// update nodeAddresses
-	networkAddresses = append(networkAddresses, corev1.NodeAddress{Address: vm.Name, Type: corev1.NodeInternalDNS})
+	// update nodeAddresses, the VM name is the guest hostname
+	hostnameType, err := hostnameAddressType(s.machineProviderSpec.NodeAddressRules)
+	if err != nil {
+		return err
+	}
+	if hostnameType != "" {
+		networkAddresses = append(networkAddresses, corev1.NodeAddress{Address: vm.Name, Type: hostnameType})
+	}
 
 	// VMI might be nil while the vm is in creating state but the vmi wasn't created yet.
 	//For example when colning the VM's dv
 	if vmi != nil {
 		// Copy specific addresses - only node addresses.
-		addresses, err := extractNodeAddresses(vmi)
+		addresses, err := extractNodeAddresses(vmi, s.machineProviderSpec.NodeAddressRules, s.machineProviderSpec.IPFamilies)
 		if err != nil {
-			klog.Errorf("%s: Error extracting vm IP addresses: %v", s.machine.GetName(), err)
+			s.logger().Error(err, "Error extracting vm IP addresses")
 			return err
 		}
 		networkAddresses = append(networkAddresses, addresses...)
 	}
 
-	klog.Infof("%s: finished calculating KubeVirt status", s.machine.GetName())
+	s.logger().Info("finished calculating KubeVirt status")
 
 	s.machine.Status.Addresses = networkAddresses
 	// TODO: update the phase of the machine
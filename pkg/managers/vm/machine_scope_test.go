@@ -1,7 +1,15 @@
 package vm
 
 import (
+	"context"
 	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 const testNamespace = "infraCluster-test"
@@ -13,3 +21,327 @@ func TestGetUserData(t *testing.T) {
 func TestPatchMachine(t *testing.T) {
 
 }
+
+func TestMachineInstanceState(t *testing.T) {
+	runStrategyHalted := kubevirtapiv1.RunStrategyHalted
+	cases := []struct {
+		name            string
+		noVM            bool
+		runStrategy     *kubevirtapiv1.VirtualMachineRunStrategy
+		vmiPhase        *kubevirtapiv1.VirtualMachineInstancePhase
+		vmiReady        corev1.ConditionStatus
+		dvPhase         *cdiv1.DataVolumePhase
+		wantState       machineState
+		wantPending     bool
+		wantHealthState corev1.ConditionStatus
+	}{
+		{
+			name:            "VM wasn't created",
+			noVM:            true,
+			wantState:       vmNotCreated,
+			wantPending:     true,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+		{
+			name:            "Boot volume is importing",
+			dvPhase:         dataVolumePhasePtr(cdiv1.ImportInProgress),
+			wantState:       dataVolumeProvisioning,
+			wantPending:     true,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+		{
+			name:            "Boot volume import failed",
+			dvPhase:         dataVolumePhasePtr(cdiv1.Failed),
+			wantState:       dataVolumeFailed,
+			wantHealthState: corev1.ConditionFalse,
+		},
+		{
+			name:            "Boot volume is ready and the VMI wasn't created",
+			dvPhase:         dataVolumePhasePtr(cdiv1.Succeeded),
+			wantState:       vmiPending,
+			wantPending:     true,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+		{
+			name:            "VM is halted",
+			runStrategy:     &runStrategyHalted,
+			dvPhase:         dataVolumePhasePtr(cdiv1.Succeeded),
+			wantState:       vmStopped,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+		{
+			name:            "VMI without phase",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.VmPhaseUnset),
+			wantState:       vmiPending,
+			wantPending:     true,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+		{
+			name:            "VMI is scheduling",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Scheduling),
+			dvPhase:         dataVolumePhasePtr(cdiv1.Succeeded),
+			wantState:       vmiScheduling,
+			wantPending:     true,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+		{
+			name:            "VMI is running",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Running),
+			dvPhase:         dataVolumePhasePtr(cdiv1.Succeeded),
+			wantState:       vmiRunning,
+			wantHealthState: corev1.ConditionTrue,
+		},
+		{
+			name:            "VMI is running and ready",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Running),
+			vmiReady:        corev1.ConditionTrue,
+			wantState:       vmiRunning,
+			wantHealthState: corev1.ConditionTrue,
+		},
+		{
+			name:            "VMI is running and its readiness probe fails",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Running),
+			vmiReady:        corev1.ConditionFalse,
+			wantState:       vmiNotReady,
+			wantHealthState: corev1.ConditionFalse,
+		},
+		{
+			name:            "VMI has succeeded",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Succeeded),
+			wantState:       vmiSucceeded,
+			wantHealthState: corev1.ConditionFalse,
+		},
+		{
+			name:            "VMI has failed",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Failed),
+			wantState:       vmiFailed,
+			wantHealthState: corev1.ConditionFalse,
+		},
+		{
+			name:            "VMI is unknown",
+			vmiPhase:        vmiPhasePtr(kubevirtapiv1.Unknown),
+			wantState:       vmiUnknown,
+			wantHealthState: corev1.ConditionUnknown,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var vm *kubevirtapiv1.VirtualMachine
+			if !tc.noVM {
+				runStrategy := kubevirtapiv1.RunStrategyAlways
+				vm = &kubevirtapiv1.VirtualMachine{Spec: kubevirtapiv1.VirtualMachineSpec{RunStrategy: &runStrategy}}
+				if tc.runStrategy != nil {
+					vm.Spec.RunStrategy = tc.runStrategy
+				}
+			}
+			var vmi *kubevirtapiv1.VirtualMachineInstance
+			if tc.vmiPhase != nil {
+				vmi = &kubevirtapiv1.VirtualMachineInstance{}
+				vmi.Status.Phase = *tc.vmiPhase
+				if tc.vmiReady != "" {
+					vmi.Status.Conditions = []kubevirtapiv1.VirtualMachineInstanceCondition{
+						{Type: kubevirtapiv1.VirtualMachineInstanceReady, Status: tc.vmiReady},
+					}
+				}
+			}
+			var dv *cdiv1.DataVolume
+			if tc.dvPhase != nil {
+				dv = stubDataVolume(*tc.dvPhase)
+			}
+
+			state := machineInstanceState(vm, vmi, dv)
+			assert.Equal(t, state, tc.wantState)
+			assert.Equal(t, isInstancePending(state), tc.wantPending)
+			assert.Equal(t, instanceHealthCondition(state, vmi).Status, tc.wantHealthState)
+		})
+	}
+}
+
+func vmiPhasePtr(phase kubevirtapiv1.VirtualMachineInstancePhase) *kubevirtapiv1.VirtualMachineInstancePhase {
+	return &phase
+}
+
+func dataVolumePhasePtr(phase cdiv1.DataVolumePhase) *cdiv1.DataVolumePhase {
+	return &phase
+}
+
+func TestInstanceType(t *testing.T) {
+	cases := []struct {
+		name             string
+		providerSpec     kubevirtproviderv1alpha1.KubevirtMachineProviderSpec
+		wantInstanceType string
+		wantInstancetype *kubevirtapiv1.InstancetypeMatcher
+		wantPreference   *kubevirtapiv1.PreferenceMatcher
+		wantErr          bool
+	}{
+		{
+			name:             "Default resources",
+			wantInstanceType: "1c-2048M",
+		},
+		{
+			name: "Requested resources",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				RequestedCPU:    4,
+				RequestedMemory: "8Gi",
+			},
+			wantInstanceType: "4c-8Gi",
+		},
+		{
+			name: "Cluster instancetype and preference",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				Instancetype: &kubevirtproviderv1alpha1.InstancetypeMatcher{Name: "u1.medium"},
+				Preference:   &kubevirtproviderv1alpha1.PreferenceMatcher{Name: "rhel.9"},
+			},
+			wantInstanceType: "u1.medium",
+			wantInstancetype: &kubevirtapiv1.InstancetypeMatcher{Kind: "VirtualMachineClusterInstancetype", Name: "u1.medium"},
+			wantPreference:   &kubevirtapiv1.PreferenceMatcher{Kind: "VirtualMachineClusterPreference", Name: "rhel.9"},
+		},
+		{
+			name: "Namespaced instancetype",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				Instancetype: &kubevirtproviderv1alpha1.InstancetypeMatcher{Name: "worker", Kind: kubevirtproviderv1alpha1.InstancetypeKind},
+			},
+			wantInstanceType: "worker",
+			wantInstancetype: &kubevirtapiv1.InstancetypeMatcher{Kind: "VirtualMachineInstancetype", Name: "worker"},
+		},
+		{
+			name: "Instancetype with requested resources",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				Instancetype: &kubevirtproviderv1alpha1.InstancetypeMatcher{Name: "u1.medium"},
+				RequestedCPU: 2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Preference of an unknown kind",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				Preference: &kubevirtproviderv1alpha1.PreferenceMatcher{Name: "rhel.9", Kind: "Preference"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &machinev1.Machine{}
+			machine.Name = "machine-test"
+			machineScope := &machineScope{machine: machine, machineProviderSpec: &tc.providerSpec}
+
+			instancetype, preference, err := machineScope.buildMatchers()
+			if tc.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, instancetype, tc.wantInstancetype)
+			assert.DeepEqual(t, preference, tc.wantPreference)
+			assert.Equal(t, machineScope.getInstanceType(), tc.wantInstanceType)
+		})
+	}
+}
+
+func TestSetProviderStatusAddresses(t *testing.T) {
+	cases := []struct {
+		name          string
+		rules         []kubevirtproviderv1alpha1.NodeAddressRule
+		wantAddresses []corev1.NodeAddress
+		wantErr       bool
+	}{
+		{
+			name: "Report the VM name as an internal DNS name by default",
+			wantAddresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalDNS, Address: "machine-test"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+			},
+		},
+		{
+			name:  "Report the VM name as an external DNS name",
+			rules: []kubevirtproviderv1alpha1.NodeAddressRule{{Interface: "hostname", Type: kubevirtproviderv1alpha1.NodeAddressRuleExternalDNS}},
+			wantAddresses: []corev1.NodeAddress{
+				{Type: corev1.NodeExternalDNS, Address: "machine-test"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+			},
+		},
+		{
+			name:  "Drop the VM name",
+			rules: []kubevirtproviderv1alpha1.NodeAddressRule{{Interface: "hostname", Type: kubevirtproviderv1alpha1.NodeAddressRuleDrop}},
+			wantAddresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+			},
+		},
+		{
+			name:    "Reject an IP type of the VM name",
+			rules:   []kubevirtproviderv1alpha1.NodeAddressRule{{Interface: "hostname", Type: kubevirtproviderv1alpha1.NodeAddressRuleInternalIP}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &machinev1.Machine{}
+			machine.Name = "machine-test"
+			machineScope := &machineScope{
+				ctx:                   context.Background(),
+				machine:               machine,
+				machineProviderSpec:   &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{NodeAddressRules: tc.rules},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{},
+			}
+			vm := &kubevirtapiv1.VirtualMachine{}
+			vm.Name = "machine-test"
+			vmi := &kubevirtapiv1.VirtualMachineInstance{
+				Status: kubevirtapiv1.VirtualMachineInstanceStatus{
+					Interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+						{Name: mainNetworkName, IP: "192.168.1.10", IPs: []string{"192.168.1.10"}},
+						{Name: podNetworkName, IP: "10.128.0.5", IPs: []string{"10.128.0.5"}},
+					},
+				},
+			}
+
+			err := machineScope.setProviderStatus(vm, vmi, kubevirtapiv1.VirtualMachineCondition{})
+			if tc.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, machine.Status.Addresses, tc.wantAddresses)
+		})
+	}
+}
+
+func TestBuildVMAnnotations(t *testing.T) {
+	cases := []struct {
+		name               string
+		machineAnnotations map[string]string
+		wantAnnotations    map[string]string
+	}{
+		{
+			name: "Without annotations",
+		},
+		{
+			name: "Copy the user annotations",
+			machineAnnotations: map[string]string{
+				"example.com/owner":                         "team-a",
+				"machine.openshift.io/instance-state":       "vmiRunning",
+				"VmId":                                      "vm-uid",
+				"kubevirt.machine.openshift.io/restart":     "",
+				"kubevirt.machine.openshift.io/migrate":     "",
+				"kubevirt.machine.openshift.io/power-state": "Stopped",
+			},
+			wantAnnotations: map[string]string{"example.com/owner": "team-a"},
+		},
+		{
+			name: "Skip the annotations of the controller only",
+			machineAnnotations: map[string]string{
+				"machine.openshift.io/instance-state": "vmiRunning",
+				"VmId":                                "vm-uid",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &machinev1.Machine{}
+			machine.Annotations = tc.machineAnnotations
+			machineScope := &machineScope{machine: machine}
+
+			assert.DeepEqual(t, machineScope.buildVMAnnotations(), tc.wantAnnotations)
+		})
+	}
+}
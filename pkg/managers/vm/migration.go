@@ -0,0 +1,134 @@
+package vm
+
+import (
+	"fmt"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// syncMigration starts a live migration of the vmi when the machine carries the migrate annotation,
+// and reflects the progress of the last migration in the machine provider status.
+func (m *manager) syncMigration(vmi *kubevirtapiv1.VirtualMachineInstance, machineScope *machineScope) error {
+	previous := machineScope.machineProviderStatus.Migration.DeepCopy()
+
+	if _, requested := machineScope.machine.GetAnnotations()[migrateAnnotationKey]; requested {
+		if err := m.startMigration(vmi, machineScope); err != nil {
+			return err
+		}
+	}
+
+	current := machineScope.machineProviderStatus.Migration
+	if current != nil && current.Name != "" && previous != nil && previous.UID == current.UID && !isMigrationFinal(current.Phase) {
+		migration, err := m.getInfraClusterVMIMigration(current.Name, machineScope.vmNamespace, machineScope)
+		if err != nil {
+			return fmt.Errorf("failed to get VMI migration %s: %w", current.Name, err)
+		}
+		if migration.Status.Phase != kubevirtapiv1.MigrationPhaseUnset {
+			current.Phase = migration.Status.Phase
+		}
+	}
+
+	if vmi != nil && vmi.Status.MigrationState != nil {
+		state := vmi.Status.MigrationState
+		if current == nil || (current.UID != state.MigrationUID && isMigrationFinal(current.Phase)) {
+			// The migration was started by the infra-cluster, for example by a node drain of a LiveMigrate vmi
+			current = &kubevirtproviderv1alpha1.MigrationStatus{
+				UID:   state.MigrationUID,
+				Phase: migrationPhaseFromState(state),
+			}
+			machineScope.machineProviderStatus.Migration = current
+		}
+		if current.UID == state.MigrationUID {
+			if current.Name == "" {
+				current.Phase = migrationPhaseFromState(state)
+			}
+			current.SourceNode = state.SourceNode
+			current.TargetNode = state.TargetNode
+			current.StartTimestamp = state.StartTimestamp
+			current.EndTimestamp = state.EndTimestamp
+		}
+	}
+
+	m.recordMigrationEvent(previous, current, machineScope)
+	return nil
+}
+
+// startMigration creates a VirtualMachineInstanceMigration for a running vmi and removes the migrate annotation.
+// The annotation is kept until the vmi is running, so the migration starts once it is possible.
+func (m *manager) startMigration(vmi *kubevirtapiv1.VirtualMachineInstance, machineScope *machineScope) error {
+	if vmi == nil || vmi.Status.Phase != kubevirtapiv1.Running {
+		machineScope.logger().Info("VMI is not running, delaying the requested migration")
+		return nil
+	}
+	if current := machineScope.machineProviderStatus.Migration; current != nil && !isMigrationFinal(current.Phase) {
+		machineScope.logger().Info("VMI migration is in progress, delaying the requested migration", "migration", current.Name)
+		return nil
+	}
+
+	migration := &kubevirtapiv1.VirtualMachineInstanceMigration{
+		ObjectMeta: k8smetav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-migration-", vmi.Name),
+			Namespace:    vmi.Namespace,
+			Labels:       vmi.Labels,
+		},
+		Spec: kubevirtapiv1.VirtualMachineInstanceMigrationSpec{
+			VMIName: vmi.Name,
+		},
+	}
+	createdMigration, err := m.createInfraClusterVMIMigration(migration, machineScope)
+	if err != nil {
+		return fmt.Errorf("failed to create VMI migration: %w", err)
+	}
+	machineScope.logger().Info("created VMI migration", "migration", createdMigration.Name)
+
+	machineScope.machineProviderStatus.Migration = &kubevirtproviderv1alpha1.MigrationStatus{
+		Name:       createdMigration.Name,
+		UID:        createdMigration.UID,
+		Phase:      kubevirtapiv1.MigrationPending,
+		SourceNode: vmi.Status.NodeName,
+	}
+	delete(machineScope.machine.Annotations, migrateAnnotationKey)
+	return nil
+}
+
+func (m *manager) recordMigrationEvent(previous, current *kubevirtproviderv1alpha1.MigrationStatus, machineScope *machineScope) {
+	if current == nil || current.Phase == kubevirtapiv1.MigrationPhaseUnset {
+		return
+	}
+	if previous != nil && previous.UID == current.UID && previous.Phase == current.Phase {
+		return
+	}
+
+	eventType := corev1.EventTypeNormal
+	if current.Phase == kubevirtapiv1.MigrationFailed {
+		eventType = corev1.EventTypeWarning
+	}
+	m.eventRecorder.Eventf(machineScope.machine, eventType, "Migration"+string(current.Phase), "VMI migration %s %s, source node: %q, target node: %q",
+		current.UID, current.Phase, current.SourceNode, current.TargetNode)
+}
+
+func isMigrationFinal(phase kubevirtapiv1.VirtualMachineInstanceMigrationPhase) bool {
+	return phase == kubevirtapiv1.MigrationSucceeded || phase == kubevirtapiv1.MigrationFailed
+}
+
+func migrationPhaseFromState(state *kubevirtapiv1.VirtualMachineInstanceMigrationState) kubevirtapiv1.VirtualMachineInstanceMigrationPhase {
+	switch {
+	case state.Failed:
+		return kubevirtapiv1.MigrationFailed
+	case state.Completed:
+		return kubevirtapiv1.MigrationSucceeded
+	default:
+		return kubevirtapiv1.MigrationRunning
+	}
+}
+
+func (m *manager) createInfraClusterVMIMigration(migration *kubevirtapiv1.VirtualMachineInstanceMigration, machineScope *machineScope) (*kubevirtapiv1.VirtualMachineInstanceMigration, error) {
+	return machineScope.infraClusterClient.CreateVirtualMachineInstanceMigration(machineScope.ctx, migration.Namespace, migration)
+}
+
+func (m *manager) getInfraClusterVMIMigration(name, namespace string, machineScope *machineScope) (*kubevirtapiv1.VirtualMachineInstanceMigration, error) {
+	return machineScope.infraClusterClient.GetVirtualMachineInstanceMigration(machineScope.ctx, namespace, name, &k8smetav1.GetOptions{})
+}
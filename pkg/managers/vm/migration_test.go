@@ -0,0 +1,138 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	mockInfraClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster/mock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	"k8s.io/client-go/tools/record"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestSyncMigration(t *testing.T) {
+	cases := []struct {
+		name                string
+		annotate            bool
+		vmiPhase            kubevirtapiv1.VirtualMachineInstancePhase
+		vmiMigrationState   *kubevirtapiv1.VirtualMachineInstanceMigrationState
+		existingMigration   *kubevirtproviderv1alpha1.MigrationStatus
+		getMigrationPhase   kubevirtapiv1.VirtualMachineInstanceMigrationPhase
+		wantCreateMigration bool
+		wantAnnotation      bool
+		wantMigration       *kubevirtproviderv1alpha1.MigrationStatus
+	}{
+		{
+			name:                "Start a requested migration of a running VMI",
+			annotate:            true,
+			vmiPhase:            kubevirtapiv1.Running,
+			wantCreateMigration: true,
+			wantAnnotation:      false,
+			wantMigration: &kubevirtproviderv1alpha1.MigrationStatus{
+				Name:       "migration-test",
+				UID:        "migration-uid",
+				Phase:      kubevirtapiv1.MigrationPending,
+				SourceNode: "node-a",
+			},
+		},
+		{
+			name:           "Delay a requested migration of a VMI that is not running",
+			annotate:       true,
+			vmiPhase:       kubevirtapiv1.Scheduling,
+			wantAnnotation: true,
+		},
+		{
+			name:     "Report the progress of a requested migration",
+			vmiPhase: kubevirtapiv1.Running,
+			vmiMigrationState: &kubevirtapiv1.VirtualMachineInstanceMigrationState{
+				MigrationUID: "migration-uid",
+				SourceNode:   "node-a",
+				TargetNode:   "node-b",
+				Completed:    true,
+			},
+			existingMigration: &kubevirtproviderv1alpha1.MigrationStatus{
+				Name:  "migration-test",
+				UID:   "migration-uid",
+				Phase: kubevirtapiv1.MigrationRunning,
+			},
+			getMigrationPhase: kubevirtapiv1.MigrationSucceeded,
+			wantMigration: &kubevirtproviderv1alpha1.MigrationStatus{
+				Name:       "migration-test",
+				UID:        "migration-uid",
+				Phase:      kubevirtapiv1.MigrationSucceeded,
+				SourceNode: "node-a",
+				TargetNode: "node-b",
+			},
+		},
+		{
+			name:     "Report a migration started by the infra-cluster",
+			vmiPhase: kubevirtapiv1.Running,
+			vmiMigrationState: &kubevirtapiv1.VirtualMachineInstanceMigrationState{
+				MigrationUID: "drain-uid",
+				SourceNode:   "node-a",
+				TargetNode:   "node-c",
+			},
+			wantMigration: &kubevirtproviderv1alpha1.MigrationStatus{
+				UID:        "drain-uid",
+				Phase:      kubevirtapiv1.MigrationRunning,
+				SourceNode: "node-a",
+				TargetNode: "node-c",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			if tc.annotate {
+				machine.Annotations = map[string]string{migrateAnnotationKey: ""}
+			}
+
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+				return newMockInfraClusterClient, nil
+			}
+
+			machineScope, err := stubMachineScope(machine, newMockTenantClusterClient, infraClusterClientMockBuilder)
+			if err != nil {
+				t.Fatalf("Unable to build virtual machine with error: %v", err)
+			}
+			machineScope.vmNamespace = clusterNamespace
+			machineScope.machineProviderStatus.Migration = tc.existingMigration
+
+			vmi, _ := stubVmi(stubVirtualMachine(machineScope))
+			vmi.Status.Phase = tc.vmiPhase
+			vmi.Status.NodeName = "node-a"
+			vmi.Status.MigrationState = tc.vmiMigrationState
+
+			createdMigration := &kubevirtapiv1.VirtualMachineInstanceMigration{}
+			createdMigration.Name = "migration-test"
+			createdMigration.UID = "migration-uid"
+			createTimes := 0
+			if tc.wantCreateMigration {
+				createTimes = 1
+			}
+			newMockInfraClusterClient.EXPECT().CreateVirtualMachineInstanceMigration(gomock.Any(), vmi.Namespace, gomock.Any()).Return(createdMigration, nil).Times(createTimes)
+
+			gotMigration := &kubevirtapiv1.VirtualMachineInstanceMigration{}
+			gotMigration.Status.Phase = tc.getMigrationPhase
+			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstanceMigration(gomock.Any(), clusterNamespace, "migration-test", gomock.Any()).Return(gotMigration, nil).AnyTimes()
+
+			providerVMInstance := &manager{eventRecorder: record.NewFakeRecorder(10)}
+			err = providerVMInstance.syncMigration(vmi, machineScope)
+			assert.NilError(t, err)
+
+			_, hasAnnotation := machine.Annotations[migrateAnnotationKey]
+			assert.Equal(t, hasAnnotation, tc.wantAnnotation)
+			assert.DeepEqual(t, machineScope.machineProviderStatus.Migration, tc.wantMigration)
+		})
+	}
+}
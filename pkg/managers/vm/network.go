@@ -0,0 +1,228 @@
+package vm
+
+import (
+	"fmt"
+	"net"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// defaultPodNetworkIPv6CIDR is the default IPv6 network of KubeVirt masquerade
+const defaultPodNetworkIPv6CIDR = "fd10:0:2::/120"
+
+// addressFamilyConfig is the static configuration of an address family of a machine interface
+type addressFamilyConfig struct {
+	// disabled is set for a family missing in the IPFamilies of the machine
+	disabled   bool
+	addresses  []string
+	gateway    string
+	dnsServers []string
+}
+
+// interfaceConfig is the static configuration of a machine interface, rendered to the guest network configuration
+type interfaceConfig struct {
+	name string
+	ipv4 addressFamilyConfig
+	ipv6 addressFamilyConfig
+}
+
+// family returns the configuration of the address family of ip
+func (c *interfaceConfig) family(ip net.IP) *addressFamilyConfig {
+	if ip.To4() != nil {
+		return &c.ipv4
+	}
+	return &c.ipv6
+}
+
+// ipFamily returns the address family of ip
+func ipFamily(ip net.IP) corev1.IPFamily {
+	if ip.To4() != nil {
+		return corev1.IPv4Protocol
+	}
+	return corev1.IPv6Protocol
+}
+
+// getIPFamilies returns the validated IPFamilies of the machine, which are empty when the families aren't restricted
+func (s *machineScope) getIPFamilies() ([]corev1.IPFamily, error) {
+	families := s.machineProviderSpec.IPFamilies
+	if len(families) > 2 || (len(families) == 2 && families[0] == families[1]) {
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: IPFamilies can have each family once", s.machine.GetName())
+	}
+	for _, family := range families {
+		if family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: Value of IPFamilies, can be only: %v, %v", s.machine.GetName(),
+				corev1.IPv4Protocol, corev1.IPv6Protocol)
+		}
+	}
+	return families, nil
+}
+
+// hasIPFamily returns true if the addresses of family are enabled by the families
+func hasIPFamily(families []corev1.IPFamily, family corev1.IPFamily) bool {
+	if len(families) == 0 {
+		return true
+	}
+	for _, f := range families {
+		if f == family {
+			return true
+		}
+	}
+	return false
+}
+
+// getPodNetworkIPv6CIDR returns the pod IPv6 network of a machine with the IPv6 family, empty otherwise
+func (s *machineScope) getPodNetworkIPv6CIDR() (string, error) {
+	families, err := s.getIPFamilies()
+	if err != nil {
+		return "", err
+	}
+	if len(families) == 0 || !hasIPFamily(families, corev1.IPv6Protocol) {
+		if s.machineProviderSpec.PodNetworkIPv6CIDR != "" {
+			return "", machinecontroller.InvalidMachineConfiguration("%v: PodNetworkIPv6CIDR requires the %v family in IPFamilies",
+				s.machine.GetName(), corev1.IPv6Protocol)
+		}
+		return "", nil
+	}
+	cidr := s.machineProviderSpec.PodNetworkIPv6CIDR
+	if cidr == "" {
+		cidr = defaultPodNetworkIPv6CIDR
+	}
+	if ip, _, err := net.ParseCIDR(cidr); err != nil || ip.To4() != nil {
+		return "", machinecontroller.InvalidMachineConfiguration("%v: PodNetworkIPv6CIDR %q isn't an IPv6 CIDR", s.machine.GetName(), cidr)
+	}
+	return cidr, nil
+}
+
+// hasStaticNetworkConfig returns true if the guest network of the machine is configured by the user data
+func (s *machineScope) hasStaticNetworkConfig() bool {
+	return s.machineProviderSpec.NetworkConfig != nil || len(s.machineProviderSpec.IPPools) > 0
+}
+
+// getDefaultInterface returns the guest interface of the NetworkConfig and of the IPPools without interface
+func (s *machineScope) getDefaultInterface() string {
+	if networkConfig := s.machineProviderSpec.NetworkConfig; networkConfig != nil && networkConfig.Interface != "" {
+		return networkConfig.Interface
+	}
+	return defaultNetworkInterface
+}
+
+// buildInterfaceConfigs returns the static configuration of the machine interfaces: the NetworkConfig,
+// with the addresses allocated from the IPPools of the provider status.
+// The address families missing in the IPFamilies of the machine are disabled.
+func (s *machineScope) buildInterfaceConfigs() ([]interfaceConfig, error) {
+	families, err := s.getIPFamilies()
+	if err != nil {
+		return nil, err
+	}
+
+	var interfaces []interfaceConfig
+	if networkConfig := s.machineProviderSpec.NetworkConfig; networkConfig != nil {
+		iface, err := newInterfaceConfig(s.getDefaultInterface(), networkConfig, families)
+		if err != nil {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: NetworkConfig: %v", s.machine.GetName(), err)
+		}
+		interfaces = append(interfaces, iface)
+	}
+
+	for _, ipAddress := range s.machineProviderStatus.IPAddresses {
+		i := 0
+		for i < len(interfaces) && interfaces[i].name != ipAddress.Interface {
+			i++
+		}
+		if i == len(interfaces) {
+			interfaces = append(interfaces, newEmptyInterfaceConfig(ipAddress.Interface, families))
+		}
+		ip, _, err := net.ParseCIDR(ipAddress.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q of pool %s: %w", ipAddress.Address, ipAddress.Pool, err)
+		}
+		family := interfaces[i].family(ip)
+		family.addresses = append(family.addresses, ipAddress.Address)
+		if family.gateway == "" {
+			family.gateway = ipAddress.Gateway
+		}
+		for _, server := range ipAddress.DNSServers {
+			if !containsString(family.dnsServers, server) {
+				family.dnsServers = append(family.dnsServers, server)
+			}
+		}
+	}
+	return interfaces, nil
+}
+
+func newEmptyInterfaceConfig(name string, families []corev1.IPFamily) interfaceConfig {
+	return interfaceConfig{
+		name: name,
+		ipv4: addressFamilyConfig{disabled: !hasIPFamily(families, corev1.IPv4Protocol)},
+		ipv6: addressFamilyConfig{disabled: !hasIPFamily(families, corev1.IPv6Protocol)},
+	}
+}
+
+// newInterfaceConfig validates the network config against the families and splits it by address family
+func newInterfaceConfig(name string, networkConfig *kubevirtproviderv1alpha1.NetworkConfig, families []corev1.IPFamily) (interfaceConfig, error) {
+	iface := newEmptyInterfaceConfig(name, families)
+	parseIP := func(kind, value string, parse func(string) (net.IP, error)) (*addressFamilyConfig, error) {
+		ip, err := parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", kind, value, err)
+		}
+		if !hasIPFamily(families, ipFamily(ip)) {
+			return nil, fmt.Errorf("%s %q isn't of IPFamilies %v", kind, value, families)
+		}
+		return iface.family(ip), nil
+	}
+	parseAddress := func(value string) (net.IP, error) {
+		ip, _, err := net.ParseCIDR(value)
+		return ip, err
+	}
+	parsePlainIP := func(value string) (net.IP, error) {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("not an IP address")
+		}
+		return ip, nil
+	}
+
+	for _, address := range networkConfig.Addresses {
+		family, err := parseIP("address", address, parseAddress)
+		if err != nil {
+			return iface, err
+		}
+		family.addresses = append(family.addresses, address)
+	}
+
+	var gateways []string
+	if networkConfig.Gateway != "" {
+		gateways = append(gateways, networkConfig.Gateway)
+	}
+	for _, gateway := range append(gateways, networkConfig.Gateways...) {
+		family, err := parseIP("gateway", gateway, parsePlainIP)
+		if err != nil {
+			return iface, err
+		}
+		if family.gateway != "" {
+			return iface, fmt.Errorf("gateways %q and %q are of the same family", family.gateway, gateway)
+		}
+		family.gateway = gateway
+	}
+
+	for _, server := range networkConfig.DNSServers {
+		family, err := parseIP("DNS server", server, parsePlainIP)
+		if err != nil {
+			return iface, err
+		}
+		family.dnsServers = append(family.dnsServers, server)
+	}
+	return iface, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
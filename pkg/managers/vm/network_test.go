@@ -0,0 +1,130 @@
+package vm
+
+import (
+	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildInterfaceConfigsFamilies(t *testing.T) {
+	cases := []struct {
+		name          string
+		families      []corev1.IPFamily
+		networkConfig *kubevirtproviderv1alpha1.NetworkConfig
+		wantErr       string
+	}{
+		{
+			name:     "Dual stack",
+			families: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{
+				Addresses: []string{"10.0.0.5/24", "fd00::5/64"},
+				Gateway:   "10.0.0.1",
+				Gateways:  []string{"fd00::1"},
+			},
+		},
+		{
+			name:          "Fail on an address of another family",
+			families:      []corev1.IPFamily{corev1.IPv6Protocol},
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{Addresses: []string{"10.0.0.5/24"}},
+			wantErr:       `machine-test: NetworkConfig: address "10.0.0.5/24" isn't of IPFamilies [IPv6]`,
+		},
+		{
+			name:          "Fail on two gateways of a family",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{Gateway: "10.0.0.1", Gateways: []string{"10.0.0.2"}},
+			wantErr:       `machine-test: NetworkConfig: gateways "10.0.0.1" and "10.0.0.2" are of the same family`,
+		},
+		{
+			name:          "Fail on an invalid DNS server",
+			networkConfig: &kubevirtproviderv1alpha1.NetworkConfig{DNSServers: []string{"dns"}},
+			wantErr:       `machine-test: NetworkConfig: invalid DNS server "dns": not an IP address`,
+		},
+		{
+			name:     "Fail on a repeated family",
+			families: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv4Protocol},
+			wantErr:  "machine-test: IPFamilies can have each family once",
+		},
+		{
+			name:     "Fail on an unknown family",
+			families: []corev1.IPFamily{"IPv5"},
+			wantErr:  "machine-test: Value of IPFamilies, can be only: IPv4, IPv6",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine: initializeMachine(t, nil, "", false),
+				machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+					IPFamilies:    tc.families,
+					NetworkConfig: tc.networkConfig,
+				},
+				machineProviderStatus: &kubevirtproviderv1alpha1.KubevirtMachineProviderStatus{},
+			}
+			_, err := machineScope.buildInterfaceConfigs()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestGetPodNetworkIPv6CIDR(t *testing.T) {
+	cases := []struct {
+		name     string
+		families []corev1.IPFamily
+		cidr     string
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "Without families",
+		},
+		{
+			name:     "Single stack IPv4",
+			families: []corev1.IPFamily{corev1.IPv4Protocol},
+		},
+		{
+			name:     "Dual stack with the default IPv6 network",
+			families: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+			want:     "fd10:0:2::/120",
+		},
+		{
+			name:     "Single stack IPv6 with a network",
+			families: []corev1.IPFamily{corev1.IPv6Protocol},
+			cidr:     "fd20::/120",
+			want:     "fd20::/120",
+		},
+		{
+			name:     "Fail on an IPv4 network",
+			families: []corev1.IPFamily{corev1.IPv6Protocol},
+			cidr:     "10.0.2.0/24",
+			wantErr:  `machine-test: PodNetworkIPv6CIDR "10.0.2.0/24" isn't an IPv6 CIDR`,
+		},
+		{
+			name:    "Fail on a network without the IPv6 family",
+			cidr:    "fd20::/120",
+			wantErr: "machine-test: PodNetworkIPv6CIDR requires the IPv6 family in IPFamilies",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine: initializeMachine(t, nil, "", false),
+				machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+					IPFamilies:         tc.families,
+					PodNetworkIPv6CIDR: tc.cidr,
+				},
+			}
+			cidr, err := machineScope.getPodNetworkIPv6CIDR()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, cidr, tc.want)
+		})
+	}
+}
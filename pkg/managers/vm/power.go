@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// Values of the power state annotation
+const (
+	powerStateRunning = "Running"
+	powerStateStopped = "Stopped"
+	// powerStatePaused starts the vmi paused before it boots, to debug the boot, and pauses a running vmi
+	powerStatePaused = "Paused"
+)
+
+// syncPowerState drives the VM towards the power state requested by the machine power state annotation.
+// Machines without the annotation are left to the VM run strategy.
+func (m *manager) syncPowerState(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance, machineScope *machineScope) error {
+	powerState, ok := machineScope.machine.GetAnnotations()[powerStateAnnotationKey]
+	if !ok || vm == nil {
+		return nil
+	}
+	if len(vm.Status.StateChangeRequests) > 0 {
+		machineScope.logger().Info("VM state change is in progress")
+		return nil
+	}
+
+	running := isVMIActive(vmi)
+	paused := running && isVMIPaused(vmi)
+
+	switch powerState {
+	case powerStateStopped:
+		if !running {
+			return nil
+		}
+		if err := machineScope.infraClusterClient.StopVirtualMachine(machineScope.ctx, vm.Namespace, vm.Name); err != nil {
+			return fmt.Errorf("failed to stop VM: %w", err)
+		}
+		m.recordPowerEvent(machineScope, "Stopped", vm.Name)
+	case powerStateRunning, powerStatePaused:
+		if !running {
+			if err := machineScope.infraClusterClient.StartVirtualMachine(machineScope.ctx, vm.Namespace, vm.Name); err != nil {
+				return fmt.Errorf("failed to start VM: %w", err)
+			}
+			m.recordPowerEvent(machineScope, "Started", vm.Name)
+			return nil
+		}
+		// A vmi started with the Paused power state is paused by its start strategy before it boots
+		if vmi.Status.Phase != kubevirtapiv1.Running {
+			return nil
+		}
+		switch {
+		case powerState == powerStateRunning && paused:
+			if err := machineScope.infraClusterClient.UnpauseVirtualMachineInstance(machineScope.ctx, vmi.Namespace, vmi.Name); err != nil {
+				return fmt.Errorf("failed to unpause VMI: %w", err)
+			}
+			m.recordPowerEvent(machineScope, "Unpaused", vm.Name)
+		case powerState == powerStatePaused && !paused:
+			// The start strategy only applies to the next boot, a vmi which already booted is paused by its subresource
+			if err := machineScope.infraClusterClient.PauseVirtualMachineInstance(machineScope.ctx, vmi.Namespace, vmi.Name); err != nil {
+				return fmt.Errorf("failed to pause VMI: %w", err)
+			}
+			m.recordPowerEvent(machineScope, "Paused", vm.Name)
+		}
+	}
+	return nil
+}
+
+// getStartStrategy returns the start strategy of the vmi, which is Paused for machines with the Paused power state
+func (s *machineScope) getStartStrategy() *kubevirtapiv1.StartStrategy {
+	if s.machine.GetAnnotations()[powerStateAnnotationKey] != powerStatePaused {
+		return nil
+	}
+	startStrategy := kubevirtapiv1.StartStrategyPaused
+	return &startStrategy
+}
+
+func (m *manager) recordPowerEvent(machineScope *machineScope, action, vmName string) {
+	machineScope.logger().Info("changed VM power state", "action", action, "vm", vmName)
+	m.eventRecorder.Eventf(machineScope.machine, corev1.EventTypeNormal, action, "%s VM %s", action, vmName)
+}
+
+// isVMIActive returns true if the vmi exists and didn't reach a final phase
+func isVMIActive(vmi *kubevirtapiv1.VirtualMachineInstance) bool {
+	if vmi == nil || vmi.DeletionTimestamp != nil {
+		return false
+	}
+	return vmi.Status.Phase != kubevirtapiv1.Succeeded && vmi.Status.Phase != kubevirtapiv1.Failed
+}
+
+func isVMIPaused(vmi *kubevirtapiv1.VirtualMachineInstance) bool {
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubevirtapiv1.VirtualMachineInstancePaused {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
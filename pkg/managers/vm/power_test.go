@@ -0,0 +1,257 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	mockInfraClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster/mock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestSyncPowerState(t *testing.T) {
+	cases := []struct {
+		name               string
+		powerState         *string
+		vmiPhase           kubevirtapiv1.VirtualMachineInstancePhase
+		vmiPaused          bool
+		noVMI              bool
+		stateChangePending bool
+		wantCall           string
+	}{
+		{
+			name:     "Leave a machine without power state to the run strategy",
+			noVMI:    true,
+			wantCall: "",
+		},
+		{
+			name:       "Stop a running VM",
+			powerState: stringPtr(powerStateStopped),
+			vmiPhase:   kubevirtapiv1.Running,
+			wantCall:   "Stop",
+		},
+		{
+			name:       "Keep a stopped VM stopped",
+			powerState: stringPtr(powerStateStopped),
+			noVMI:      true,
+			wantCall:   "",
+		},
+		{
+			name:       "Start a stopped VM",
+			powerState: stringPtr(powerStateRunning),
+			noVMI:      true,
+			wantCall:   "Start",
+		},
+		{
+			name:       "Start a VM whose VMI failed",
+			powerState: stringPtr(powerStateRunning),
+			vmiPhase:   kubevirtapiv1.Failed,
+			wantCall:   "Start",
+		},
+		{
+			name:       "Start a stopped VM paused",
+			powerState: stringPtr(powerStatePaused),
+			noVMI:      true,
+			wantCall:   "Start",
+		},
+		{
+			name:       "Leave a VMI started paused to its start strategy",
+			powerState: stringPtr(powerStatePaused),
+			vmiPhase:   kubevirtapiv1.Running,
+			vmiPaused:  true,
+			wantCall:   "",
+		},
+		{
+			name:       "Pause a running VMI",
+			powerState: stringPtr(powerStatePaused),
+			vmiPhase:   kubevirtapiv1.Running,
+			wantCall:   "Pause",
+		},
+		{
+			name:       "Wait for a VMI started paused to run before pausing it",
+			powerState: stringPtr(powerStatePaused),
+			vmiPhase:   kubevirtapiv1.Scheduled,
+			wantCall:   "",
+		},
+		{
+			name:       "Unpause a paused VMI",
+			powerState: stringPtr(powerStateRunning),
+			vmiPhase:   kubevirtapiv1.Running,
+			vmiPaused:  true,
+			wantCall:   "Unpause",
+		},
+		{
+			name:               "Wait for a pending state change",
+			powerState:         stringPtr(powerStateStopped),
+			vmiPhase:           kubevirtapiv1.Running,
+			stateChangePending: true,
+			wantCall:           "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			if tc.powerState != nil {
+				machine.Annotations = map[string]string{powerStateAnnotationKey: *tc.powerState}
+			}
+
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+				return newMockInfraClusterClient, nil
+			}
+
+			machineScope, err := stubMachineScope(machine, newMockTenantClusterClient, infraClusterClientMockBuilder)
+			if err != nil {
+				t.Fatalf("Unable to build virtual machine with error: %v", err)
+			}
+
+			virtualMachine := stubVirtualMachine(machineScope)
+			if tc.stateChangePending {
+				virtualMachine.Status.StateChangeRequests = []kubevirtapiv1.VirtualMachineStateChangeRequest{{Action: kubevirtapiv1.StopRequest}}
+			}
+			var vmi *kubevirtapiv1.VirtualMachineInstance
+			if !tc.noVMI {
+				vmi, _ = stubVmi(virtualMachine)
+				vmi.Status.Phase = tc.vmiPhase
+				if tc.vmiPaused {
+					vmi.Status.Conditions = []kubevirtapiv1.VirtualMachineInstanceCondition{
+						{Type: kubevirtapiv1.VirtualMachineInstancePaused, Status: corev1.ConditionTrue},
+					}
+				}
+			}
+
+			times := func(call string) int {
+				if tc.wantCall == call {
+					return 1
+				}
+				return 0
+			}
+			newMockInfraClusterClient.EXPECT().StopVirtualMachine(gomock.Any(), virtualMachine.Namespace, virtualMachine.Name).Return(nil).Times(times("Stop"))
+			newMockInfraClusterClient.EXPECT().StartVirtualMachine(gomock.Any(), virtualMachine.Namespace, virtualMachine.Name).Return(nil).Times(times("Start"))
+			newMockInfraClusterClient.EXPECT().PauseVirtualMachineInstance(gomock.Any(), virtualMachine.Namespace, virtualMachine.Name).Return(nil).Times(times("Pause"))
+			newMockInfraClusterClient.EXPECT().UnpauseVirtualMachineInstance(gomock.Any(), virtualMachine.Namespace, virtualMachine.Name).Return(nil).Times(times("Unpause"))
+
+			providerVMInstance := &manager{eventRecorder: record.NewFakeRecorder(10)}
+			err = providerVMInstance.syncPowerState(virtualMachine, vmi, machineScope)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestGetRunStrategy(t *testing.T) {
+	cases := []struct {
+		name            string
+		runStrategy     string
+		powerState      *string
+		wantRunStrategy kubevirtapiv1.VirtualMachineRunStrategy
+		wantErr         bool
+	}{
+		{
+			name:            "Default to the Always run strategy",
+			wantRunStrategy: kubevirtapiv1.RunStrategyAlways,
+		},
+		{
+			name:            "Use the provider spec run strategy",
+			runStrategy:     string(kubevirtapiv1.RunStrategyRerunOnFailure),
+			wantRunStrategy: kubevirtapiv1.RunStrategyRerunOnFailure,
+		},
+		{
+			name:        "Reject an unknown run strategy",
+			runStrategy: "Sometimes",
+			wantErr:     true,
+		},
+		{
+			name:            "Run manually a machine with a power state",
+			runStrategy:     string(kubevirtapiv1.RunStrategyAlways),
+			powerState:      stringPtr(powerStateStopped),
+			wantRunStrategy: kubevirtapiv1.RunStrategyManual,
+		},
+		{
+			name:       "Reject an unknown power state",
+			powerState: stringPtr("Sleeping"),
+			wantErr:    true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := initializeMachine(t, nil, "", false)
+			if tc.powerState != nil {
+				machine.Annotations = map[string]string{powerStateAnnotationKey: *tc.powerState}
+			}
+			machineScope, err := stubMachineScope(machine, nil, func(context.Context, tenantcluster.Client, string, string) (infracluster.Client, error) {
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatalf("Unable to build virtual machine with error: %v", err)
+			}
+			machineScope.machineProviderSpec.RunStrategy = tc.runStrategy
+
+			runStrategy, err := machineScope.getRunStrategy()
+			if tc.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, runStrategy, tc.wantRunStrategy)
+		})
+	}
+}
+
+func TestGetStartStrategy(t *testing.T) {
+	cases := []struct {
+		name              string
+		powerState        *string
+		wantStartStrategy *kubevirtapiv1.StartStrategy
+	}{
+		{
+			name:              "Start a machine without power state unpaused",
+			wantStartStrategy: nil,
+		},
+		{
+			name:              "Start a running machine unpaused",
+			powerState:        stringPtr(powerStateRunning),
+			wantStartStrategy: nil,
+		},
+		{
+			name:              "Start a paused machine paused",
+			powerState:        stringPtr(powerStatePaused),
+			wantStartStrategy: startStrategyPtr(kubevirtapiv1.StartStrategyPaused),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := initializeMachine(t, nil, "", false)
+			if tc.powerState != nil {
+				machine.Annotations = map[string]string{powerStateAnnotationKey: *tc.powerState}
+			}
+			machineScope, err := stubMachineScope(machine, nil, func(context.Context, tenantcluster.Client, string, string) (infracluster.Client, error) {
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatalf("Unable to build virtual machine with error: %v", err)
+			}
+
+			vmiTemplate, err := machineScope.buildVMITemplate()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, vmiTemplate.Spec.StartStrategy, tc.wantStartStrategy)
+		})
+	}
+}
+
+func startStrategyPtr(startStrategy kubevirtapiv1.StartStrategy) *kubevirtapiv1.StartStrategy {
+	return &startStrategy
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,79 @@
+package vm
+
+import (
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	// kubeletPort is probed by the probes without handler
+	kubeletPort = 10250
+	// defaultLivenessProbeInitialDelaySeconds leaves a node the time to install its OS, reboot and start its kubelet
+	defaultLivenessProbeInitialDelaySeconds = 900
+)
+
+// buildVMIProbes sets the readiness and liveness probes of the machine on the VMI spec
+func (s *machineScope) buildVMIProbes(spec *kubevirtapiv1.VirtualMachineInstanceSpec) error {
+	readinessProbe, err := s.buildProbe("ReadinessProbe", s.machineProviderSpec.ReadinessProbe)
+	if err != nil {
+		return err
+	}
+	livenessProbe, err := s.buildProbe("LivenessProbe", s.machineProviderSpec.LivenessProbe)
+	if err != nil {
+		return err
+	}
+	spec.ReadinessProbe = readinessProbe
+	spec.LivenessProbe = livenessProbe
+	return nil
+}
+
+// buildProbe returns the VMI probe of a machine probe
+func (s *machineScope) buildProbe(field string, probe *kubevirtproviderv1alpha1.Probe) (*kubevirtapiv1.Probe, error) {
+	if probe == nil {
+		return nil, nil
+	}
+	vmiProbe := &kubevirtapiv1.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	if field == "LivenessProbe" {
+		if vmiProbe.InitialDelaySeconds == 0 {
+			vmiProbe.InitialDelaySeconds = defaultLivenessProbeInitialDelaySeconds
+		}
+		if vmiProbe.SuccessThreshold > 1 {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: LivenessProbe successThreshold can be only 1", s.machine.GetName())
+		}
+	}
+
+	handlers := 0
+	if probe.HTTPGet != nil {
+		handlers++
+		vmiProbe.HTTPGet = probe.HTTPGet.DeepCopy()
+	}
+	if probe.TCPSocket != nil {
+		handlers++
+		vmiProbe.TCPSocket = probe.TCPSocket.DeepCopy()
+	}
+	if probe.Exec != nil {
+		handlers++
+		vmiProbe.Exec = probe.Exec.DeepCopy()
+	}
+	if probe.GuestAgentPing {
+		handlers++
+		vmiProbe.GuestAgentPing = &kubevirtapiv1.GuestAgentPing{}
+	}
+	switch handlers {
+	case 0:
+		vmiProbe.TCPSocket = &corev1.TCPSocketAction{Port: intstr.FromInt(kubeletPort)}
+	case 1:
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: %s can have only one of httpGet, tcpSocket, exec or guestAgentPing", s.machine.GetName(), field)
+	}
+	return vmiProbe, nil
+}
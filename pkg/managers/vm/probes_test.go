@@ -0,0 +1,88 @@
+package vm
+
+import (
+	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestBuildVMIProbes(t *testing.T) {
+	kubeletHandler := kubevirtapiv1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(kubeletPort)}}
+	cases := []struct {
+		name           string
+		readinessProbe *kubevirtproviderv1alpha1.Probe
+		livenessProbe  *kubevirtproviderv1alpha1.Probe
+		wantReadiness  *kubevirtapiv1.Probe
+		wantLiveness   *kubevirtapiv1.Probe
+		wantErr        string
+	}{
+		{
+			name: "Without probes",
+		},
+		{
+			name:           "Probe the kubelet port by default",
+			readinessProbe: &kubevirtproviderv1alpha1.Probe{PeriodSeconds: 5},
+			livenessProbe:  &kubevirtproviderv1alpha1.Probe{FailureThreshold: 6},
+			wantReadiness:  &kubevirtapiv1.Probe{Handler: kubeletHandler, PeriodSeconds: 5},
+			wantLiveness:   &kubevirtapiv1.Probe{Handler: kubeletHandler, InitialDelaySeconds: defaultLivenessProbeInitialDelaySeconds, FailureThreshold: 6},
+		},
+		{
+			name: "HTTP readiness probe",
+			readinessProbe: &kubevirtproviderv1alpha1.Probe{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(10256)},
+			},
+			wantReadiness: &kubevirtapiv1.Probe{
+				Handler: kubevirtapiv1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(10256)}},
+			},
+		},
+		{
+			name:           "Guest agent probes",
+			readinessProbe: &kubevirtproviderv1alpha1.Probe{Exec: &corev1.ExecAction{Command: []string{"systemctl", "is-active", "kubelet"}}},
+			livenessProbe:  &kubevirtproviderv1alpha1.Probe{GuestAgentPing: true, InitialDelaySeconds: 60},
+			wantReadiness: &kubevirtapiv1.Probe{
+				Handler: kubevirtapiv1.Handler{Exec: &corev1.ExecAction{Command: []string{"systemctl", "is-active", "kubelet"}}},
+			},
+			wantLiveness: &kubevirtapiv1.Probe{
+				Handler:             kubevirtapiv1.Handler{GuestAgentPing: &kubevirtapiv1.GuestAgentPing{}},
+				InitialDelaySeconds: 60,
+			},
+		},
+		{
+			name: "Fail on two handlers",
+			readinessProbe: &kubevirtproviderv1alpha1.Probe{
+				TCPSocket:      &corev1.TCPSocketAction{Port: intstr.FromInt(22)},
+				GuestAgentPing: true,
+			},
+			wantErr: "machine-test: ReadinessProbe can have only one of httpGet, tcpSocket, exec or guestAgentPing",
+		},
+		{
+			name:          "Fail on a liveness success threshold",
+			livenessProbe: &kubevirtproviderv1alpha1.Probe{SuccessThreshold: 2},
+			wantErr:       "machine-test: LivenessProbe successThreshold can be only 1",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine: initializeMachine(t, nil, "", false),
+				machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+					ReadinessProbe: tc.readinessProbe,
+					LivenessProbe:  tc.livenessProbe,
+				},
+			}
+			spec := kubevirtapiv1.VirtualMachineInstanceSpec{}
+			err := machineScope.buildVMIProbes(&spec)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, spec.ReadinessProbe, tc.wantReadiness)
+			assert.DeepEqual(t, spec.LivenessProbe, tc.wantLiveness)
+		})
+	}
+}
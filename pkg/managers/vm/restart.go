@@ -0,0 +1,133 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// syncRestart detects resources and sizing changes of the VM template which are not applied to the running vmi yet,
+// and restarts the VM according to the machine RestartPolicy.
+// A machine annotated with a restart request is restarted regardless of the policy.
+// A pending restart is reported by the RestartRequired condition of the provider status.
+func (m *manager) syncRestart(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance, machineScope *machineScope) error {
+	if vm == nil || vmi == nil || vmi.Status.Phase != kubevirtapiv1.Running || vmi.DeletionTimestamp != nil {
+		return nil
+	}
+
+	changedResources := changedVMIResources(vm, vmi)
+	_, restartRequested := machineScope.machine.GetAnnotations()[restartAnnotationKey]
+	if len(changedResources) == 0 && !restartRequested {
+		m.setRestartRequiredCondition(machineScope, corev1.ConditionFalse, "VMIUpToDate", "VMI matches the VM template")
+		return nil
+	}
+
+	if !restartRequested && machineScope.machineProviderSpec.RestartPolicy != kubevirtproviderv1alpha1.RestartPolicyImmediate {
+		message := fmt.Sprintf("VMI %s differ from the VM template, restart the machine to apply them", strings.Join(changedResources, ", "))
+		if machineScope.machineProviderSpec.RestartPolicy == kubevirtproviderv1alpha1.RestartPolicyManual {
+			message = fmt.Sprintf("VMI %s differ from the VM template, annotate the machine with %s to apply them", strings.Join(changedResources, ", "), restartAnnotationKey)
+		}
+		if m.setRestartRequiredCondition(machineScope, corev1.ConditionTrue, "RestartPending", message) {
+			m.eventRecorder.Eventf(machineScope.machine, corev1.EventTypeNormal, "RestartPending", "%s", message)
+		}
+		return nil
+	}
+
+	if err := m.restartInfraClusterVM(vm.Name, vm.Namespace, machineScope); err != nil {
+		return fmt.Errorf("failed to restart VM: %w", err)
+	}
+	delete(machineScope.machine.Annotations, restartAnnotationKey)
+	if len(changedResources) == 0 {
+		machineScope.logger().Info("restarted VM on request")
+		m.eventRecorder.Eventf(machineScope.machine, corev1.EventTypeNormal, "Restarted", "Restarted VM %s", vm.Name)
+		return nil
+	}
+	machineScope.logger().Info("restarted VM to apply changes", "resources", changedResources)
+	m.setRestartRequiredCondition(machineScope, corev1.ConditionTrue, "RestartInProgress", fmt.Sprintf("VM restarted to apply %s changes", strings.Join(changedResources, ", ")))
+	m.eventRecorder.Eventf(machineScope.machine, corev1.EventTypeNormal, "Restarted", "Restarted VM %s to apply %s changes", vm.Name, strings.Join(changedResources, ", "))
+	return nil
+}
+
+// setRestartRequiredCondition sets the RestartRequired condition of the provider status,
+// and returns true when the condition status was changed
+func (m *manager) setRestartRequiredCondition(machineScope *machineScope, status corev1.ConditionStatus, reason, message string) bool {
+	conditions := machineScope.machineProviderStatus.Conditions
+	changed := true
+	if existing := findProviderCondition(conditions, restartRequiredCondition); existing != nil {
+		changed = existing.Status != status
+	}
+	machineScope.machineProviderStatus.Conditions = setKubevirtMachineProviderCondition(kubevirtapiv1.VirtualMachineCondition{
+		Type:    restartRequiredCondition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}, conditions)
+	return changed
+}
+
+// changedVMIResources returns the names of the resource requests and limits, and of the CPU and memory sizing of
+// the VM template which differ from the running vmi. A VM which the infra-cluster reports with a RestartRequired
+// condition has a changed spec, also when the changes are not among those compared.
+func changedVMIResources(vm *kubevirtapiv1.VirtualMachine, vmi *kubevirtapiv1.VirtualMachineInstance) []string {
+	if vm.Spec.Template == nil {
+		return nil
+	}
+	desired := vm.Spec.Template.Spec.Domain
+	running := vmi.Spec.Domain
+
+	// The infra-cluster defaults the vmi fields which the template doesn't set, only the fields it sets are compared
+	var changed []string
+	changed = append(changed, changedResourceList("", desired.Resources.Requests, running.Resources.Requests)...)
+	changed = append(changed, changedResourceList("limits.", desired.Resources.Limits, running.Resources.Limits)...)
+	if desired.CPU != nil {
+		runningCPU := running.CPU
+		if runningCPU == nil {
+			runningCPU = &kubevirtapiv1.CPU{}
+		}
+		if (desired.CPU.Cores != 0 && desired.CPU.Cores != runningCPU.Cores) ||
+			(desired.CPU.Sockets != 0 && desired.CPU.Sockets != runningCPU.Sockets) ||
+			(desired.CPU.Threads != 0 && desired.CPU.Threads != runningCPU.Threads) {
+			changed = append(changed, "cpu topology")
+		}
+	}
+	if desired.Memory != nil && desired.Memory.Guest != nil {
+		if running.Memory == nil || running.Memory.Guest == nil || running.Memory.Guest.Cmp(*desired.Memory.Guest) != 0 {
+			changed = append(changed, "guest memory")
+		}
+	}
+	sort.Strings(changed)
+	if len(changed) == 0 && vmRestartRequired(vm) {
+		changed = append(changed, "spec")
+	}
+	return changed
+}
+
+// changedResourceList returns the names, prefixed with prefix, of the desired resources which differ from the running ones
+func changedResourceList(prefix string, desired, running corev1.ResourceList) []string {
+	var changed []string
+	for name, quantity := range desired {
+		if runningQuantity, ok := running[name]; !ok || runningQuantity.Cmp(quantity) != 0 {
+			changed = append(changed, prefix+string(name))
+		}
+	}
+	return changed
+}
+
+// vmRestartRequired returns whether the infra-cluster reports that the VM template changes only apply once the VM
+// is restarted
+func vmRestartRequired(vm *kubevirtapiv1.VirtualMachine) bool {
+	for _, condition := range vm.Status.Conditions {
+		if condition.Type == restartRequiredCondition && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *manager) restartInfraClusterVM(vmName, vmNamespace string, machineScope *machineScope) error {
+	return machineScope.infraClusterClient.RestartVirtualMachine(machineScope.ctx, vmNamespace, vmName)
+}
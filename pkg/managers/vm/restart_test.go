@@ -0,0 +1,200 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	mockInfraClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster/mock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestSyncRestart(t *testing.T) {
+	cases := []struct {
+		name           string
+		restartPolicy  string
+		annotate       bool
+		runningMemory  string
+		wantRestart    bool
+		wantAnnotation bool
+		wantCondition  corev1.ConditionStatus
+		wantReason     string
+	}{
+		{
+			name:          "VMI matches the VM template",
+			restartPolicy: kubevirtproviderv1alpha1.RestartPolicyImmediate,
+			runningMemory: defaultRequestedMemory,
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    "VMIUpToDate",
+		},
+		{
+			name:          "Report a pending restart with the Never policy",
+			restartPolicy: "",
+			runningMemory: "1024M",
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "RestartPending",
+		},
+		{
+			name:          "Wait for the restart annotation with the Manual policy",
+			restartPolicy: kubevirtproviderv1alpha1.RestartPolicyManual,
+			runningMemory: "1024M",
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "RestartPending",
+		},
+		{
+			name:          "Restart on the restart annotation with the Manual policy",
+			restartPolicy: kubevirtproviderv1alpha1.RestartPolicyManual,
+			annotate:      true,
+			runningMemory: "1024M",
+			wantRestart:   true,
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "RestartInProgress",
+		},
+		{
+			name:          "Restart on the restart annotation without pending changes",
+			restartPolicy: kubevirtproviderv1alpha1.RestartPolicyNever,
+			annotate:      true,
+			runningMemory: defaultRequestedMemory,
+			wantRestart:   true,
+		},
+		{
+			name:          "Restart immediately with the Immediate policy",
+			restartPolicy: kubevirtproviderv1alpha1.RestartPolicyImmediate,
+			runningMemory: "1024M",
+			wantRestart:   true,
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "RestartInProgress",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			if tc.annotate {
+				machine.Annotations = map[string]string{restartAnnotationKey: ""}
+			}
+
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+				return newMockInfraClusterClient, nil
+			}
+
+			machineScope, err := stubMachineScope(machine, newMockTenantClusterClient, infraClusterClientMockBuilder)
+			if err != nil {
+				t.Fatalf("Unable to build virtual machine with error: %v", err)
+			}
+			machineScope.machineProviderSpec.RestartPolicy = tc.restartPolicy
+
+			virtualMachine := stubVirtualMachine(machineScope)
+			vmi, _ := stubVmi(stubVirtualMachine(machineScope))
+			vmi.Status.Phase = kubevirtapiv1.Running
+			vmi.Spec.Domain.Resources.Requests = corev1.ResourceList{
+				corev1.ResourceMemory: apiresource.MustParse(tc.runningMemory),
+			}
+
+			restartTimes := 0
+			if tc.wantRestart {
+				restartTimes = 1
+			}
+			newMockInfraClusterClient.EXPECT().RestartVirtualMachine(gomock.Any(), virtualMachine.Namespace, virtualMachine.Name).Return(nil).Times(restartTimes)
+
+			providerVMInstance := &manager{eventRecorder: record.NewFakeRecorder(10)}
+			err = providerVMInstance.syncRestart(virtualMachine, vmi, machineScope)
+			assert.NilError(t, err)
+
+			_, hasAnnotation := machine.Annotations[restartAnnotationKey]
+			assert.Equal(t, hasAnnotation, tc.wantAnnotation)
+			condition := findProviderCondition(machineScope.machineProviderStatus.Conditions, restartRequiredCondition)
+			if tc.wantReason == "" {
+				assert.Assert(t, condition == nil)
+				return
+			}
+			assert.Assert(t, condition != nil)
+			assert.Equal(t, condition.Status, tc.wantCondition)
+			assert.Equal(t, condition.Reason, tc.wantReason)
+		})
+	}
+}
+
+func TestChangedVMIResources(t *testing.T) {
+	guestMemory := apiresource.MustParse("2Gi")
+	otherGuestMemory := apiresource.MustParse("4Gi")
+	cases := []struct {
+		name        string
+		desired     kubevirtapiv1.DomainSpec
+		running     kubevirtapiv1.DomainSpec
+		vmCondition corev1.ConditionStatus
+		want        []string
+	}{
+		{
+			name: "Ignore the vmi fields defaulted by the infra-cluster",
+			desired: kubevirtapiv1.DomainSpec{
+				Resources: kubevirtapiv1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: apiresource.MustParse("2Gi")}},
+			},
+			running: kubevirtapiv1.DomainSpec{
+				Resources: kubevirtapiv1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: apiresource.MustParse("2Gi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: apiresource.MustParse("2Gi")},
+				},
+				CPU:    &kubevirtapiv1.CPU{Cores: 1, Sockets: 1, Threads: 1},
+				Memory: &kubevirtapiv1.Memory{Guest: &guestMemory},
+			},
+		},
+		{
+			name: "Report changed requests and limits",
+			desired: kubevirtapiv1.DomainSpec{
+				Resources: kubevirtapiv1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: apiresource.MustParse("4Gi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: apiresource.MustParse("4")},
+				},
+			},
+			running: kubevirtapiv1.DomainSpec{
+				Resources: kubevirtapiv1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: apiresource.MustParse("2Gi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: apiresource.MustParse("2")},
+				},
+			},
+			want: []string{"limits.cpu", "memory"},
+		},
+		{
+			name:    "Report a changed CPU topology and guest memory",
+			desired: kubevirtapiv1.DomainSpec{CPU: &kubevirtapiv1.CPU{Cores: 4}, Memory: &kubevirtapiv1.Memory{Guest: &otherGuestMemory}},
+			running: kubevirtapiv1.DomainSpec{CPU: &kubevirtapiv1.CPU{Cores: 2, Sockets: 1, Threads: 1}, Memory: &kubevirtapiv1.Memory{Guest: &guestMemory}},
+			want:    []string{"cpu topology", "guest memory"},
+		},
+		{
+			name:        "Report the changes of a VM which requires a restart",
+			vmCondition: corev1.ConditionTrue,
+			want:        []string{"spec"},
+		},
+		{
+			name:        "Ignore a VM which doesn't require a restart",
+			vmCondition: corev1.ConditionFalse,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vm := &kubevirtapiv1.VirtualMachine{}
+			vm.Spec.Template = &kubevirtapiv1.VirtualMachineInstanceTemplateSpec{}
+			vm.Spec.Template.Spec.Domain = tc.desired
+			if tc.vmCondition != "" {
+				vm.Status.Conditions = []kubevirtapiv1.VirtualMachineCondition{{Type: restartRequiredCondition, Status: tc.vmCondition}}
+			}
+			vmi := &kubevirtapiv1.VirtualMachineInstance{}
+			vmi.Spec.Domain = tc.running
+
+			assert.DeepEqual(t, changedVMIResources(vm, vmi), tc.want)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	corev1 "k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+// syncVMSecrets creates or updates the infra-cluster secrets of the vm
+func (m *manager) syncVMSecrets(secrets []*corev1.Secret, vm *kubevirtapiv1.VirtualMachine, machineScope *machineScope) error {
+	for _, secret := range secrets {
+		if err := m.syncVMSecret(secret, vm, machineScope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncVMSecret creates an infra-cluster secret of the vm, or updates it when its data was changed.
+// The secret is owned by the vm, so it is garbage collected with it.
+func (m *manager) syncVMSecret(secret *corev1.Secret, vm *kubevirtapiv1.VirtualMachine, machineScope *machineScope) error {
+	controller := true
+	secret.OwnerReferences = []k8smetav1.OwnerReference{
+		{
+			APIVersion: kubevirtapiv1.VirtualMachineGroupVersionKind.GroupVersion().String(),
+			Kind:       kubevirtapiv1.VirtualMachineGroupVersionKind.Kind,
+			Name:       vm.Name,
+			UID:        vm.UID,
+			Controller: &controller,
+		},
+	}
+
+	existingSecret, err := m.getInfraClusterSecret(secret.Name, secret.Namespace, machineScope)
+	if err != nil {
+		if !infracluster.IsNotFound(err) {
+			return fmt.Errorf("failed to get secret %s: %w", secret.Name, err)
+		}
+		if _, err := machineScope.infraClusterClient.CreateSecret(machineScope.ctx, secret.Namespace, secret); err != nil {
+			return fmt.Errorf("failed to create secret %s: %w", secret.Name, err)
+		}
+		machineScope.logger().Info("created secret", "secret", secret.Name)
+		return nil
+	}
+
+	if reflect.DeepEqual(existingSecret.Data, secret.Data) && reflect.DeepEqual(existingSecret.OwnerReferences, secret.OwnerReferences) {
+		return nil
+	}
+	existingSecret.Data = secret.Data
+	existingSecret.OwnerReferences = secret.OwnerReferences
+	if _, err := machineScope.infraClusterClient.UpdateSecret(machineScope.ctx, existingSecret.Namespace, existingSecret); err != nil {
+		return fmt.Errorf("failed to update secret %s: %w", secret.Name, err)
+	}
+	machineScope.logger().Info("updated secret", "secret", secret.Name)
+	return nil
+}
+
+// deleteVMSecrets deletes the infra-cluster secrets of the vm, if they weren't garbage collected yet
+func (m *manager) deleteVMSecrets(vmName, vmNamespace string, machineScope *machineScope) error {
+	secretNames := []string{buildUserDataSecretName(vmName)}
+	if machineScope.machineProviderSpec.SSHKeys != nil {
+		secretNames = append(secretNames, buildSSHKeysSecretName(vmName))
+	}
+	for _, secretName := range secretNames {
+		err := machineScope.infraClusterClient.DeleteSecret(machineScope.ctx, vmNamespace, secretName, &k8smetav1.DeleteOptions{})
+		if err != nil && !infracluster.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret %s: %w", secretName, err)
+		}
+	}
+	return nil
+}
+
+func (m *manager) getInfraClusterSecret(name, namespace string, machineScope *machineScope) (*corev1.Secret, error) {
+	return machineScope.infraClusterClient.GetSecret(machineScope.ctx, namespace, name, &k8smetav1.GetOptions{})
+}
@@ -0,0 +1,135 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
+	mockInfraClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster/mock"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncVMSecret(t *testing.T) {
+	cases := []struct {
+		name          string
+		staleData     bool
+		existingOwned bool
+		notFound      bool
+		getErr        error
+		wantCreate    bool
+		wantUpdate    bool
+		wantErr       string
+	}{
+		{
+			name:       "Create a missing secret",
+			notFound:   true,
+			wantCreate: true,
+		},
+		{
+			name:          "Update a secret with changed user data",
+			staleData:     true,
+			existingOwned: true,
+			wantUpdate:    true,
+		},
+		{
+			name:       "Adopt a secret without owner",
+			wantUpdate: true,
+		},
+		{
+			name:          "Skip an up to date secret",
+			existingOwned: true,
+		},
+		{
+			name:    "Fail to get the secret",
+			getErr:  errors.New("client error"),
+			wantErr: "failed to get secret machine-test-userdata: client error",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			newMockInfraClusterClient := mockInfraClusterClient.NewMockClient(mockCtrl)
+			newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+			machine := initializeMachine(t, nil, "", false)
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+				return newMockInfraClusterClient, nil
+			}
+			newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
+
+			machineScope, err := stubMachineScope(machine, newMockTenantClusterClient, infraClusterClientMockBuilder)
+			if err != nil {
+				t.Fatalf("Unable to build virtual machine with error: %v", err)
+			}
+			machineScope.vmNamespace = clusterNamespace
+			virtualMachine := stubVirtualMachine(machineScope)
+			virtualMachine.UID = "vm-uid"
+
+			userDataSecret, err := machineScope.buildUserDataSecret()
+			assert.NilError(t, err)
+			renderedUserData := string(userDataSecret.Data[userDataSecretKey])
+
+			var existingSecret *corev1.Secret
+			getErr := tc.getErr
+			if tc.notFound {
+				getErr = stubNotFoundError()
+			} else if getErr == nil {
+				existingData := renderedUserData
+				if tc.staleData {
+					existingData = userDataValue
+				}
+				existingSecret = &corev1.Secret{
+					ObjectMeta: k8smetav1.ObjectMeta{Name: userDataSecret.Name, Namespace: clusterNamespace},
+					Data:       map[string][]byte{userDataSecretKey: []byte(existingData)},
+				}
+				if tc.existingOwned {
+					controller := true
+					existingSecret.OwnerReferences = []k8smetav1.OwnerReference{{
+						APIVersion: "kubevirt.io/v1",
+						Kind:       "VirtualMachine",
+						Name:       virtualMachine.Name,
+						UID:        virtualMachine.UID,
+						Controller: &controller,
+					}}
+				}
+			}
+			newMockInfraClusterClient.EXPECT().GetSecret(gomock.Any(), clusterNamespace, buildUserDataSecretName(virtualMachine.Name), gomock.Any()).Return(existingSecret, getErr)
+
+			createTimes, updateTimes := 0, 0
+			if tc.wantCreate {
+				createTimes = 1
+			}
+			if tc.wantUpdate {
+				updateTimes = 1
+			}
+			var writtenSecret *corev1.Secret
+			recordSecret := func(_ context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+				writtenSecret = secret
+				return secret, nil
+			}
+			newMockInfraClusterClient.EXPECT().CreateSecret(gomock.Any(), clusterNamespace, gomock.Any()).DoAndReturn(recordSecret).Times(createTimes)
+			newMockInfraClusterClient.EXPECT().UpdateSecret(gomock.Any(), clusterNamespace, gomock.Any()).DoAndReturn(recordSecret).Times(updateTimes)
+
+			providerVMInstance := &manager{eventRecorder: record.NewFakeRecorder(10)}
+			err = providerVMInstance.syncVMSecret(userDataSecret, virtualMachine, machineScope)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			if writtenSecret != nil {
+				assert.Equal(t, string(writtenSecret.Data[userDataSecretKey]), renderedUserData)
+				assert.Equal(t, len(writtenSecret.OwnerReferences), 1)
+				assert.Equal(t, writtenSecret.OwnerReferences[0].UID, virtualMachine.UID)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package vm
+
+import (
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/utils"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	corev1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	defaultSSHKeysSecretSuffix = "ssh-keys"
+	// defaultSSHKeysUser is the user of RHCOS and Fedora CoreOS guests
+	defaultSSHKeysUser = "core"
+)
+
+func buildSSHKeysSecretName(virtualMachineName string) string {
+	return buildVolumeName(virtualMachineName, defaultSSHKeysSecretSuffix)
+}
+
+// buildAccessCredentials returns the VMI access credentials of the machine SSH keys
+func (s *machineScope) buildAccessCredentials() ([]kubevirtapiv1.AccessCredential, error) {
+	sshKeys := s.machineProviderSpec.SSHKeys
+	if sshKeys == nil {
+		return nil, nil
+	}
+	if sshKeys.SecretName == "" {
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: SSHKeys requires a secretName", s.machine.GetName())
+	}
+	userDataFormat, err := s.getUserDataFormat()
+	if err != nil {
+		return nil, err
+	}
+	noCloud := s.machineProviderSpec.CloudInitDataSource == kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud
+
+	propagationMethod := sshKeys.PropagationMethod
+	if propagationMethod == "" {
+		propagationMethod = kubevirtproviderv1alpha1.SSHKeysPropagationMethodQemuGuestAgent
+		if userDataFormat == kubevirtproviderv1alpha1.UserDataFormatCloudConfig && !noCloud {
+			propagationMethod = kubevirtproviderv1alpha1.SSHKeysPropagationMethodConfigDrive
+		}
+	}
+
+	credential := &kubevirtapiv1.SSHPublicKeyAccessCredential{
+		Source: kubevirtapiv1.SSHPublicKeyAccessCredentialSource{
+			Secret: &kubevirtapiv1.AccessCredentialSecretSource{SecretName: buildSSHKeysSecretName(s.machine.GetName())},
+		},
+	}
+	switch propagationMethod {
+	case kubevirtproviderv1alpha1.SSHKeysPropagationMethodQemuGuestAgent:
+		users := sshKeys.Users
+		if len(users) == 0 {
+			if userDataFormat != kubevirtproviderv1alpha1.UserDataFormatIgnition {
+				return nil, machinecontroller.InvalidMachineConfiguration("%v: SSHKeys requires users with the %v propagation method",
+					s.machine.GetName(), kubevirtproviderv1alpha1.SSHKeysPropagationMethodQemuGuestAgent)
+			}
+			users = []string{defaultSSHKeysUser}
+		}
+		credential.PropagationMethod.QemuGuestAgent = &kubevirtapiv1.QemuGuestAgentSSHPublicKeyAccessCredentialPropagation{Users: users}
+	case kubevirtproviderv1alpha1.SSHKeysPropagationMethodConfigDrive:
+		if noCloud {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: SSHKeys propagation method %v requires the %v data source", s.machine.GetName(),
+				kubevirtproviderv1alpha1.SSHKeysPropagationMethodConfigDrive, kubevirtproviderv1alpha1.CloudInitDataSourceConfigDrive)
+		}
+		credential.PropagationMethod.ConfigDrive = &kubevirtapiv1.ConfigDriveSSHPublicKeyAccessCredentialPropagation{}
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration("%v: Value of SSHKeys propagationMethod, can be only one of: %v, %v", s.machine.GetName(),
+			kubevirtproviderv1alpha1.SSHKeysPropagationMethodQemuGuestAgent, kubevirtproviderv1alpha1.SSHKeysPropagationMethodConfigDrive)
+	}
+
+	return []kubevirtapiv1.AccessCredential{{SSHPublicKey: credential}}, nil
+}
+
+// buildSSHKeysSecret returns the infra-cluster copy of the SSH keys secret of the machine
+func (s *machineScope) buildSSHKeysSecret() (*corev1.Secret, error) {
+	secretName := s.machineProviderSpec.SSHKeys.SecretName
+	namespace := s.machine.GetNamespace()
+	sshKeysSecret, err := s.tenantClusterClient.GetSecret(s.ctx, secretName, namespace)
+	if err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			return nil, machinecontroller.InvalidMachineConfiguration("%v: SSH keys secret %s/%s not found", s.machine.GetName(), namespace, secretName)
+		}
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      buildSSHKeysSecretName(s.machine.GetName()),
+			Namespace: s.vmNamespace,
+			Labels:    utils.BuildLabels(s.infraID),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: sshKeysSecret.Data,
+	}, nil
+}
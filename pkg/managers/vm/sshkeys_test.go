@@ -0,0 +1,137 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+)
+
+func TestBuildAccessCredentials(t *testing.T) {
+	sshKeysCredentials := func(propagationMethod kubevirtapiv1.SSHPublicKeyAccessCredentialPropagationMethod) []kubevirtapiv1.AccessCredential {
+		return []kubevirtapiv1.AccessCredential{{SSHPublicKey: &kubevirtapiv1.SSHPublicKeyAccessCredential{
+			Source: kubevirtapiv1.SSHPublicKeyAccessCredentialSource{
+				Secret: &kubevirtapiv1.AccessCredentialSecretSource{SecretName: "machine-test-ssh-keys"},
+			},
+			PropagationMethod: propagationMethod,
+		}}}
+	}
+	cases := []struct {
+		name         string
+		providerSpec kubevirtproviderv1alpha1.KubevirtMachineProviderSpec
+		want         []kubevirtapiv1.AccessCredential
+		wantErr      string
+	}{
+		{
+			name: "Without SSH keys",
+		},
+		{
+			name: "Ignition through the guest agent as core by default",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				SSHKeys: &kubevirtproviderv1alpha1.SSHKeysSource{SecretName: "keys"},
+			},
+			want: sshKeysCredentials(kubevirtapiv1.SSHPublicKeyAccessCredentialPropagationMethod{
+				QemuGuestAgent: &kubevirtapiv1.QemuGuestAgentSSHPublicKeyAccessCredentialPropagation{Users: []string{"core"}},
+			}),
+		},
+		{
+			name: "Cloud config in a config drive by default",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat: kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				SSHKeys:        &kubevirtproviderv1alpha1.SSHKeysSource{SecretName: "keys"},
+			},
+			want: sshKeysCredentials(kubevirtapiv1.SSHPublicKeyAccessCredentialPropagationMethod{
+				ConfigDrive: &kubevirtapiv1.ConfigDriveSSHPublicKeyAccessCredentialPropagation{},
+			}),
+		},
+		{
+			name: "Cloud config in NoCloud through the guest agent",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat:      kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				CloudInitDataSource: kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud,
+				SSHKeys:             &kubevirtproviderv1alpha1.SSHKeysSource{SecretName: "keys", Users: []string{"ubuntu"}},
+			},
+			want: sshKeysCredentials(kubevirtapiv1.SSHPublicKeyAccessCredentialPropagationMethod{
+				QemuGuestAgent: &kubevirtapiv1.QemuGuestAgentSSHPublicKeyAccessCredentialPropagation{Users: []string{"ubuntu"}},
+			}),
+		},
+		{
+			name: "Fail on guest agent propagation of cloud config without users",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat: kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				SSHKeys: &kubevirtproviderv1alpha1.SSHKeysSource{
+					SecretName:        "keys",
+					PropagationMethod: kubevirtproviderv1alpha1.SSHKeysPropagationMethodQemuGuestAgent,
+				},
+			},
+			wantErr: "machine-test: SSHKeys requires users with the QemuGuestAgent propagation method",
+		},
+		{
+			name: "Fail on config drive propagation in NoCloud",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				UserDataFormat:      kubevirtproviderv1alpha1.UserDataFormatCloudConfig,
+				CloudInitDataSource: kubevirtproviderv1alpha1.CloudInitDataSourceNoCloud,
+				SSHKeys: &kubevirtproviderv1alpha1.SSHKeysSource{
+					SecretName:        "keys",
+					PropagationMethod: kubevirtproviderv1alpha1.SSHKeysPropagationMethodConfigDrive,
+				},
+			},
+			wantErr: "machine-test: SSHKeys propagation method ConfigDrive requires the ConfigDrive data source",
+		},
+		{
+			name: "Fail without secret name",
+			providerSpec: kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+				SSHKeys: &kubevirtproviderv1alpha1.SSHKeysSource{},
+			},
+			wantErr: "machine-test: SSHKeys requires a secretName",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				machine:             initializeMachine(t, nil, "", false),
+				machineProviderSpec: &tc.providerSpec,
+			}
+			accessCredentials, err := machineScope.buildAccessCredentials()
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, accessCredentials, tc.want)
+		})
+	}
+}
+
+func TestBuildSSHKeysSecret(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	newMockTenantClusterClient := mockTenantClusterClient.NewMockClient(mockCtrl)
+
+	machine := initializeMachine(t, nil, "", false)
+	keys := map[string][]byte{"admin": []byte("ssh-ed25519 AAAA admin")}
+	newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), "keys", machine.Namespace).Return(&corev1.Secret{Data: keys}, nil)
+	newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), "missing", machine.Namespace).Return(nil, stubNotFoundError())
+
+	machineScope := &machineScope{
+		machine:             machine,
+		tenantClusterClient: newMockTenantClusterClient,
+		vmNamespace:         clusterNamespace,
+		machineProviderSpec: &kubevirtproviderv1alpha1.KubevirtMachineProviderSpec{
+			SSHKeys: &kubevirtproviderv1alpha1.SSHKeysSource{SecretName: "keys"},
+		},
+	}
+	sshKeysSecret, err := machineScope.buildSSHKeysSecret()
+	assert.NilError(t, err)
+	assert.Equal(t, sshKeysSecret.Name, "machine-test-ssh-keys")
+	assert.Equal(t, sshKeysSecret.Namespace, clusterNamespace)
+	assert.DeepEqual(t, sshKeysSecret.Data, keys)
+
+	machineScope.machineProviderSpec.SSHKeys.SecretName = "missing"
+	_, err = machineScope.buildSSHKeysSecret()
+	assert.Error(t, err, "machine-test: SSH keys secret default/missing not found")
+}
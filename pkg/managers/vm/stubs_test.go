@@ -1,15 +1,18 @@
 package vm
 
 import (
+	"context"
 	"fmt"
 
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
-	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1alpha1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	corev1 "k8s.io/api/core/v1"
 
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
 
 	machineapierros "github.com/openshift/machine-api-operator/pkg/controller/machine"
 
@@ -26,7 +29,7 @@ const (
 	mahcineName              = "machine-test"
 	clusterID                = "kubevirt-actuator-cluster"
 	clusterName              = "kubevirt-actuator-cluster"
-	userDataValue            = "123"
+	userDataValue            = `{"ignition":{"version":"3.1.0"}}`
 	workerUserDataSecretName = "worker-user-data"
 	SourceTestPvcName        = "SourceTestPvcName"
 	NetworkName              = "multus-network"
@@ -48,6 +51,18 @@ func stubVmi(vm *kubevirtapiv1.VirtualMachine) (*kubevirtapiv1.VirtualMachineIns
 	return &vmi, nil
 }
 
+func stubNotFoundError() error {
+	return apimachineryerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "")
+}
+
+func stubDataVolume(phase cdiv1.DataVolumePhase) *cdiv1.DataVolume {
+	return &cdiv1.DataVolume{
+		Status: cdiv1.DataVolumeStatus{
+			Phase: phase,
+		},
+	}
+}
+
 func stubMachineScope(machine *machinev1.Machine, tenantClusterClient tenantcluster.Client, infraClusterClientBuilder infracluster.ClientBuilderFuncType) (*machineScope, error) {
 	providerSpec, err := kubevirtproviderv1alpha1.ProviderSpecFromRawExtension(machine.Spec.ProviderSpec.Value)
 	if err != nil {
@@ -59,12 +74,13 @@ func stubMachineScope(machine *machinev1.Machine, tenantClusterClient tenantclus
 		return nil, machineapierros.InvalidMachineConfiguration("failed to get machine provider status: %v", err.Error())
 	}
 
-	infraClusterClient, err := infraClusterClientBuilder(tenantClusterClient, providerSpec.CredentialsSecretName, machine.GetNamespace())
+	infraClusterClient, err := infraClusterClientBuilder(context.Background(), tenantClusterClient, providerSpec.CredentialsSecretName, machine.GetNamespace())
 	if err != nil {
 		return nil, machineapierros.InvalidMachineConfiguration("failed to create aKubeVirt client: %v", err.Error())
 	}
 
 	return &machineScope{
+		ctx:                   context.Background(),
 		infraClusterClient:    infraClusterClient,
 		tenantClusterClient:   tenantClusterClient,
 		machine:               machine,
@@ -87,7 +103,7 @@ func stubBuildVMITemplate(s *machineScope) *kubevirtapiv1.VirtualMachineInstance
 	template := &kubevirtapiv1.VirtualMachineInstanceTemplateSpec{}
 
 	template.ObjectMeta = metav1.ObjectMeta{
-		Labels: map[string]string{"kubevirt.io/vm": virtualMachineName, "name": virtualMachineName},
+		Labels: map[string]string{"kubevirt.io/vm": virtualMachineName, "name": virtualMachineName, "tenantcluster-test-id-asdfg-machine.openshift.io": "owned"},
 	}
 
 	template.Spec = kubevirtapiv1.VirtualMachineInstanceSpec{}
@@ -104,7 +120,9 @@ func stubBuildVMITemplate(s *machineScope) *kubevirtapiv1.VirtualMachineInstance
 			Name: buildCloudInitVolumeDiskName(virtualMachineName),
 			VolumeSource: kubevirtapiv1.VolumeSource{
 				CloudInitConfigDrive: &kubevirtapiv1.CloudInitConfigDriveSource{
-					UserData: userDataValue,
+					UserDataSecretRef: &corev1.LocalObjectReference{
+						Name: buildUserDataSecretName(virtualMachineName),
+					},
 				},
 			},
 		},
@@ -191,8 +209,8 @@ func stubVirtualMachine(machineScope *machineScope) *kubevirtapiv1.VirtualMachin
 	virtualMachine := kubevirtapiv1.VirtualMachine{
 		Spec: kubevirtapiv1.VirtualMachineSpec{
 			RunStrategy: &runAlways,
-			DataVolumeTemplates: []cdiv1.DataVolume{
-				*buildBootVolumeDataVolumeTemplate(machineScope.machine.GetName(), machineScope.machineProviderSpec.SourcePvcName, namespace, storageClassName, defaultRequestedStorage, defaultPersistentVolumeAccessMode, map[string]string{"tenantcluster-test-id-asdfg-machine.openshift.io": "owned"}),
+			DataVolumeTemplates: []kubevirtapiv1.DataVolumeTemplateSpec{
+				*buildBootVolumeDataVolumeTemplate(machineScope.machine.GetName(), machineScope.machineProviderSpec.SourcePvcName, namespace, storageClassName, defaultRequestedStorage, defaultPersistentVolumeAccessMode, map[string]string{"kubevirt.io/vm": machineScope.machine.GetName(), "tenantcluster-test-id-asdfg-machine.openshift.io": "owned"}),
 			},
 			Template: vmiTemplate,
 		},
@@ -200,6 +218,7 @@ func stubVirtualMachine(machineScope *machineScope) *kubevirtapiv1.VirtualMachin
 
 	labels := machineScope.machine.Labels
 	labels["tenantcluster-test-id-asdfg-machine.openshift.io"] = "owned"
+	labels["kubevirt.io/vm"] = machineScope.machine.Name
 
 	virtualMachine.APIVersion = APIVersion
 	virtualMachine.Kind = Kind
@@ -17,14 +17,19 @@ limitations under the License.
 package vm
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"net"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
 // upstreamMachineClusterIDLabel is the label that a machine must have to identify the cluster to which it belongs
@@ -45,6 +50,28 @@ const upstreamMachineClusterIDLabel = "sigs.k8s.io/cluster-api-cluster"
 // 	}
 // }
 
+// restartRequiredCondition is set in the provider status while the running vmi doesn't match the VM template
+const restartRequiredCondition kubevirtapiv1.VirtualMachineConditionType = "RestartRequired"
+
+// instanceHealthyCondition reports whether the machine instance runs, it is false once the vmi has exited
+const instanceHealthyCondition kubevirtapiv1.VirtualMachineConditionType = "InstanceHealthy"
+
+// guestAgentConnectedCondition reports whether the QEMU guest agent of the vmi is connected
+const guestAgentConnectedCondition kubevirtapiv1.VirtualMachineConditionType = "GuestAgentConnected"
+
+// providerOwnedConditions returns the conditions set by the controller, which are kept when
+// the provider status is refreshed from the VM status
+func providerOwnedConditions(conditions []kubevirtapiv1.VirtualMachineCondition) []kubevirtapiv1.VirtualMachineCondition {
+	var owned []kubevirtapiv1.VirtualMachineCondition
+	for _, condition := range conditions {
+		switch condition.Type {
+		case restartRequiredCondition, instanceHealthyCondition, guestAgentConnectedCondition:
+			owned = append(owned, condition)
+		}
+	}
+	return owned
+}
+
 // setKubevirtMachineProviderCondition sets the condition for the machine and
 // returns the new slice of conditions.
 // If the machine does not already have a condition with the specified type,
@@ -93,25 +120,190 @@ func shouldUpdateCondition(newCondition, existingCondition *kubevirtapiv1.Virtua
 }
 
 // The network info is saved in the vmi
-// extractNodeAddresses maps the instance information from Vmi to an array of NodeAddresses
-func extractNodeAddresses(vmi *kubevirtapiv1.VirtualMachineInstance) ([]corev1.NodeAddress, error) {
-	// Not clear if the order matters here, but we might as well indicate a sensible preference order
-
+// extractNodeAddresses maps the addresses of the vmi interfaces to node addresses, classified by the rules of their interface.
+// Link-local addresses, and addresses of a family missing in families, are dropped. Once the guest agent is connected,
+// only the addresses it reports are kept, the others are the addresses of the virt-launcher pod rather than of the guest.
+// The addresses are ordered by type, internal first, then by the order of families, IPv4 first without families,
+// then by interface name.
+func extractNodeAddresses(vmi *kubevirtapiv1.VirtualMachineInstance, rules []kubevirtproviderv1alpha1.NodeAddressRule, families []corev1.IPFamily) ([]corev1.NodeAddress, error) {
 	if vmi == nil {
 		return nil, fmt.Errorf("nil vmi passed to extractNodeAddresses")
 	}
 
-	addresses := []corev1.NodeAddress{}
-	interfaces := vmi.Status.Interfaces
-	for _, i := range interfaces {
-		if i.IP != "" {
-			addresses = append(addresses, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: i.IP})
+	if len(families) == 0 {
+		families = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	}
+	familyRank := map[corev1.IPFamily]int{}
+	for i, family := range families {
+		familyRank[family] = i
+	}
+
+	type interfaceAddress struct {
+		corev1.NodeAddress
+		interfaceName string
+		familyRank    int
+	}
+	var interfaceAddresses []interfaceAddress
+	seen := map[corev1.NodeAddress]bool{}
+	agentConnected := isGuestAgentConnected(vmi)
+	for _, i := range vmi.Status.Interfaces {
+		// Guest interfaces without a VMI network, like the loopback, are only reported by the guest agent
+		if i.Name == "" || agentConnected && i.InterfaceName == "" {
+			continue
+		}
+		addressType, err := nodeAddressType(i.Name, rules)
+		if err != nil {
+			return nil, err
+		}
+		if addressType == "" {
+			continue
+		}
+		ips := i.IPs
+		if len(ips) == 0 && i.IP != "" {
+			ips = []string{i.IP}
+		}
+		for _, ip := range ips {
+			parsedIP := net.ParseIP(ip)
+			if parsedIP == nil || parsedIP.IsLinkLocalUnicast() {
+				continue
+			}
+			rank, ok := familyRank[ipFamily(parsedIP)]
+			if !ok {
+				continue
+			}
+			address := corev1.NodeAddress{Type: addressType, Address: ip}
+			if seen[address] {
+				continue
+			}
+			seen[address] = true
+			interfaceAddresses = append(interfaceAddresses, interfaceAddress{
+				NodeAddress:   address,
+				interfaceName: i.Name,
+				familyRank:    rank,
+			})
 		}
 	}
 
+	sort.SliceStable(interfaceAddresses, func(i, j int) bool {
+		a, b := interfaceAddresses[i], interfaceAddresses[j]
+		if a.Type != b.Type {
+			return a.Type == corev1.NodeInternalIP
+		}
+		if a.familyRank != b.familyRank {
+			return a.familyRank < b.familyRank
+		}
+		if a.interfaceName != b.interfaceName {
+			return a.interfaceName < b.interfaceName
+		}
+		return a.Address < b.Address
+	})
+
+	addresses := make([]corev1.NodeAddress, 0, len(interfaceAddresses))
+	for _, address := range interfaceAddresses {
+		addresses = append(addresses, address.NodeAddress)
+	}
 	return addresses, nil
 }
 
+// nodeAddressType returns the node address type of the addresses of the interface, or an empty type if they are dropped
+func nodeAddressType(interfaceName string, rules []kubevirtproviderv1alpha1.NodeAddressRule) (corev1.NodeAddressType, error) {
+	ruleType := kubevirtproviderv1alpha1.NodeAddressRuleInternalIP
+	if interfaceName == podNetworkName {
+		ruleType = kubevirtproviderv1alpha1.NodeAddressRuleDrop
+	}
+	for _, rule := range rules {
+		if rule.Interface == interfaceName {
+			ruleType = rule.Type
+			break
+		}
+	}
+
+	switch ruleType {
+	case kubevirtproviderv1alpha1.NodeAddressRuleInternalIP:
+		return corev1.NodeInternalIP, nil
+	case kubevirtproviderv1alpha1.NodeAddressRuleExternalIP:
+		return corev1.NodeExternalIP, nil
+	case kubevirtproviderv1alpha1.NodeAddressRuleDrop:
+		return "", nil
+	default:
+		return "", machinecontroller.InvalidMachineConfiguration("Value of NodeAddressRules type of interface %v, can be only one of: %v, %v, %v", interfaceName,
+			kubevirtproviderv1alpha1.NodeAddressRuleInternalIP, kubevirtproviderv1alpha1.NodeAddressRuleExternalIP, kubevirtproviderv1alpha1.NodeAddressRuleDrop)
+	}
+}
+
+// hostnameAddressType returns the node address type of the VM name, or an empty type if it's dropped
+func hostnameAddressType(rules []kubevirtproviderv1alpha1.NodeAddressRule) (corev1.NodeAddressType, error) {
+	ruleType := kubevirtproviderv1alpha1.NodeAddressRuleInternalDNS
+	for _, rule := range rules {
+		if rule.Interface == kubevirtproviderv1alpha1.NodeAddressRuleHostname {
+			ruleType = rule.Type
+			break
+		}
+	}
+
+	switch ruleType {
+	case kubevirtproviderv1alpha1.NodeAddressRuleInternalDNS:
+		return corev1.NodeInternalDNS, nil
+	case kubevirtproviderv1alpha1.NodeAddressRuleExternalDNS:
+		return corev1.NodeExternalDNS, nil
+	case kubevirtproviderv1alpha1.NodeAddressRuleDrop:
+		return "", nil
+	default:
+		return "", machinecontroller.InvalidMachineConfiguration("Value of NodeAddressRules type of interface %v, can be only one of: %v, %v, %v",
+			kubevirtproviderv1alpha1.NodeAddressRuleHostname, kubevirtproviderv1alpha1.NodeAddressRuleInternalDNS,
+			kubevirtproviderv1alpha1.NodeAddressRuleExternalDNS, kubevirtproviderv1alpha1.NodeAddressRuleDrop)
+	}
+}
+
+// instanceHealthCondition returns the instance healthy condition of a machine instance state
+func instanceHealthCondition(state machineState, vmi *kubevirtapiv1.VirtualMachineInstance) kubevirtapiv1.VirtualMachineCondition {
+	condition := kubevirtapiv1.VirtualMachineCondition{
+		Type:    instanceHealthyCondition,
+		Status:  corev1.ConditionUnknown,
+		Reason:  "InstancePending",
+		Message: fmt.Sprintf("Machine instance is in state %s", state),
+	}
+	switch state {
+	case vmiRunning:
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "VMIRunning"
+		condition.Message = "VMI is running"
+	case vmiNotReady:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "VMINotReady"
+		condition.Message = "VMI is running but not ready, its readiness probe fails"
+		if vmi != nil {
+			for _, vmiCondition := range vmi.Status.Conditions {
+				if vmiCondition.Type == kubevirtapiv1.VirtualMachineInstanceReady && vmiCondition.Message != "" {
+					condition.Message = fmt.Sprintf("VMI is running but not ready: %s", vmiCondition.Message)
+				}
+			}
+		}
+	case vmiSucceeded:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "VMISucceeded"
+		condition.Message = "VMI has exited, the guest was shut down"
+	case vmiFailed:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "VMIFailed"
+		condition.Message = "VMI has failed"
+		if vmi != nil && vmi.Status.Reason != "" {
+			condition.Message = fmt.Sprintf("VMI has failed: %s", vmi.Status.Reason)
+		}
+	case vmiUnknown:
+		condition.Reason = "VMIUnknown"
+		condition.Message = "VMI state can't be obtained, its node may be unreachable"
+	case dataVolumeFailed:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "DataVolumeFailed"
+		condition.Message = "Boot volume provisioning has failed"
+	case vmStopped:
+		condition.Reason = "VMStopped"
+		condition.Message = "VM is stopped"
+	}
+	return condition
+}
+
 // TODO There is only one kind of VirtualMachineConditionType: VirtualMachineFailure
 //      How should report on success?
 //      Is Failure/false is good enough or need to add type to client-go?
@@ -132,6 +324,19 @@ func conditionFailed() kubevirtapiv1.VirtualMachineCondition {
 	}
 }
 
+// hashVirtualMachine returns a hash of the VM fields rendered by the controller
+func hashVirtualMachine(vm *kubevirtapiv1.VirtualMachine) (string, error) {
+	rendered := vm.DeepCopy()
+	delete(rendered.Annotations, appliedHashAnnotationKey)
+	rendered.ResourceVersion = ""
+	rendered.Status = kubevirtapiv1.VirtualMachineStatus{}
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
 // validateMachine check the label that a machine must have to identify the cluster to which it belongs is present.
 func validateMachine(machine machinev1.Machine) error {
 	// TODO: insert a validation on machine labels
@@ -2,7 +2,124 @@ package vm
 
 import (
 	"testing"
+
+	kubevirtproviderv1alpha1 "github.com/openshift/cluster-api-provider-kubevirt/pkg/apis/kubevirtprovider/v1alpha1"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
 )
 
 func TestExtractNodeAddresses(t *testing.T) {
+	cases := []struct {
+		name       string
+		interfaces []kubevirtapiv1.VirtualMachineInstanceNetworkInterface
+		rules      []kubevirtproviderv1alpha1.NodeAddressRule
+		families   []corev1.IPFamily
+		agent      bool
+		want       []corev1.NodeAddress
+		wantErr    string
+	}{
+		{
+			name: "Drop the pod network by default",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: podNetworkName, IP: "10.128.0.5", IPs: []string{"10.128.0.5"}},
+				{Name: mainNetworkName, IP: "192.168.1.10", IPs: []string{"192.168.1.10"}},
+			},
+			want: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.1.10"}},
+		},
+		{
+			name: "Report all the addresses of an interface, IPv4 first",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, IP: "fd00::10", IPs: []string{"fd00::10", "192.168.1.11", "192.168.1.10"}},
+			},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.11"},
+				{Type: corev1.NodeInternalIP, Address: "fd00::10"},
+			},
+		},
+		{
+			name: "Order the addresses by primary family, then by interface, without link-local addresses",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, IPs: []string{"192.168.1.10", "fd00::10", "fe80::1"}},
+				{Name: "storage", IPs: []string{"10.1.0.10", "fd01::10"}},
+			},
+			families: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "fd00::10"},
+				{Type: corev1.NodeInternalIP, Address: "fd01::10"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+				{Type: corev1.NodeInternalIP, Address: "10.1.0.10"},
+			},
+		},
+		{
+			name: "Drop the addresses of a family missing in the families",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, IPs: []string{"192.168.1.10", "fd00::10"}},
+			},
+			families: []corev1.IPFamily{corev1.IPv4Protocol},
+			want:     []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.1.10"}},
+		},
+		{
+			name: "Prefer the addresses reported by the guest agent",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, InterfaceName: "enp1s0", IPs: []string{"192.168.1.10"}},
+				{Name: "storage", IP: "10.1.0.10"},
+				{InterfaceName: "lo", IPs: []string{"127.0.0.1"}},
+			},
+			agent: true,
+			want:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.1.10"}},
+		},
+		{
+			name: "Fall back to the primary address",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, IP: "192.168.1.10"},
+			},
+			want: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.1.10"}},
+		},
+		{
+			name: "Classify the interfaces by rules, internal addresses first",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, IPs: []string{"203.0.113.10"}},
+				{Name: podNetworkName, IPs: []string{"10.128.0.5"}},
+				{Name: "storage", IPs: []string{"10.1.0.10"}},
+			},
+			rules: []kubevirtproviderv1alpha1.NodeAddressRule{
+				{Interface: mainNetworkName, Type: kubevirtproviderv1alpha1.NodeAddressRuleExternalIP},
+				{Interface: podNetworkName, Type: kubevirtproviderv1alpha1.NodeAddressRuleInternalIP},
+				{Interface: "storage", Type: kubevirtproviderv1alpha1.NodeAddressRuleDrop},
+			},
+			want: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.128.0.5"},
+				{Type: corev1.NodeExternalIP, Address: "203.0.113.10"},
+			},
+		},
+		{
+			name: "Fail on an unknown rule type",
+			interfaces: []kubevirtapiv1.VirtualMachineInstanceNetworkInterface{
+				{Name: mainNetworkName, IPs: []string{"192.168.1.10"}},
+			},
+			rules:   []kubevirtproviderv1alpha1.NodeAddressRule{{Interface: mainNetworkName, Type: "Hostname"}},
+			wantErr: "Value of NodeAddressRules type of interface main, can be only one of: InternalIP, ExternalIP, Drop",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vmi := &kubevirtapiv1.VirtualMachineInstance{
+				Status: kubevirtapiv1.VirtualMachineInstanceStatus{Interfaces: tc.interfaces},
+			}
+			if tc.agent {
+				vmi.Status.Conditions = []kubevirtapiv1.VirtualMachineInstanceCondition{
+					{Type: kubevirtapiv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionTrue},
+				}
+			}
+			addresses, err := extractNodeAddresses(vmi, tc.rules, tc.families)
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, addresses, tc.want)
+		})
+	}
 }
@@ -1,63 +1,89 @@
 package vm
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
+	"github.com/openshift/cluster-api-provider-kubevirt/pkg/tracing"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
 	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/klog"
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
+	"k8s.io/client-go/tools/record"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 const (
 	requeueAfterSeconds      = 20
 	requeueAfterFatalSeconds = 180
 	masterLabel              = "node-role.kubevirt.io/master"
+	// vmFieldManager owns the VM fields rendered by the controller
+	vmFieldManager = "kubevirt-machine-controller"
 )
 
 // ProviderVM runs the logic to reconciles a machine resource towards its desired state
 type ProviderVM interface {
-	Create(machine *machinev1.Machine) error
-	Delete(machine *machinev1.Machine) error
-	Update(machine *machinev1.Machine) (bool, error)
-	Exists(machine *machinev1.Machine) (bool, error)
+	Create(ctx context.Context, machine *machinev1.Machine) error
+	Delete(ctx context.Context, machine *machinev1.Machine) error
+	Update(ctx context.Context, machine *machinev1.Machine) (bool, error)
+	Exists(ctx context.Context, machine *machinev1.Machine) (bool, error)
 }
 
 // manager is the struct which implement ProviderVM interface
 // Use tenantClusterClient to access secret params assigned by user
 // Use infraClusterClientBuilder to create the infra cluster vms
+// Use eventRecorder to report infra-cluster state changes on the machine
 type manager struct {
 	infraClusterClientBuilder infracluster.ClientBuilderFuncType
 	tenantClusterClient       tenantcluster.Client
+	eventRecorder             record.EventRecorder
 }
 
 // New creates provider vm instance
-func New(infraClusterClientBuilder infracluster.ClientBuilderFuncType, tenantClusterClient tenantcluster.Client) ProviderVM {
+func New(infraClusterClientBuilder infracluster.ClientBuilderFuncType, tenantClusterClient tenantcluster.Client, eventRecorder record.EventRecorder) ProviderVM {
 	return &manager{
 		tenantClusterClient:       tenantClusterClient,
 		infraClusterClientBuilder: infraClusterClientBuilder,
+		eventRecorder:             eventRecorder,
 	}
 }
 
 // Create creates machine if it does not exists.
-func (m *manager) Create(machine *machinev1.Machine) (resultErr error) {
-	machineScope, err := newMachineScope(machine, m.tenantClusterClient, m.infraClusterClientBuilder)
+func (m *manager) Create(ctx context.Context, machine *machinev1.Machine) (resultErr error) {
+	machineScope, err := newMachineScope(ctx, machine, m.tenantClusterClient, m.infraClusterClientBuilder)
 	if err != nil {
 		return err
 	}
+	if err := machineScope.waitForBackoff(time.Now()); err != nil {
+		return err
+	}
 
+	// The MAC address is allocated before building the VM, which sets it on the main interface
+	if err := machineScope.allocateMACAddress(); err != nil {
+		return err
+	}
 	virtualMachineFromMachine, err := machineScope.createVirtualMachineFromMachine()
 	if err != nil {
 		return err
 	}
 
-	klog.Infof("%s: create machine", machineScope.getMachineName())
+	// The addresses are allocated and the secrets are read before creating the VM,
+	// so an invalid user data or SSH keys secret fails the machine
+	if err := machineScope.allocateIPAddresses(); err != nil {
+		return err
+	}
+	vmSecrets, err := machineScope.buildVMSecrets()
+	if err != nil {
+		return err
+	}
+
+	machineScope.logger().Info("create machine")
 
 	defer func() {
 		// After the operation is done (success or failure)
@@ -70,75 +96,120 @@ func (m *manager) Create(machine *machinev1.Machine) (resultErr error) {
 	createdVM, err := m.createInfraClusterVM(virtualMachineFromMachine, machineScope)
 
 	if err != nil {
-		klog.Errorf("%s: error creating machine: %v", machineScope.getMachineName(), err)
+		machineScope.logger().Error(err, "error creating machine")
 		conditionFailed := conditionFailed()
 		conditionFailed.Message = err.Error()
-		return fmt.Errorf("failed to create virtual machine: %w", err)
+		return machineScope.requeueWithBackoff(infracluster.ToMachineError(fmt.Errorf("failed to create virtual machine: %w", err), machineScope.getMachineName(), machinecontroller.CreateMachine), time.Now())
 	}
+	machineScope.resetBackoff()
 
-	klog.Infof("Created Machine %v", machineScope.getMachineName())
+	machineScope.logger().Info("Created Machine")
 
-	if err := m.syncMachine(createdVM, machineScope); err != nil {
-		klog.Errorf("%s: fail syncing machine from vm: %v", machineScope.getMachineName(), err)
+	if err := m.syncVMSecrets(vmSecrets, createdVM, machineScope); err != nil {
+		machineScope.logger().Error(err, "fail syncing VM secrets")
 		return err
 	}
 
+	if err := m.syncMachine(createdVM, machineScope); err != nil {
+		machineScope.logger().Error(err, "fail syncing machine from vm")
+		return machineScope.requeueWithBackoff(err, time.Now())
+	}
+
 	return nil
 }
 
 // delete deletes machine
-func (m *manager) Delete(machine *machinev1.Machine) error {
-	machineScope, err := newMachineScope(machine, m.tenantClusterClient, m.infraClusterClientBuilder)
+func (m *manager) Delete(ctx context.Context, machine *machinev1.Machine) (resultErr error) {
+	machineScope, err := newMachineScope(ctx, machine, m.tenantClusterClient, m.infraClusterClientBuilder)
 	if err != nil {
 		return err
 	}
+	if err := machineScope.waitForBackoff(time.Now()); err != nil {
+		return err
+	}
 
 	virtualMachineFromMachine, err := machineScope.createVirtualMachineFromMachine()
 	if err != nil {
 		return err
 	}
 
-	klog.Infof("%s: delete machine", machineScope.getMachineName())
+	machineScope.logger().Info("delete machine")
+
+	defer func() {
+		// The backoff of a failed deletion is kept in the machine provider status
+		if resultErr == nil || machineScope.machineProviderStatus.Backoff == nil {
+			return
+		}
+		if err := machineScope.patchMachine(); err != nil {
+			resultErr = err
+		}
+	}()
 
 	existingVM, err := m.getInraClusterVM(virtualMachineFromMachine.GetName(), virtualMachineFromMachine.GetNamespace(), machineScope)
 	if err != nil {
-		// TODO ask Nir how to check it
-		if strings.Contains(err.Error(), "not found") {
-			klog.Infof("%s: VM does not exist", machineScope.getMachineName())
-			return nil
+		if infracluster.IsNotFound(err) {
+			machineScope.logger().Info("VM does not exist")
+			if err := machineScope.releaseMACAddress(); err != nil {
+				return err
+			}
+			return machineScope.releaseIPAddresses()
 		}
 
-		klog.Errorf("%s: error getting existing VM: %v", machineScope.getMachineName(), err)
-		return err
+		machineScope.logger().Error(err, "error getting existing VM")
+		return machineScope.requeueWithBackoff(infracluster.ToMachineError(err, machineScope.getMachineName(), machinecontroller.DeleteMachine), time.Now())
 	}
 
 	if existingVM == nil {
-		klog.Warningf("%s: VM not found to delete for machine", machineScope.getMachineName())
+		machineScope.logger().Info("VM not found to delete for machine")
 		return nil
 	}
 
-	if err := m.deleteInraClusterVM(existingVM.GetName(), existingVM.GetNamespace(), machineScope); err != nil {
-		return fmt.Errorf("failed to delete VM: %w", err)
+	if err := m.deleteInraClusterVM(existingVM.GetName(), existingVM.GetNamespace(), machineScope); err != nil && !infracluster.IsNotFound(err) {
+		machineScope.logger().Error(err, "error deleting VM")
+		return machineScope.requeueWithBackoff(infracluster.ToMachineError(fmt.Errorf("failed to delete VM: %w", err), machineScope.getMachineName(), machinecontroller.DeleteMachine), time.Now())
+	}
+	if err := m.deleteVMSecrets(existingVM.GetName(), existingVM.GetNamespace(), machineScope); err != nil {
+		return err
+	}
+	if err := machineScope.releaseMACAddress(); err != nil {
+		return err
+	}
+	if err := machineScope.releaseIPAddresses(); err != nil {
+		return err
 	}
 
-	klog.Infof("Deleted machine %v", machineScope.getMachineName())
+	machineScope.logger().Info("Deleted machine")
 
 	return nil
 }
 
 // update finds a vm and reconciles the machine resource status against it.
-func (m *manager) Update(machine *machinev1.Machine) (wasUpdated bool, resultErr error) {
-	machineScope, err := newMachineScope(machine, m.tenantClusterClient, m.infraClusterClientBuilder)
+func (m *manager) Update(ctx context.Context, machine *machinev1.Machine) (wasUpdated bool, resultErr error) {
+	machineScope, err := newMachineScope(ctx, machine, m.tenantClusterClient, m.infraClusterClientBuilder)
 	if err != nil {
 		return false, err
 	}
+	if err := machineScope.waitForBackoff(time.Now()); err != nil {
+		return false, err
+	}
 
+	if err := machineScope.allocateMACAddress(); err != nil {
+		return false, err
+	}
 	virtualMachineFromMachine, err := machineScope.createVirtualMachineFromMachine()
 	if err != nil {
 		return false, err
 	}
 
-	klog.Infof("%s: update machine", machineScope.getMachineName())
+	if err := machineScope.allocateIPAddresses(); err != nil {
+		return false, err
+	}
+	vmSecrets, err := machineScope.buildVMSecrets()
+	if err != nil {
+		return false, err
+	}
+
+	machineScope.logger().Info("update machine")
 
 	defer func() {
 		// After the operation is done (success or failure)
@@ -148,90 +219,143 @@ func (m *manager) Update(machine *machinev1.Machine) (wasUpdated bool, resultErr
 		}
 	}()
 
-	wasUpdated, updatedVM, err := m.updateVM(err, virtualMachineFromMachine, machineScope)
+	wasUpdated, updatedVM, err := m.updateVM(virtualMachineFromMachine, machineScope)
 	if err != nil {
+		return false, machineScope.requeueWithBackoff(err, time.Now())
+	}
+	machineScope.resetBackoff()
+
+	if err := m.syncVMSecrets(vmSecrets, updatedVM, machineScope); err != nil {
+		machineScope.logger().Error(err, "fail syncing VM secrets")
 		return false, err
 	}
 
 	if err := m.syncMachine(updatedVM, machineScope); err != nil {
-		klog.Errorf("%s: fail syncing machine from vm: %v", machineScope.getMachineName(), err)
+		machineScope.logger().Error(err, "fail syncing machine from vm")
+		return false, machineScope.requeueWithBackoff(err, time.Now())
+	}
+	if err := m.failOnCrashLoop(machineScope); err != nil {
 		return false, err
 	}
+	if err := m.requeueIfInstancePending(machineScope); err != nil {
+		return wasUpdated, err
+	}
 	return wasUpdated, nil
 }
 
-func (m *manager) updateVM(err error, virtualMachineFromMachine *kubevirtapiv1.VirtualMachine, machineScope *machineScope) (bool, *kubevirtapiv1.VirtualMachine, error) {
+func (m *manager) updateVM(virtualMachineFromMachine *kubevirtapiv1.VirtualMachine, machineScope *machineScope) (bool, *kubevirtapiv1.VirtualMachine, error) {
 	existingVM, err := m.getInraClusterVM(virtualMachineFromMachine.GetName(), virtualMachineFromMachine.GetNamespace(), machineScope)
 	if err != nil {
-		klog.Errorf("%s: error getting existing VM: %v", machineScope.getMachineName(), err)
-		return false, nil, err
+		machineScope.logger().Error(err, "error getting existing VM")
+		return false, nil, infracluster.ToMachineError(err, machineScope.getMachineName(), machinecontroller.UpdateMachine)
 	}
 	if existingVM == nil {
 		if machineScope.updateAllowed() {
-			klog.Infof("%s: Possible eventual-consistency discrepancy; returning an error to requeue", machineScope.getMachineName())
+			machineScope.logger().Info("Possible eventual-consistency discrepancy; returning an error to requeue")
 			return false, nil, &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterSeconds * time.Second}
 		}
-		klog.Warningf("%s: attempted to update machine but the VM found", machineScope.getMachineName())
+		machineScope.logger().Info("attempted to update machine but the VM found")
 
 		// This is an unrecoverable error condition.  We should delay to
 		// minimize unnecessary API calls.
 		return false, nil, &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterFatalSeconds * time.Second}
 	}
 
-	previousResourceVersion := existingVM.ResourceVersion
-	virtualMachineFromMachine.ObjectMeta.ResourceVersion = previousResourceVersion
-
-	//TODO remove it after pushing that PR: https://github.com/kubevirt/kubevirt/pull/3889
-	virtualMachineFromMachine.Status = kubevirtapiv1.VirtualMachineStatus{
-		Created: existingVM.Status.Created,
-		Ready:   existingVM.Status.Ready,
+	// The rendered fields are always applied, so the changes of other actors to the fields owned by the controller
+	// are reverted. Applying unchanged fields doesn't update the VM, the hash of the rendered fields, applied with
+	// them, only tells a changed machine from a reverted drift.
+	renderedHash, err := hashVirtualMachine(virtualMachineFromMachine)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to hash VM: %w", err)
+	}
+	machineChanged := existingVM.GetAnnotations()[appliedHashAnnotationKey] != renderedHash
+	if virtualMachineFromMachine.Annotations == nil {
+		virtualMachineFromMachine.Annotations = map[string]string{}
 	}
+	virtualMachineFromMachine.Annotations[appliedHashAnnotationKey] = renderedHash
 
-	updatedVM, err := m.updateInraClusterVM(virtualMachineFromMachine, machineScope)
+	updatedVM, err := m.applyInfraClusterVM(virtualMachineFromMachine, machineScope)
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to update VM: %w", err)
+		machineScope.logger().Error(err, "error updating VM")
+		return false, nil, infracluster.ToMachineError(fmt.Errorf("failed to update VM: %w", err), machineScope.getMachineName(), machinecontroller.UpdateMachine)
 	}
-	currentResourceVersion := updatedVM.ResourceVersion
 
-	klog.Infof("Updated machine %s", machineScope.getMachineName())
-
-	wasUpdated := previousResourceVersion != currentResourceVersion
+	wasUpdated := existingVM.ResourceVersion != updatedVM.ResourceVersion
+	switch {
+	case machineChanged:
+		machineScope.logger().Info("Updated machine")
+	case wasUpdated:
+		machineScope.logger().Info("Reverted the changes of the VM fields owned by the controller")
+	default:
+		machineScope.logger().V(3).Info("VM is up to date")
+	}
 	return wasUpdated, updatedVM, nil
 }
 
+// syncMachine syncs the machine from its VM, its vmi and its boot volume data volume. The vmi and the data volume
+// are absent only once they aren't found, the other errors of the infra-cluster are returned, so the state of the
+// machine isn't synced from a vmi or a data volume which couldn't be read.
 func (m *manager) syncMachine(vm *kubevirtapiv1.VirtualMachine, machineScope *machineScope) error {
 	vmi, err := m.getInraClusterVMI(vm.Name, vm.Namespace, machineScope)
 	if err != nil {
-		klog.Errorf("%s: error getting vmi for machine: %v", machineScope.getMachineName(), err)
+		if !infracluster.IsNotFound(err) {
+			machineScope.logger().Error(err, "error getting vmi for machine")
+			return infracluster.ToMachineError(fmt.Errorf("failed to get VMI: %w", err), machineScope.getMachineName(), machinecontroller.UpdateMachine)
+		}
+		vmi = nil
+	}
+	machineScope.trackVMIRestarts(vm, time.Now())
+	dv, err := m.getInfraClusterDataVolume(buildBootVolumeName(vm.Name), vm.Namespace, machineScope)
+	if err != nil {
+		if !infracluster.IsNotFound(err) {
+			machineScope.logger().Error(err, "error getting boot volume data volume for machine")
+			return infracluster.ToMachineError(fmt.Errorf("failed to get boot volume data volume: %w", err), machineScope.getMachineName(), machinecontroller.UpdateMachine)
+		}
+		dv = nil
 	}
-	if err := machineScope.SyncMachineFromVm(vm, vmi); err != nil {
-		klog.Errorf("%s: fail syncing machine from vm: %v", machineScope.getMachineName(), err)
+	if err := m.syncPowerState(vm, vmi, machineScope); err != nil {
+		machineScope.logger().Error(err, "fail syncing vm power state")
+		return err
+	}
+	if err := m.syncMigration(vmi, machineScope); err != nil {
+		machineScope.logger().Error(err, "fail syncing vmi migration")
+		return err
+	}
+	previousState := machineScope.machineProviderStatus.InstanceState
+	if err := machineScope.SyncMachineFromVm(vm, vmi, dv); err != nil {
+		machineScope.logger().Error(err, "fail syncing machine from vm")
+		return err
+	}
+	m.recordInstanceStateEvent(previousState, machineScope)
+	traceInstanceState(previousState, machineScope)
+	if err := m.syncRestart(vm, vmi, machineScope); err != nil {
+		machineScope.logger().Error(err, "fail applying vm changes to vmi")
 		return err
 	}
 	return nil
 }
 
 // exists returns true if machine exists.
-func (m *manager) Exists(machine *machinev1.Machine) (bool, error) {
-	machineScope, err := newMachineScope(machine, m.tenantClusterClient, m.infraClusterClientBuilder)
+// The machine exists as long as its VM does, also when the VM is powered off and has no vmi.
+func (m *manager) Exists(ctx context.Context, machine *machinev1.Machine) (bool, error) {
+	machineScope, err := newMachineScope(ctx, machine, m.tenantClusterClient, m.infraClusterClientBuilder)
 	if err != nil {
 		return false, err
 	}
 
-	klog.Infof("%s: check if machine exists", machineScope.getMachineName())
+	machineScope.logger().Info("check if machine exists")
 	existingVM, err := m.getInraClusterVM(machine.GetName(), machineScope.vmNamespace, machineScope)
 	if err != nil {
-		// TODO ask Nir how to check it
-		if strings.Contains(err.Error(), "not found") {
-			klog.Infof("%s: VM does not exist", machineScope.getMachineName())
+		if infracluster.IsNotFound(err) {
+			machineScope.logger().Info("VM does not exist")
 			return false, nil
 		}
-		klog.Errorf("%s: error getting existing VM: %v", machineScope.getMachineName(), err)
+		machineScope.logger().Error(err, "error getting existing VM")
 		return false, err
 	}
 
 	if existingVM == nil {
-		klog.Infof("%s: VM does not exist", machineScope.getMachineName())
+		machineScope.logger().Info("VM does not exist")
 		return false, nil
 	}
 
@@ -239,23 +363,27 @@ func (m *manager) Exists(machine *machinev1.Machine) (bool, error) {
 }
 
 func (m *manager) createInfraClusterVM(virtualMachine *kubevirtapiv1.VirtualMachine, machineScope *machineScope) (*kubevirtapiv1.VirtualMachine, error) {
-	return machineScope.infraClusterClient.CreateVirtualMachine(virtualMachine.Namespace, virtualMachine)
+	return machineScope.infraClusterClient.CreateVirtualMachine(machineScope.ctx, virtualMachine.Namespace, virtualMachine)
 }
 
 func (m *manager) getInraClusterVM(vmName, vmNamespace string, machineScope *machineScope) (*kubevirtapiv1.VirtualMachine, error) {
-	return machineScope.infraClusterClient.GetVirtualMachine(vmNamespace, vmName, &k8smetav1.GetOptions{})
+	return machineScope.infraClusterClient.GetVirtualMachine(machineScope.ctx, vmNamespace, vmName, &k8smetav1.GetOptions{})
 }
 func (m *manager) getInraClusterVMI(vmName, vmNamespace string, machineScope *machineScope) (*kubevirtapiv1.VirtualMachineInstance, error) {
-	return machineScope.infraClusterClient.GetVirtualMachineInstance(vmNamespace, vmName, &k8smetav1.GetOptions{})
+	return machineScope.infraClusterClient.GetVirtualMachineInstance(machineScope.ctx, vmNamespace, vmName, &k8smetav1.GetOptions{})
+}
+
+func (m *manager) getInfraClusterDataVolume(dvName, dvNamespace string, machineScope *machineScope) (*cdiv1.DataVolume, error) {
+	return machineScope.infraClusterClient.GetDataVolume(machineScope.ctx, dvNamespace, dvName, &k8smetav1.GetOptions{})
 }
 
 func (m *manager) deleteInraClusterVM(vmName, vmNamespace string, machineScope *machineScope) error {
 	gracePeriod := int64(10)
-	return machineScope.infraClusterClient.DeleteVirtualMachine(vmNamespace, vmName, &k8smetav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	return machineScope.infraClusterClient.DeleteVirtualMachine(machineScope.ctx, vmNamespace, vmName, &k8smetav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
 }
 
-func (m *manager) updateInraClusterVM(updatedVM *kubevirtapiv1.VirtualMachine, machineScope *machineScope) (*kubevirtapiv1.VirtualMachine, error) {
-	return machineScope.infraClusterClient.UpdateVirtualMachine(updatedVM.Namespace, updatedVM)
+func (m *manager) applyInfraClusterVM(appliedVM *kubevirtapiv1.VirtualMachine, machineScope *machineScope) (*kubevirtapiv1.VirtualMachine, error) {
+	return machineScope.infraClusterClient.ApplyVirtualMachine(machineScope.ctx, appliedVM.Namespace, appliedVM, vmFieldManager)
 }
 
 // isMaster returns true if the machine is part of a cluster's control plane
@@ -282,14 +410,43 @@ func (m *manager) isMaster() (bool, error) {
 	return false, nil
 }
 
-func (m *manager) requeueIfInstancePending(vm *kubevirtapiv1.VirtualMachine, machineName string) error {
+func (m *manager) requeueIfInstancePending(machineScope *machineScope) error {
 	// If machine state is still pending, we will return an error to keep the controllers
 	// attempting to update status until it hits a more permanent state. This will ensure
 	// we get a public IP populated more quickly.
-	if !vm.Status.Ready {
-		klog.Infof("%s: VM status is not ready, returning an error to requeue", machineName)
+	state := machineState(machineScope.machineProviderStatus.InstanceState)
+	if isInstancePending(state) {
+		machineScope.logger().Info("machine instance is pending, returning an error to requeue", "state", state)
 		return &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterSeconds * time.Second}
 	}
 
 	return nil
 }
+
+// traceInstanceState adds the transition of the machine instance state to the span of the machine operation,
+// which tells the time spent provisioning the boot volume from the time spent booting the VMI
+func traceInstanceState(previousState string, machineScope *machineScope) {
+	status := machineScope.machineProviderStatus
+	if status.InstanceState == previousState {
+		return
+	}
+	tracing.AddEvent(machineScope.ctx, "InstanceStateChanged",
+		attribute.String("previousState", previousState),
+		attribute.String("state", status.InstanceState),
+		attribute.String("vmiPhase", string(status.VMIPhase)),
+		attribute.String("dataVolumePhase", string(status.DataVolumePhase)),
+	)
+}
+
+// recordInstanceStateEvent emits a warning event when the machine instance becomes unhealthy
+func (m *manager) recordInstanceStateEvent(previousState string, machineScope *machineScope) {
+	state := machineScope.machineProviderStatus.InstanceState
+	if state == previousState {
+		return
+	}
+	condition := findProviderCondition(machineScope.machineProviderStatus.Conditions, instanceHealthyCondition)
+	if condition == nil || condition.Status != corev1.ConditionFalse {
+		return
+	}
+	m.eventRecorder.Eventf(machineScope.machine, corev1.EventTypeWarning, "InstanceUnhealthy", "%s: %s", condition.Reason, condition.Message)
+}
@@ -1,13 +1,18 @@
 package vm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
 
-	kubevirtapiv1 "kubevirt.io/client-go/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	kubevirtapiv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 
 	"github.com/golang/mock/gomock"
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/infracluster"
@@ -15,6 +20,7 @@ import (
 	"github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster"
 	mockTenantClusterClient "github.com/openshift/cluster-api-provider-kubevirt/pkg/clients/tenantcluster/mock"
 	"gotest.tools/assert"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -95,7 +101,7 @@ func TestCreate(t *testing.T) {
 				t.Fatalf("Unable to create the stub machine object")
 			}
 
-			infraClusterClientMockBuilder := func(tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
 				return newMockInfraClusterClient, nil
 			}
 
@@ -111,17 +117,20 @@ func TestCreate(t *testing.T) {
 			returnVM.Status.Ready = tc.wantVMToBeReady
 
 			// TODO: test negative flow, return err != nil
-			newMockInfraClusterClient.EXPECT().CreateVirtualMachine(clusterID, virtualMachine).Return(returnVM, tc.ClientCreateVMError).AnyTimes()
-			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
-
-			newMockTenantClusterClient.EXPECT().PatchMachine(machine, machine.DeepCopy()).Return(nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().StatusPatchMachine(machine, machine.DeepCopy()).Return(nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetSecret(workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetNamespace().Return(clusterNamespace, nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetInfraID().Return(infraID, nil).AnyTimes()
-
-			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient)
-			err = providerVMInstance.Create(machine)
+			newMockInfraClusterClient.EXPECT().CreateVirtualMachine(gomock.Any(), clusterID, virtualMachine).Return(returnVM, tc.ClientCreateVMError).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetSecret(gomock.Any(), clusterID, buildUserDataSecretName(virtualMachine.Name), gomock.Any()).Return(nil, stubNotFoundError()).AnyTimes()
+			newMockInfraClusterClient.EXPECT().CreateSecret(gomock.Any(), clusterID, gomock.Any()).Return(&corev1.Secret{}, nil).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetDataVolume(gomock.Any(), clusterID, buildBootVolumeName(virtualMachine.Name), gomock.Any()).Return(stubDataVolume(cdiv1.Succeeded), nil).AnyTimes()
+
+			newMockTenantClusterClient.EXPECT().PatchMachine(gomock.Any(), machine, machine.DeepCopy()).Return(nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().StatusPatchMachine(gomock.Any(), machine, machine.DeepCopy()).Return(nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetNamespace(gomock.Any()).Return(clusterNamespace, nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetInfraID(gomock.Any()).Return(infraID, nil).AnyTimes()
+
+			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient, record.NewFakeRecorder(10))
+			err = providerVMInstance.Create(context.Background(), machine)
 			if tc.wantValidateMachineErr != "" {
 				assert.Equal(t, tc.wantValidateMachineErr, err.Error())
 			} else if tc.wantCreateVMErr != "" {
@@ -228,7 +237,7 @@ func TestDelete(t *testing.T) {
 				t.Fatalf("Unable to create the stub machine object")
 			}
 
-			infraClusterClientMockBuilder := func(tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
 				return newMockInfraClusterClient, nil
 			}
 
@@ -246,20 +255,21 @@ func TestDelete(t *testing.T) {
 			}
 
 			//InfraCluster mocks
-			newMockInfraClusterClient.EXPECT().GetVirtualMachine(clusterID, virtualMachine.Name, gomock.Any()).Return(returnVM, tc.clientGetVMError).AnyTimes()
-			newMockInfraClusterClient.EXPECT().DeleteVirtualMachine(clusterID, virtualMachine.Name, gomock.Any()).Return(tc.clientDeleteVMError).AnyTimes()
-			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetVirtualMachine(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(returnVM, tc.clientGetVMError).AnyTimes()
+			newMockInfraClusterClient.EXPECT().DeleteVirtualMachine(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(tc.clientDeleteVMError).AnyTimes()
+			newMockInfraClusterClient.EXPECT().DeleteSecret(gomock.Any(), clusterID, buildUserDataSecretName(virtualMachine.Name), gomock.Any()).Return(nil).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
 
 			//TenantCluster mocks
 			// TODO: test negative flow, return err != nil
-			newMockTenantClusterClient.EXPECT().PatchMachine(machine, machine.DeepCopy()).Return(nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().StatusPatchMachine(machine, machine.DeepCopy()).Return(nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetSecret(workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetNamespace().Return("kubevirt-actuator-cluster", nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetInfraID().Return(infraID, nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().PatchMachine(gomock.Any(), machine, machine.DeepCopy()).Return(nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().StatusPatchMachine(gomock.Any(), machine, machine.DeepCopy()).Return(nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetNamespace(gomock.Any()).Return("kubevirt-actuator-cluster", nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetInfraID(gomock.Any()).Return(infraID, nil).AnyTimes()
 
-			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient)
-			err = providerVMInstance.Delete(machine)
+			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient, record.NewFakeRecorder(10))
+			err = providerVMInstance.Delete(context.Background(), machine)
 
 			// getServicErr
 			// deleteServiceErr
@@ -334,7 +344,7 @@ func TestExists(t *testing.T) {
 				t.Fatalf("Unable to create the stub machine object")
 			}
 
-			infraClusterClientMockBuilder := func(tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
 				return newMockInfraClusterClient, nil
 			}
 
@@ -352,14 +362,14 @@ func TestExists(t *testing.T) {
 			}
 
 			//InfraCluster mocks
-			newMockInfraClusterClient.EXPECT().GetVirtualMachine(clusterID, virtualMachine.Name, gomock.Any()).Return(returnVM, tc.clientGetError).AnyTimes()
-			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetSecret(workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetNamespace().Return("kubevirt-actuator-cluster", nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetInfraID().Return(infraID, nil).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetVirtualMachine(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(returnVM, tc.clientGetError).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetNamespace(gomock.Any()).Return("kubevirt-actuator-cluster", nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetInfraID(gomock.Any()).Return(infraID, nil).AnyTimes()
 
-			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient)
-			existsVM, err := providerVMInstance.Exists(machine)
+			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient, record.NewFakeRecorder(10))
+			existsVM, err := providerVMInstance.Exists(context.Background(), machine)
 
 			if tc.clientGetError != nil {
 				assert.Equal(t, tc.clientGetError.Error(), err.Error())
@@ -383,11 +393,17 @@ func TestUpdate(t *testing.T) {
 		wantUpdateVMErr                 string
 		clientGetVMError                error
 		clientUpdateVMError             error
+		clientGetVMIError               error
+		wantSyncErr                     string
 		emptyGetVM                      bool
 		labels                          map[string]string
 		providerID                      string
 		wantVMToBeReady                 bool
 		useDefaultCredentialsSecretName bool
+		alreadyApplied                  bool
+		drifted                         bool
+		machineAnnotations              map[string]string
+		wantWasUpdated                  bool
 	}{
 		{
 			name:                   "Update a VM",
@@ -399,6 +415,40 @@ func TestUpdate(t *testing.T) {
 			labels:                 nil,
 			providerID:             formatProviderID(defaultNamespace, mahcineName),
 			wantVMToBeReady:        true,
+			wantWasUpdated:         true,
+		},
+		{
+			name:                   "Update a VM that is already up to date",
+			wantValidateMachineErr: "",
+			wantUpdateVMErr:        "",
+			clientGetVMError:       nil,
+			clientUpdateVMError:    nil,
+			emptyGetVM:             false,
+			labels:                 nil,
+			providerID:             formatProviderID(defaultNamespace, mahcineName),
+			wantVMToBeReady:        true,
+			alreadyApplied:         true,
+			wantWasUpdated:         false,
+		},
+		{
+			name:            "Update a VM that is already up to date but was changed by another actor",
+			providerID:      formatProviderID(defaultNamespace, mahcineName),
+			wantVMToBeReady: true,
+			alreadyApplied:  true,
+			drifted:         true,
+			wantWasUpdated:  true,
+		},
+		{
+			name:            "Update a VM whose machine annotations of the controller changed",
+			emptyGetVM:      false,
+			providerID:      formatProviderID(defaultNamespace, mahcineName),
+			wantVMToBeReady: true,
+			alreadyApplied:  true,
+			machineAnnotations: map[string]string{
+				kubevirtIdAnnotationKey:                              "vm-uid",
+				machinecontroller.MachineInstanceStateAnnotationName: string(vmiRunning),
+			},
+			wantWasUpdated: false,
 		},
 		{
 			name:                            "Update a VM, use default CredentialsSecretName",
@@ -411,19 +461,19 @@ func TestUpdate(t *testing.T) {
 			providerID:                      formatProviderID(defaultNamespace, mahcineName),
 			wantVMToBeReady:                 true,
 			useDefaultCredentialsSecretName: true,
+			wantWasUpdated:                  true,
+		},
+		{
+			name:                   "Update a VM that never be ready",
+			wantValidateMachineErr: "",
+			wantUpdateVMErr:        "",
+			clientGetVMError:       nil,
+			clientUpdateVMError:    nil,
+			emptyGetVM:             false,
+			labels:                 nil,
+			providerID:             "",
+			wantVMToBeReady:        false,
 		},
-		// TODO: enable that test after pushing the PR: https://github.com/kubevirt/kubevirt/pull/3889 so update wouldn't override the vm Status
-		//{
-		//	name:                   "Update a VM that never be ready",
-		//	wantValidateMachineErr: "",
-		//	wantUpdateVMErr:        "",
-		//	clientGetVMError:       nil,
-		//	clientUpdateVMError:    nil,
-		//	emptyGetVM:             false,
-		//	labels:                 nil,
-		//	providerID:             "",
-		//	wantVMToBeReady:        false,
-		//},
 		{
 			name:                   "Update a VM from unlabeled machine and fail",
 			wantValidateMachineErr: fmt.Sprintf("%s: failed validating machine provider spec: %v: missing %q label", mahcineName, mahcineName, machinev1.MachineClusterIDLabel),
@@ -446,6 +496,27 @@ func TestUpdate(t *testing.T) {
 			providerID:             "",
 			wantVMToBeReady:        false,
 		},
+		{
+			name:              "Update a VM with getting vmi error and fail",
+			clientGetVMIError: errors.New("client error"),
+			wantSyncErr:       "failed to get VMI: client error",
+			providerID:        formatProviderID(defaultNamespace, mahcineName),
+			wantVMToBeReady:   true,
+		},
+		{
+			name:              "Update a VM with an unavailable infra-cluster getting vmi and requeue",
+			clientGetVMIError: apimachineryerrors.NewServiceUnavailable("unavailable"),
+			wantSyncErr:       "requeue in: 20s",
+			providerID:        formatProviderID(defaultNamespace, mahcineName),
+			wantVMToBeReady:   true,
+		},
+		{
+			name:              "Update a VM whose vmi is not found and requeue it as pending",
+			clientGetVMIError: stubNotFoundError(),
+			wantSyncErr:       "requeue in: 20s",
+			providerID:        formatProviderID(defaultNamespace, mahcineName),
+			wantVMToBeReady:   true,
+		},
 		{
 			name:                   "Update a nonexistent VM and fail",
 			wantValidateMachineErr: "",
@@ -480,8 +551,11 @@ func TestUpdate(t *testing.T) {
 			if machine == nil {
 				t.Fatalf("Unable to create the stub machine object")
 			}
+			if tc.machineAnnotations != nil {
+				machine.Annotations = tc.machineAnnotations
+			}
 
-			infraClusterClientMockBuilder := func(tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
+			infraClusterClientMockBuilder := func(ctx context.Context, tenantClusterClient tenantcluster.Client, secretName, namespace string) (infracluster.Client, error) {
 				return newMockInfraClusterClient, nil
 			}
 
@@ -492,6 +566,10 @@ func TestUpdate(t *testing.T) {
 
 			virtualMachine := stubVirtualMachine(machineScope)
 			vmi, _ := stubVmi(virtualMachine)
+			vmi.Status.Phase = kubevirtapiv1.Scheduling
+			if tc.wantVMToBeReady {
+				vmi.Status.Phase = kubevirtapiv1.Running
+			}
 			var getReturnVM *kubevirtapiv1.VirtualMachine
 			if !tc.emptyGetVM {
 				returnVMResult := stubVirtualMachine(machineScope)
@@ -506,25 +584,55 @@ func TestUpdate(t *testing.T) {
 			}
 
 			updateReturnVM := stubVirtualMachine(machineScope)
+			updateReturnVM.ResourceVersion = "2"
 			updateReturnVM.Status = kubevirtapiv1.VirtualMachineStatus{
 				Created: true,
 				Ready:   tc.wantVMToBeReady,
 			}
 
-			newMockInfraClusterClient.EXPECT().GetVirtualMachine(clusterID, virtualMachine.Name, gomock.Any()).Return(getReturnVM, tc.clientGetVMError).AnyTimes()
-			newMockInfraClusterClient.EXPECT().UpdateVirtualMachine(clusterID, getReturnVM).Return(updateReturnVM, tc.clientUpdateVMError).AnyTimes()
-			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, nil).AnyTimes()
+			applyTimes := 1
+			if tc.wantValidateMachineErr != "" || tc.clientGetVMError != nil || tc.emptyGetVM {
+				applyTimes = 0
+			}
+			if tc.alreadyApplied && !tc.drifted {
+				// Applying the fields of an up to date VM doesn't update it
+				updateReturnVM.ResourceVersion = getReturnVM.ResourceVersion
+			}
+
+			newMockInfraClusterClient.EXPECT().GetVirtualMachine(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(getReturnVM, tc.clientGetVMError).AnyTimes()
+			newMockInfraClusterClient.EXPECT().ApplyVirtualMachine(gomock.Any(), clusterID, gomock.Any(), vmFieldManager).Return(updateReturnVM, tc.clientUpdateVMError).Times(applyTimes)
+			newMockInfraClusterClient.EXPECT().GetSecret(gomock.Any(), clusterID, buildUserDataSecretName(virtualMachine.Name), gomock.Any()).Return(nil, stubNotFoundError()).AnyTimes()
+			newMockInfraClusterClient.EXPECT().CreateSecret(gomock.Any(), clusterID, gomock.Any()).Return(&corev1.Secret{}, nil).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetVirtualMachineInstance(gomock.Any(), clusterID, virtualMachine.Name, gomock.Any()).Return(vmi, tc.clientGetVMIError).AnyTimes()
+			newMockInfraClusterClient.EXPECT().GetDataVolume(gomock.Any(), clusterID, buildBootVolumeName(virtualMachine.Name), gomock.Any()).Return(stubDataVolume(cdiv1.Succeeded), nil).AnyTimes()
 
 			// TODO: test negative flow, return err != nil
-			newMockTenantClusterClient.EXPECT().PatchMachine(machine, machine.DeepCopy()).Return(nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().StatusPatchMachine(machine, machine.DeepCopy()).Return(nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetSecret(workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetNamespace().Return("kubevirt-actuator-cluster", nil).AnyTimes()
-			newMockTenantClusterClient.EXPECT().GetInfraID().Return(infraID, nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().PatchMachine(gomock.Any(), machine, machine.DeepCopy()).Return(nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().StatusPatchMachine(gomock.Any(), machine, machine.DeepCopy()).Return(nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetSecret(gomock.Any(), workerUserDataSecretName, machine.Namespace).Return(stubSecret(), nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetNamespace(gomock.Any()).Return("kubevirt-actuator-cluster", nil).AnyTimes()
+			newMockTenantClusterClient.EXPECT().GetInfraID(gomock.Any()).Return(infraID, nil).AnyTimes()
+
+			if tc.alreadyApplied {
+				// The VM was applied before the machine annotations were set
+				appliedScope := *machineScope
+				appliedScope.machine = machine.DeepCopy()
+				appliedScope.machine.Annotations = nil
+				appliedScope.vmNamespace = clusterNamespace
+				appliedScope.infraID = infraID
+				renderedVM, err := appliedScope.createVirtualMachineFromMachine()
+				if err != nil {
+					t.Fatalf("Unable to render virtual machine with error: %v", err)
+				}
+				renderedHash, err := hashVirtualMachine(renderedVM)
+				if err != nil {
+					t.Fatalf("Unable to hash virtual machine with error: %v", err)
+				}
+				getReturnVM.Annotations = map[string]string{appliedHashAnnotationKey: renderedHash}
+			}
 
-			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient)
-			// TODO: test the bool wasUpdated
-			_, err = providerVMInstance.Update(machine)
+			providerVMInstance := New(infraClusterClientMockBuilder, newMockTenantClusterClient, record.NewFakeRecorder(10))
+			wasUpdated, err := providerVMInstance.Update(context.Background(), machine)
 
 			if tc.wantValidateMachineErr != "" {
 				assert.Equal(t, tc.wantValidateMachineErr, err.Error())
@@ -532,6 +640,8 @@ func TestUpdate(t *testing.T) {
 				assert.Equal(t, tc.clientGetVMError.Error(), err.Error())
 			} else if tc.wantUpdateVMErr != "" {
 				assert.Equal(t, tc.wantUpdateVMErr, err.Error())
+			} else if tc.wantSyncErr != "" {
+				assert.Equal(t, tc.wantSyncErr, err.Error())
 			} else if tc.emptyGetVM {
 				assert.Equal(t, err.Error(), "requeue in: 3m0s")
 			} else if !tc.wantVMToBeReady {
@@ -539,6 +649,7 @@ func TestUpdate(t *testing.T) {
 			} else {
 				assert.Equal(t, err, nil)
 				assert.Equal(t, *machine.Spec.ProviderID, tc.providerID)
+				assert.Equal(t, wasUpdated, tc.wantWasUpdated)
 			}
 		})
 	}
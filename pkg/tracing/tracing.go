@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone doesn't export the spans, which aren't recorded
+	ExporterNone = "none"
+	// ExporterOTLP exports the spans to an OTLP gRPC endpoint
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes the spans to stdout, for local use
+	ExporterStdout = "stdout"
+)
+
+// The keys of the span attributes of a machine
+const (
+	MachineKey   = attribute.Key("machine.name")
+	NamespaceKey = attribute.Key("machine.namespace")
+)
+
+const (
+	serviceName = "kubevirt-machine-controller"
+	tracerName  = "github.com/openshift/cluster-api-provider-kubevirt"
+)
+
+// Config is the configuration of the span exporter
+type Config struct {
+	// Exporter is ExporterNone, ExporterOTLP or ExporterStdout
+	Exporter string
+	// OTLPEndpoint is the host:port of the OTLP gRPC endpoint, the exporter defaults apply when it's empty,
+	// like the OTEL_EXPORTER_OTLP_ENDPOINT environment variable
+	OTLPEndpoint string
+	// OTLPInsecure disables the TLS of the connection to the OTLP endpoint
+	OTLPInsecure bool
+}
+
+// Setup sets the global tracer provider, which exports the spans as configured by config,
+// and returns the function flushing the spans and stopping the exporter
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var options []otlptracegrpc.Option
+		if config.OTLPEndpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(config.OTLPEndpoint))
+		}
+		if config.OTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter %q, expected %q, %q or %q", config.Exporter, ExporterNone, ExporterOTLP, ExporterStdout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s tracing exporter: %w", config.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts the span name, a child of the span of ctx if any, and returns a copy of ctx carrying it
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan ends span, which failed if err isn't nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// AddEvent adds the event name to the span of ctx, if any
+func AddEvent(ctx context.Context, name string, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(attributes...))
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gotest.tools/assert"
+)
+
+func TestSetup(t *testing.T) {
+	cases := []struct {
+		name     string
+		exporter string
+		wantErr  string
+	}{
+		{
+			name:     "No exporter",
+			exporter: ExporterNone,
+		},
+		{
+			name:     "Stdout exporter",
+			exporter: ExporterStdout,
+		},
+		{
+			name:     "Invalid exporter",
+			exporter: "jaeger",
+			wantErr:  `invalid tracing exporter "jaeger", expected "none", "otlp" or "stdout"`,
+		},
+	}
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), Config{Exporter: tc.exporter})
+			if tc.wantErr != "" {
+				assert.Error(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.NilError(t, shutdown(context.Background()))
+		})
+	}
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := StartSpan(context.Background(), "actuator.Create", MachineKey.String("machine-test"))
+	childCtx, child := StartSpan(ctx, "infracluster.CreateVirtualMachine")
+	AddEvent(childCtx, "Created", attribute.String("vm", "machine-test"))
+	EndSpan(child, errors.New("connection refused"))
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	assert.Equal(t, len(spans), 2)
+
+	assert.Equal(t, spans[0].Name(), "infracluster.CreateVirtualMachine")
+	assert.Equal(t, spans[0].Parent().SpanID(), spans[1].SpanContext().SpanID())
+	assert.Equal(t, spans[0].Status().Code, codes.Error)
+	assert.Equal(t, spans[0].Status().Description, "connection refused")
+	events := spans[0].Events()
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Name, "Created")
+	assert.Equal(t, events[1].Name, "exception")
+
+	assert.Equal(t, spans[1].Name(), "actuator.Create")
+	assert.Equal(t, spans[1].Status().Code, codes.Unset)
+	attributes := spans[1].Attributes()
+	assert.Equal(t, len(attributes), 1)
+	assert.Equal(t, attributes[0].Key, MachineKey)
+	assert.Equal(t, attributes[0].Value.AsString(), "machine-test")
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout returns a context done after timeout, or a cancelable copy of ctx without timeout
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// CallWithTimeout returns the result of call, made with a client which doesn't take a context, or the error of ctx
+// once it's canceled or timeout passed. The call then keeps running until the timeout of its client.
+func CallWithTimeout(ctx context.Context, timeout time.Duration, call func() (interface{}, error)) (interface{}, error) {
+	ctx, cancel := WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestCallWithTimeout(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	hung := make(chan struct{})
+	defer close(hung)
+
+	cases := []struct {
+		name       string
+		ctx        context.Context
+		timeout    time.Duration
+		call       func() (interface{}, error)
+		want       interface{}
+		wantErr    error
+		wantNoCall bool
+	}{
+		{
+			name:    "Return the result of the call",
+			ctx:     context.Background(),
+			timeout: time.Second,
+			call:    func() (interface{}, error) { return "result", nil },
+			want:    "result",
+		},
+		{
+			name:    "Return the error of the call without timeout",
+			ctx:     context.Background(),
+			call:    func() (interface{}, error) { return nil, errors.New("call error") },
+			wantErr: errors.New("call error"),
+		},
+		{
+			name:    "Time out a hung call",
+			ctx:     context.Background(),
+			timeout: 10 * time.Millisecond,
+			call: func() (interface{}, error) {
+				<-hung
+				return "late", nil
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:       "Skip the call of a canceled context",
+			ctx:        canceled,
+			timeout:    time.Second,
+			call:       func() (interface{}, error) { return "result", nil },
+			wantErr:    context.Canceled,
+			wantNoCall: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := make(chan struct{}, 1)
+			call := tc.call
+			got, err := CallWithTimeout(tc.ctx, tc.timeout, func() (interface{}, error) {
+				calls <- struct{}{}
+				return call()
+			})
+			if tc.wantErr != nil {
+				assert.Error(t, err, tc.wantErr.Error())
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, got, tc.want)
+			if tc.wantNoCall {
+				assert.Equal(t, len(calls), 0)
+			}
+		})
+	}
+}